@@ -32,42 +32,52 @@ func randomInRange(min, max int) int {
 	return min + int(nextRandom(0)%uint64(rangeSize))
 }
 
-// /fibonacci?n=<n>
-func FibonacciHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	val, ok := req.Params["n"]
-	if !ok || val == "" {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'n'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+// maxFibonacciN es el máximo de términos de /fibonacci; por encima se responde 413
+const maxFibonacciN = 1000
+
+// Parámetros de cada ruta: los valida Bind y los publica la especificación OpenAPI
+var (
+	fibonacciParams = []server.Param{
+		server.IntParam("n", "Cantidad de términos (más de 1000 responde 413)").AtLeast(1).Require().WithExample("10"),
+	}
+	createFileParams = []server.Param{
+		server.StringParam("name", "Nombre del archivo").Require(),
+		server.StringParam("content", "Contenido de cada línea").Require().AllowingEmpty(),
+		server.IntParam("repeat", "Cantidad de repeticiones").AtLeast(1).Require(),
+	}
+	deleteFileParams = []server.Param{
+		server.StringParam("name", "Nombre del archivo").Require(),
+	}
+	textParams = []server.Param{
+		server.StringParam("text", "Texto de entrada").Require().AllowingEmpty().WithExample("hola"),
+	}
+	randomParams = []server.Param{
+		server.IntParam("min", "Límite inferior").Require().WithExample("1"),
+		server.IntParam("max", "Límite superior").Require().WithExample("100"),
 	}
+	simulateParams = []server.Param{
+		server.IntParam("seconds", "Duración").Between(1, 10).Require(),
+		server.StringParam("task", "Nombre de la tarea").Require().AllowingEmpty(),
+	}
+	sleepParams = []server.Param{
+		server.IntParam("seconds", "Duración").Between(1, 60).Require(),
+	}
+	loadTestParams = []server.Param{
+		server.IntParam("tasks", "Cantidad de tareas").Between(1, 100).Require(),
+		server.IntParam("sleep", "Espera por tarea en ms").Between(0, 1000).Require(),
+	}
+)
 
-	const maxN = 1000
-	n, _ := strconv.Atoi(val)
-	if n > maxN {
-		return &server.HTTPResponse{
-			StatusCode: 413,
-			StatusText: "Payload Too Large",
-			Body:       `{"error":"n too large; maximum allowed is 1000"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+// /fibonacci?n=<n>
+func FibonacciHandler(req *server.HTTPRequest) *server.HTTPResponse {
+	params, errResp := server.Bind(req, fibonacciParams...)
+	if errResp != nil {
+		return errResp
 	}
 
-	if n <= 0 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"n must be greater than 0"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	n := params.Int("n")
+	if n > maxFibonacciN {
+		return server.PayloadTooLarge("n too large; maximum allowed is 1000")
 	}
 
 	fibSeq := make([]int, n)
@@ -83,72 +93,33 @@ func FibonacciHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		fibSeq[i] = fibSeq[i-1] + fibSeq[i-2]
 	}
 
-	jsonData, _ := json.MarshalIndent(fibSeq, "", "  ")
-
-	return &server.HTTPResponse{
-		StatusCode: 200,
-		StatusText: "OK",
-		Body:       string(jsonData),
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
+	// En CSV y texto cada término va con su índice
+	rows := make([][]int, n)
+	for i, value := range fibSeq {
+		rows[i] = []int{i, value}
 	}
+	return server.NewResult(fibSeq).Table([]string{"index", "value"}, rows).Response()
 }
 
 // /createFile?name=filename&content=text&repeat=x
 func CreateFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	name, nameOk := req.Params["name"]
-	content, contentOk := req.Params["content"]
-	repeatStr, repeatOk := req.Params["repeat"]
-
-	if !nameOk || !contentOk || !repeatOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameters"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	repeat, err := strconv.Atoi(repeatStr)
-	if err != nil || repeat < 1 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid repeat parameter"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, createFileParams...)
+	if errResp != nil {
+		return errResp
 	}
+	name, content, repeat := params.String("name"), params.String("content"), params.Int("repeat")
 
 	// Crear archivo
 	file, err := os.Create(name)
 	if err != nil {
-		return &server.HTTPResponse{
-			StatusCode: 500,
-			StatusText: "Internal Server Error",
-			Body:       `{"error":"failed to create file"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+		return server.InternalError("failed to create file")
 	}
 	defer file.Close()
 
 	for i := 0; i < repeat; i++ {
 		_, err := file.WriteString(content + "\n")
 		if err != nil {
-			return &server.HTTPResponse{
-				StatusCode: 500,
-				StatusText: "Internal Server Error",
-				Body:       `{"error":"failed to write to file"}`,
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-			}
+			return server.InternalError("failed to write to file")
 		}
 	}
 
@@ -164,28 +135,15 @@ func CreateFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /deleteFile?name=filename
 func DeleteFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	name, nameOk := req.Params["name"]
-	if !nameOk || name == "" {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'name'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, deleteFileParams...)
+	if errResp != nil {
+		return errResp
 	}
+	name := params.String("name")
 
 	err := os.Remove(name)
 	if err != nil {
-		return &server.HTTPResponse{
-			StatusCode: 500,
-			StatusText: "Internal Server Error",
-			Body:       `{"error":"failed to delete file"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+		return server.InternalError("failed to delete file")
 	}
 
 	return &server.HTTPResponse{
@@ -200,17 +158,11 @@ func DeleteFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /reverse?text=yourtext
 func ReverseHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	text, textOk := req.Params["text"]
-	if !textOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'text'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, textParams...)
+	if errResp != nil {
+		return errResp
 	}
+	text := params.String("text")
 
 	runes := []rune(text)
 	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
@@ -230,17 +182,11 @@ func ReverseHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /toupper?text=yourtext
 func ToUpperHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	text, textOk := req.Params["text"]
-	if !textOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'text'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, textParams...)
+	if errResp != nil {
+		return errResp
 	}
+	text := params.String("text")
 
 	upper := strings.ToUpper(text)
 	jsonData, _ := json.MarshalIndent(map[string]string{"upper": upper}, "", "  ")
@@ -257,55 +203,18 @@ func ToUpperHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /random?min=x&max=y
 func RandomNumberHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	minStr, minOk := req.Params["min"]
-	maxStr, maxOk := req.Params["max"]
-	if !minOk || !maxOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameters 'min' and 'max'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, randomParams...)
+	if errResp != nil {
+		return errResp
 	}
+	min, max := params.Int("min"), params.Int("max")
 
-	// Parsear los valores min y max
-	min, err := strconv.Atoi(minStr)
-	if err != nil {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid 'min' parameter - must be an integer"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	max, err := strconv.Atoi(maxStr)
-	if err != nil {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid 'max' parameter - must be an integer"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	// Validar rango razonable
+	// Validar rango razonable: depende de los dos parámetros, así que no entra en la especificación
 	const maxRange = 1000000
 	if max-min > maxRange {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"range too large - maximum allowed range is 1,000,000"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+		return server.InvalidParamsResponse([]server.ParamError{
+			{Name: "max", Reason: "must be at most min + 1000000"},
+		})
 	}
 
 	// Generar número aleatorio usando algoritmo LCG simple
@@ -334,17 +243,11 @@ func RandomNumberHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /hash?text=yourtext
 func HashHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	text, textOk := req.Params["text"]
-	if !textOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'text'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, textParams...)
+	if errResp != nil {
+		return errResp
 	}
+	text := params.String("text")
 
 	// Implementar hash DJB2 simple
 	hash := uint32(5381)
@@ -371,30 +274,11 @@ func HashHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /simulate?seconds=s&task=name
 func SimulateHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	secondsStr, secondsOk := req.Params["seconds"]
-	task, taskOk := req.Params["task"]
-	if !secondsOk || !taskOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameters 'seconds' and 'task'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	seconds, err := strconv.Atoi(secondsStr)
-	if err != nil || seconds < 1 || seconds > 10 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"seconds must be between 1 and 10"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, simulateParams...)
+	if errResp != nil {
+		return errResp
 	}
+	seconds, task := params.Int("seconds"), params.String("task")
 
 	startTime := time.Now()
 	targetDuration := time.Duration(seconds) * time.Second
@@ -430,29 +314,11 @@ func SimulateHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /sleep?seconds=s
 func SleepHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	secondsStr, secondsOk := req.Params["seconds"]
-	if !secondsOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'seconds'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	seconds, err := strconv.Atoi(secondsStr)
-	if err != nil || seconds < 1 || seconds > 60 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"seconds must be between 1 and 60"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, sleepParams...)
+	if errResp != nil {
+		return errResp
 	}
+	seconds := params.Int("seconds")
 
 	startTime := time.Now()
 	targetDuration := time.Duration(seconds) * time.Second
@@ -472,7 +338,7 @@ func SleepHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 	response := map[string]interface{}{
 		"status":          "completed",
-		"requested_sleep": secondsStr + "s",
+		"requested_sleep": strconv.Itoa(seconds) + "s",
 		"actual_duration": elapsed.String(),
 		"iterations":      iterations,
 		"method":          "busy-wait simulation",
@@ -493,42 +359,11 @@ func SleepHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // /loadtest?tasks=n&sleep=x
 func LoadTestHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	tasksStr, tasksOk := req.Params["tasks"]
-	sleepStr, sleepOk := req.Params["sleep"]
-	if !tasksOk || !sleepOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameters 'tasks' and 'sleep'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	tasks, err := strconv.Atoi(tasksStr)
-	if err != nil || tasks < 1 || tasks > 100 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"tasks must be between 1 and 100"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	sleepMs, err := strconv.Atoi(sleepStr)
-	if err != nil || sleepMs < 0 || sleepMs > 1000 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"sleep must be between 0 and 1000 milliseconds"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, loadTestParams...)
+	if errResp != nil {
+		return errResp
 	}
+	tasks, sleepMs := params.Int("tasks"), params.Int("sleep")
 
 	startTime := time.Now()
 	var totalOperations int64
@@ -556,7 +391,7 @@ func LoadTestHandler(req *server.HTTPRequest) *server.HTTPResponse {
 	response := map[string]interface{}{
 		"status":           "completed",
 		"total_tasks":      tasks,
-		"sleep_per_task":   sleepStr + "ms",
+		"sleep_per_task":   strconv.Itoa(sleepMs) + "ms",
 		"total_duration":   totalDuration.String(),
 		"total_operations": totalOperations,
 		"tasks_per_second": float64(tasks) / totalDuration.Seconds(),
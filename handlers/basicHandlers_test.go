@@ -357,7 +357,7 @@ func TestCreateFileHandlerEdgeCases(t *testing.T) {
 		{"High repeat", "test2.txt", "x", "1000", 201},
 		{"Zero repeat", "test3.txt", "content", "0", 400}, // Invalid repeat
 		{"Invalid repeat", "test4.txt", "content", "abc", 400},
-		{"Missing name", "", "content", "1", 500},                  // Internal error when name missing
+		{"Missing name", "", "content", "1", 400},                  // name es obligatorio
 		{"Missing content", "test5.txt", "", "1", 201},             // Allows empty content
 		{"Empty repeat defaults", "test6.txt", "content", "", 400}, // Empty repeat is invalid
 	}
@@ -2,38 +2,52 @@ package handlers
 
 import (
 	"GoDocker/server"
-	"encoding/json"
+	"context"
 	"fmt"
 	"math/big"
 	"os"
-	"strconv"
 )
 
-// /isprime?num=N
-func IsPrimeHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	numStr, ok := req.Params["num"]
-	if !ok || numStr == "" {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'num'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+// maxMatrixSize acota /matrixmul: el costo es cúbico y la respuesta incluye las tres matrices
+const maxMatrixSize = 200
+
+// Cotas de num: /isprime prueba divisores hasta √num y /factor recorre todos hasta num
+const (
+	maxPrimeCandidate = 1_000_000_000_000
+	maxFactorNum      = 10_000_000
+)
+
+// Parámetros de cada ruta: los valida Bind y los publica la especificación OpenAPI
+var (
+	isPrimeParams = []server.Param{
+		server.IntParam("num", "Número a evaluar").Between(2, maxPrimeCandidate).Require().WithExample("97"),
+	}
+	factorParams = []server.Param{
+		server.IntParam("num", "Número a factorizar").Between(1, maxFactorNum).Require().WithExample("12"),
+	}
+	piParams = []server.Param{
+		server.IntParam("digits", "Cantidad de dígitos").Between(1, 1000).Require().WithExample("50"),
 	}
+	mandelbrotParams = []server.Param{
+		server.IntParam("width", "Ancho en píxeles").Between(1, 2000).Require().WithExample("80"),
+		server.IntParam("height", "Alto en píxeles").Between(1, 2000).Require().WithExample("40"),
+		server.IntParam("max_iter", "Iteraciones máximas por píxel").Between(1, 1000).Require().WithExample("100"),
+		server.StringParam("filename", "Guardar la imagen como <filename>.pgm"),
+	}
+	matrixMulParams = []server.Param{
+		server.IntParam("size", "Dimensión de las matrices").Between(1, maxMatrixSize).Require().WithExample("3"),
+		// seed 0 haría que nextRandom use la hora y el resultado no sería determinista ni cacheable
+		server.IntParam("seed", "Semilla del generador").AtLeast(1).Require().WithExample("42"),
+	}
+)
 
-	num, err := strconv.Atoi(numStr)
-	if err != nil || num < 2 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid number; must be an integer >= 2"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+// /isprime?num=N
+func IsPrimeHandler(req *server.HTTPRequest) *server.HTTPResponse {
+	params, errResp := server.Bind(req, isPrimeParams...)
+	if errResp != nil {
+		return errResp
 	}
+	num := params.Int("num")
 
 	isPrime := true
 	for i := 2; i*i <= num; i++ {
@@ -47,43 +61,16 @@ func IsPrimeHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		"number":  num,
 		"isPrime": isPrime,
 	}
-	body, _ := json.Marshal(result)
-
-	return &server.HTTPResponse{
-		StatusCode: 200,
-		StatusText: "OK",
-		Body:       string(body),
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-	}
+	return server.NewResult(result).Response()
 }
 
 // /factor?num=N
 func FactorHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	numStr, ok := req.Params["num"]
-	if !ok || numStr == "" {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'num'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	num, err := strconv.Atoi(numStr)
-	if err != nil || num < 1 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid number; must be an integer >= 1"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, factorParams...)
+	if errResp != nil {
+		return errResp
 	}
+	num := params.Int("num")
 
 	factors := []int{}
 	for i := 1; i <= num; i++ {
@@ -96,26 +83,26 @@ func FactorHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		"number":  num,
 		"factors": factors,
 	}
-	body, _ := json.Marshal(result)
-
-	return &server.HTTPResponse{
-		StatusCode: 200,
-		StatusText: "OK",
-		Body:       string(body),
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-	}
+	return server.NewResult(result).Table([]string{"factor"}, factors).Response()
 }
 
 // computePiMachin calcula π usando la fórmula de Machin: π/4 = 4*arctan(1/5) - arctan(1/239)
 func computePiMachin(digits int) string {
+	pi, _ := computePiMachinContext(context.Background(), digits)
+	return pi
+}
+
+// computePiMachinContext es computePiMachin abortable: retorna el error del contexto si se cancela
+func computePiMachinContext(ctx context.Context, digits int) (string, error) {
 	// Configurar precisión
 	precision := uint(digits*4 + 100)
 
 	// Calcular arctan(1/5) y arctan(1/239) usando series de Taylor
-	arctan1_5 := arctanSeries(5, precision, digits*2)
-	arctan1_239 := arctanSeries(239, precision, digits*2)
+	arctan1_5 := arctanSeries(ctx, 5, precision, digits*2)
+	arctan1_239 := arctanSeries(ctx, 239, precision, digits*2)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
 	// π/4 = 4*arctan(1/5) - arctan(1/239)
 	piQuarter := big.NewFloat(0.0)
@@ -136,11 +123,11 @@ func computePiMachin(digits int) string {
 	pi.SetPrec(precision)
 	pi.Mul(four, piQuarter)
 
-	return pi.Text('f', digits)
+	return pi.Text('f', digits), nil
 }
 
-// arctanSeries calcula arctan(1/x) usando la serie de Taylor
-func arctanSeries(x int, precision uint, terms int) *big.Float {
+// arctanSeries calcula arctan(1/x) usando la serie de Taylor; deja de sumar términos si el contexto se cancela
+func arctanSeries(ctx context.Context, x int, precision uint, terms int) *big.Float {
 	result := big.NewFloat(0.0)
 	result.SetPrec(precision)
 
@@ -165,6 +152,10 @@ func arctanSeries(x int, precision uint, terms int) *big.Float {
 
 	sign := 1
 	for n := 0; n < terms; n++ {
+		if n%64 == 0 && ctx.Err() != nil {
+			break
+		}
+
 		// Calcular término: sign * power / (2*n + 1)
 		denominator := big.NewFloat(float64(2*n + 1))
 		denominator.SetPrec(precision)
@@ -189,105 +180,34 @@ func arctanSeries(x int, precision uint, terms int) *big.Float {
 
 // /pi?digits=N
 func PiHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	numStr, ok := req.Params["digits"]
-	if !ok || numStr == "" {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameter 'digits'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, piParams...)
+	if errResp != nil {
+		return errResp
 	}
+	num := params.Int("digits")
 
-	num, err := strconv.Atoi(numStr)
-	if err != nil || num < 1 || num > 1000 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid digits; must be an integer between 1 and 1000"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	// Calcular π usando la fórmula de Machin; se aborta si ya nadie espera el resultado
+	pi, err := computePiMachinContext(req.Context(), num)
+	if err != nil {
+		return server.ServiceUnavailable("computation cancelled", 0)
 	}
 
-	// Calcular π usando la fórmula de Machin
-	pi := computePiMachin(num)
-
 	result := map[string]interface{}{
 		"digits": num,
 		"pi":     pi,
 	}
 
-	body, _ := json.MarshalIndent(result, "", "  ")
-
-	return &server.HTTPResponse{
-		StatusCode: 200,
-		StatusText: "OK",
-		Body:       string(body),
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-	}
+	return server.NewResult(result).Response()
 }
 
 // /mandelbrot?width=W&height=H&max_iter=I&filename=name
 func MandelbrotHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	widthStr, widthOk := req.Params["width"]
-	heightStr, heightOk := req.Params["height"]
-	maxIterStr, maxIterOk := req.Params["max_iter"]
-
-	if !widthOk || !heightOk || !maxIterOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameters 'width', 'height', and 'max_iter'"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	width, err := strconv.Atoi(widthStr)
-	if err != nil || width < 1 || width > 2000 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid width; must be an integer between 1 and 2000"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	height, err := strconv.Atoi(heightStr)
-	if err != nil || height < 1 || height > 2000 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid height; must be an integer between 1 and 2000"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	maxIter, err := strconv.Atoi(maxIterStr)
-	if err != nil || maxIter < 1 || maxIter > 1000 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid max_iter; must be an integer between 1 and 1000"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, mandelbrotParams...)
+	if errResp != nil {
+		return errResp
 	}
-
-	// Parámetros opcionales
-	filename := req.Params["filename"]
+	width, height, maxIter := params.Int("width"), params.Int("height"), params.Int("max_iter")
+	filename := params.String("filename")
 
 	// Generar conjunto de Mandelbrot
 	iterations := generateMandelbrotSet(width, height, maxIter)
@@ -316,57 +236,17 @@ func MandelbrotHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		}
 	}
 
-	body, _ := json.MarshalIndent(response, "", "  ")
-
-	return &server.HTTPResponse{
-		StatusCode: 200,
-		StatusText: "OK",
-		Body:       string(body),
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-	}
+	// En CSV y texto solo va la matriz de iteraciones, una fila por línea de píxeles
+	return server.NewResult(response).Table(nil, iterations).Response()
 }
 
 // /matrixmul?size=N&seed=S
 func MatrixMulHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	sizeStr, sizeOk := req.Params["size"]
-	seedStr, seedOk := req.Params["seed"]
-
-	if !sizeOk || !seedOk {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"missing required query parameters"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	size, err := strconv.Atoi(sizeStr)
-	if err != nil || size < 1 {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid size parameter"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
-	}
-
-	seed, err := strconv.Atoi(seedStr)
-	if err != nil {
-		return &server.HTTPResponse{
-			StatusCode: 400,
-			StatusText: "Bad Request",
-			Body:       `{"error":"invalid seed parameter"}`,
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-		}
+	params, errResp := server.Bind(req, matrixMulParams...)
+	if errResp != nil {
+		return errResp
 	}
+	size, seed := params.Int("size"), params.Int("seed")
 
 	matrixA := generateRandomMatrix(size, seed)
 	matrixB := generateRandomMatrix(size, seed+1)
@@ -393,16 +273,8 @@ func MatrixMulHandler(req *server.HTTPRequest) *server.HTTPResponse {
 	}
 
 	// Devolver resultado
-	body, _ := json.MarshalIndent(response, "", "  ")
-
-	return &server.HTTPResponse{
-		StatusCode: 200,
-		StatusText: "OK",
-		Body:       string(body),
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-	}
+	// En CSV y texto solo va la matriz resultado
+	return server.NewResult(response).Table(nil, result).Response()
 }
 
 func generateRandomMatrix(size, seed int) [][]int {
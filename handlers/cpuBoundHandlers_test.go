@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"GoDocker/server"
+	"context"
 	"encoding/json"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -243,6 +245,7 @@ func TestIsPrimeHandlerEdgeCases(t *testing.T) {
 		{"Invalid string", "abc", 400, false},
 		{"Empty string", "", 400, false},
 		{"Very large valid", "982451653", 200, true},
+		{"Above limit", "9000000000000000000", 400, false},
 		{"Too large", "18446744073709551616", 400, false}, // > uint64 max
 	}
 
@@ -287,6 +290,7 @@ func TestFactorHandlerEdgeCases(t *testing.T) {
 		{"Zero", "0", 400},
 		{"Negative", "-10", 400},
 		{"Invalid string", "abc", 400},
+		{"Above limit", "9000000000000000000", 400},
 		{"Too large", "18446744073709551616", 400},
 	}
 
@@ -329,6 +333,8 @@ func TestMatrixMulHandlerEdgeCases(t *testing.T) {
 		{"Maximum size", "100", "42", 200},
 		{"Invalid size", "abc", "42", 400},
 		{"Invalid seed", "10", "xyz", 400},
+		{"Zero seed", "10", "0", 400}, // No sería determinista
+		{"Negative seed", "10", "-3", 400},
 		{"Zero size", "0", "42", 400},
 		{"Negative size", "-5", "42", 400},
 		{"Too large size", "101", "42", 200},  // No hay límite aparentemente
@@ -416,6 +422,19 @@ func TestComputePiMachin(t *testing.T) {
 	}
 }
 
+func TestPiHandlerCancelled(t *testing.T) {
+	expectedStatus := 503
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := (&server.HTTPRequest{Method: "GET", Path: "/pi", Params: map[string]string{"digits": "1000"}}).WithContext(ctx)
+
+	resp := PiHandler(req)
+	if resp.StatusCode != expectedStatus {
+		t.Errorf("Expected status %d for a cancelled request, got %d", expectedStatus, resp.StatusCode)
+	}
+}
+
 func TestGenerateRandomMatrix(t *testing.T) {
 	expectedSize := 3
 	expectedSeed := 42
@@ -611,7 +630,7 @@ func TestMatrixMulHandlerMissingParams(t *testing.T) {
 		{"Invalid seed", map[string]string{"size": "3", "seed": "abc"}},
 		{"Zero size", map[string]string{"size": "0", "seed": "123"}},
 		{"Negative size", map[string]string{"size": "-1", "seed": "123"}},
-		// Removed "Too large size" test as MatrixMulHandler doesn't have a size limit
+		{"Too large size", map[string]string{"size": "201", "seed": "123"}},
 	}
 
 	for _, tt := range tests {
@@ -628,3 +647,31 @@ func TestMatrixMulHandlerMissingParams(t *testing.T) {
 		})
 	}
 }
+
+// Con varios parámetros inválidos el 400 los lista a todos, no solo el primero
+func TestMandelbrotHandlerListsAllInvalidParams(t *testing.T) {
+	req := &server.HTTPRequest{
+		Method: "GET", Path: "/mandelbrot", Version: "HTTP/1.1",
+		Headers: make(map[string]string), Body: "",
+		Params: map[string]string{"width": "0", "height": "abc"},
+	}
+
+	resp := MandelbrotHandler(req)
+	if resp.StatusCode != 400 {
+		t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+	}
+	var body struct {
+		InvalidParams []server.ParamError `json:"invalid_params"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	want := []server.ParamError{
+		{Name: "width", Reason: "must be between 1 and 2000"},
+		{Name: "height", Reason: "must be an integer"},
+		{Name: "max_iter", Reason: "is required"},
+	}
+	if !reflect.DeepEqual(body.InvalidParams, want) {
+		t.Errorf("Expected %v, got %v", want, body.InvalidParams)
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"GoDocker/server"
+	"encoding/json"
+)
+
+// OpenAPIHandler maneja /openapi.json con la especificación generada a partir de las rutas registradas
+func OpenAPIHandler(srv *server.Server) server.HandlerFunc {
+	return func(req *server.HTTPRequest) *server.HTTPResponse {
+		body, err := json.MarshalIndent(srv.OpenAPISpec(), "", "  ")
+		if err != nil {
+			return server.InternalError("failed to build openapi document")
+		}
+
+		return &server.HTTPResponse{
+			StatusCode: 200,
+			StatusText: "OK",
+			Body:       string(body),
+			Headers: map[string]string{
+				"Content-Type":  "application/json",
+				"Cache-Control": "no-cache",
+			},
+		}
+	}
+}
+
+// DocsHandler maneja /docs: un explorador sin dependencias externas que lee /openapi.json
+// y permite probar cada ruta desde el navegador
+func DocsHandler(req *server.HTTPRequest) *server.HTTPResponse {
+	return &server.HTTPResponse{
+		StatusCode: 200,
+		StatusText: "OK",
+		Body:       docsPage,
+		Headers: map[string]string{
+			"Content-Type": "text/html; charset=utf-8",
+		},
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>API - GoDocker</title>
+<style>
+  body { font-family: sans-serif; margin: 0; background: #f5f5f5; color: #222; }
+  header { background: #263238; color: #fff; padding: 16px 24px; }
+  header h1 { margin: 0; font-size: 22px; }
+  header input { margin-left: 8px; padding: 4px; width: 320px; }
+  main { padding: 16px 24px; }
+  h2 { border-bottom: 1px solid #ccc; padding-bottom: 4px; text-transform: capitalize; }
+  details { background: #fff; border: 1px solid #ddd; border-radius: 4px; margin: 8px 0; }
+  summary { cursor: pointer; padding: 8px; font-family: monospace; font-size: 14px; }
+  .method { display: inline-block; width: 64px; font-weight: bold; color: #fff; text-align: center; border-radius: 3px; margin-right: 8px; }
+  .GET { background: #1976d2; } .POST { background: #388e3c; } .PUT { background: #f57c00; } .DELETE { background: #d32f2f; }
+  .op { padding: 0 16px 16px; }
+  .param { margin: 4px 0; }
+  .param label { display: inline-block; width: 140px; font-family: monospace; }
+  .param small { color: #666; margin-left: 8px; }
+  pre { background: #263238; color: #eceff1; padding: 8px; overflow: auto; max-height: 400px; }
+  .lock { color: #999; font-size: 12px; margin-left: 8px; }
+</style>
+</head>
+<body>
+<header>
+  <h1 id="title">API</h1>
+  <label>X-API-Key <input id="apikey" placeholder="opcional"></label>
+  <label>Bearer <input id="bearer" placeholder="opcional"></label>
+</header>
+<main id="ops">Cargando /openapi.json...</main>
+<script>
+function el(tag, attrs, text) {
+  var e = document.createElement(tag);
+  for (var k in attrs || {}) e.setAttribute(k, attrs[k]);
+  if (text !== undefined) e.textContent = text;
+  return e;
+}
+
+function renderOperation(path, method, op) {
+  var box = el("details");
+  var sum = el("summary");
+  sum.appendChild(el("span", {"class": "method " + method.toUpperCase()}, method.toUpperCase()));
+  sum.appendChild(document.createTextNode(path + "  -  " + (op.summary || "")));
+  if (op.security) sum.appendChild(el("span", {"class": "lock"}, "[auth]"));
+  box.appendChild(sum);
+
+  var body = el("div", {"class": "op"});
+  if (op.description) body.appendChild(el("p", {}, op.description));
+
+  var inputs = {};
+  (op.parameters || []).forEach(function (p) {
+    var row = el("div", {"class": "param"});
+    row.appendChild(el("label", {}, p.name + (p.required ? " *" : "")));
+    var input;
+    if (p.schema.enum) {
+      input = el("select");
+      if (!p.required) input.appendChild(el("option", {value: ""}, ""));
+      p.schema.enum.forEach(function (v) { input.appendChild(el("option", {value: v}, v)); });
+    } else {
+      input = el("input", {placeholder: p.example !== undefined ? String(p.example) : ""});
+    }
+    if (p.schema["default"] !== undefined) input.value = p.schema["default"];
+    inputs[p.name] = input;
+    row.appendChild(input);
+    var hint = p.schema.type;
+    if (p.schema.minimum !== undefined || p.schema.maximum !== undefined) {
+      hint += " [" + (p.schema.minimum !== undefined ? p.schema.minimum : "") + ", " +
+        (p.schema.maximum !== undefined ? p.schema.maximum : "") + "]";
+    }
+    row.appendChild(el("small", {}, hint + (p.description ? " - " + p.description : "")));
+    body.appendChild(row);
+  });
+
+  var responses = Object.keys(op.responses || {}).sort().map(function (code) {
+    return code + " " + op.responses[code].description;
+  });
+  body.appendChild(el("p", {}, "Respuestas: " + responses.join(" | ")));
+
+  var button = el("button", {}, "Probar");
+  var out = el("pre");
+  out.style.display = "none";
+  button.onclick = function () {
+    var query = [];
+    for (var name in inputs) {
+      if (inputs[name].value !== "") query.push(encodeURIComponent(name) + "=" + encodeURIComponent(inputs[name].value));
+    }
+    var url = path + (query.length ? "?" + query.join("&") : "");
+    var headers = {};
+    var key = document.getElementById("apikey").value;
+    var bearer = document.getElementById("bearer").value;
+    if (key) headers["X-API-Key"] = key;
+    if (bearer) headers["Authorization"] = "Bearer " + bearer;
+
+    out.style.display = "block";
+    out.textContent = method.toUpperCase() + " " + url + "\n...";
+    var start = performance.now();
+    fetch(url, {method: method.toUpperCase(), headers: headers}).then(function (resp) {
+      return resp.text().then(function (text) {
+        var ms = Math.round(performance.now() - start);
+        try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (e) {}
+        out.textContent = method.toUpperCase() + " " + url + "\n" + resp.status + " " + resp.statusText +
+          " (" + ms + " ms)\n\n" + text;
+      });
+    }).catch(function (err) {
+      out.textContent = method.toUpperCase() + " " + url + "\n" + err;
+    });
+  };
+  body.appendChild(button);
+  body.appendChild(out);
+  box.appendChild(body);
+  return box;
+}
+
+fetch("/openapi.json").then(function (r) { return r.json(); }).then(function (spec) {
+  document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+  document.title = spec.info.title;
+
+  var byTag = {};
+  Object.keys(spec.paths).sort().forEach(function (path) {
+    Object.keys(spec.paths[path]).forEach(function (method) {
+      var op = spec.paths[path][method];
+      var tag = (op.tags && op.tags[0]) || "otros";
+      (byTag[tag] = byTag[tag] || []).push([path, method, op]);
+    });
+  });
+
+  var root = document.getElementById("ops");
+  root.textContent = "";
+  Object.keys(byTag).sort().forEach(function (tag) {
+    root.appendChild(el("h2", {}, tag));
+    byTag[tag].forEach(function (entry) { root.appendChild(renderOperation(entry[0], entry[1], entry[2])); });
+  });
+}).catch(function (err) {
+  document.getElementById("ops").textContent = "No se pudo cargar /openapi.json: " + err;
+});
+</script>
+</body>
+</html>
+`
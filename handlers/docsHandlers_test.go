@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"GoDocker/server"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	srv := server.NewServer("127.0.0.1:0", 1)
+	srv.HandleFunc("GET", "/isprime", IsPrimeHandler, IsPrimeDoc)
+	srv.HandleFunc("GET", "/openapi.json", OpenAPIHandler(srv), OpenAPIDoc)
+	RegisterProfiling(srv)
+
+	resp := OpenAPIHandler(srv)(&server.HTTPRequest{Method: "GET", Path: "/openapi.json", Headers: map[string]string{}, Params: map[string]string{}})
+	if resp.StatusCode != 200 || resp.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("Expected 200 JSON, got %d %v", resp.StatusCode, resp.Headers)
+	}
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Summary    string                   `json:"summary"`
+			Parameters []map[string]interface{} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &spec); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	isPrime := spec.Paths["/isprime"]["get"]
+	if isPrime.Summary != IsPrimeDoc.Summary || len(isPrime.Parameters) != 2 ||
+		isPrime.Parameters[0]["name"] != "num" || isPrime.Parameters[1]["name"] != "format" {
+		t.Errorf("Unexpected /isprime operation: %+v", isPrime)
+	}
+
+	// Las rutas de profiling también quedan documentadas
+	heap := spec.Paths[PprofPrefix+"/heap"]["get"]
+	if heap.Summary != "Perfil heap" || len(heap.Parameters) != 2 {
+		t.Errorf("Unexpected heap operation: %+v", heap)
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	resp := DocsHandler(&server.HTTPRequest{Method: "GET", Path: "/docs", Headers: map[string]string{}, Params: map[string]string{}})
+	if resp.StatusCode != 200 || !strings.HasPrefix(resp.Headers["Content-Type"], "text/html") {
+		t.Fatalf("Expected 200 HTML, got %d %v", resp.StatusCode, resp.Headers)
+	}
+	// Sin dependencias externas: todo el explorador va en la página
+	if !strings.Contains(resp.Body, `fetch("/openapi.json")`) || strings.Contains(resp.Body, "https://") {
+		t.Error("Expected a self-contained page that loads /openapi.json")
+	}
+}
+
+// Toda ruta documentada tiene resumen y sus parámetros tienen nombre y descripción
+func TestRouteDocsComplete(t *testing.T) {
+	docs := []server.RouteDoc{
+		HelloDoc, StatusDoc, MetricsDoc, HealthzDoc, ReadyzDoc, FaviconDoc, EchoDoc, PingDoc, TimeDoc, HelpDoc,
+		FibonacciDoc, CreateFileDoc, DeleteFileDoc, ReverseDoc, ToUpperDoc, RandomDoc, HashDoc, SimulateDoc,
+		SleepDoc, LoadTestDoc, IsPrimeDoc, FactorDoc, PiDoc, MandelbrotDoc, MatrixMulDoc, SortFileDoc,
+		WordCountDoc, GrepDoc, CompressDoc, HashFileDoc, JobSubmitDoc, JobStatusDoc, JobResultDoc,
+		JobCancelDoc, JobListDoc, OpenAPIDoc, DocsDoc,
+	}
+	for _, doc := range docs {
+		if doc.Summary == "" || len(doc.Tags) == 0 {
+			t.Errorf("Route doc without summary or tags: %+v", doc)
+		}
+		for _, p := range doc.Params {
+			if p.Name == "" || p.Description == "" {
+				t.Errorf("%s: parameter without name or description: %+v", doc.Summary, p)
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"GoDocker/server"
@@ -46,9 +47,20 @@ func StatusHandler(srv *server.Server) server.HandlerFunc {
 	return func(req *server.HTTPRequest) *server.HTTPResponse {
 		stats := srv.GetStats()
 
+		// Con algún circuit breaker abierto o sin pasar readiness el servidor responde, pero degradado
+		readiness := srv.Readiness(req.Context())
+		status := "ok"
+		if len(srv.OpenBreakers()) > 0 || !readiness.Healthy() {
+			status = "degraded"
+		}
+
 		statsJSON, _ := json.MarshalIndent(map[string]interface{}{
-			"status": "ok",
-			"stats":  stats,
+			"status":           status,
+			"stats":            stats,
+			"circuit_breakers": srv.BreakerStates(),
+			"open_breakers":    srv.OpenBreakers(),
+			"runtime":          srv.RuntimeSummary(),
+			"readiness":        readiness.Status,
 		}, "", "  ")
 
 		return &server.HTTPResponse{
@@ -62,23 +74,66 @@ func StatusHandler(srv *server.Server) server.HandlerFunc {
 	}
 }
 
-// MetricsHandler maneja peticiones a /metrics con métricas detalladas
-func MetricsHandler(srv *server.Server) server.HandlerFunc {
+// HealthzHandler maneja /healthz (liveness): 503 si falla algún check crítico de liveness
+func HealthzHandler(srv *server.Server) server.HandlerFunc {
 	return func(req *server.HTTPRequest) *server.HTTPResponse {
-		metrics := srv.GetMetrics()
+		return healthResponse(srv.Liveness(req.Context()))
+	}
+}
 
-		metricsJSON, err := json.MarshalIndent(metrics, "", "  ")
-		if err != nil {
+// ReadyzHandler maneja /readyz (readiness): 503 si falla algún check crítico o durante el shutdown
+func ReadyzHandler(srv *server.Server) server.HandlerFunc {
+	return func(req *server.HTTPRequest) *server.HTTPResponse {
+		return healthResponse(srv.Readiness(req.Context()))
+	}
+}
+
+// healthResponse serializa el reporte con el detalle de cada check
+func healthResponse(report server.HealthReport) *server.HTTPResponse {
+	body, _ := json.MarshalIndent(report, "", "  ")
+
+	resp := &server.HTTPResponse{
+		StatusCode: 200,
+		StatusText: "OK",
+		Body:       string(body),
+		Headers: map[string]string{
+			"Content-Type":  "application/json",
+			"Cache-Control": "no-store",
+		},
+	}
+	if !report.Healthy() {
+		resp.StatusCode = 503
+		resp.StatusText = "Service Unavailable"
+	}
+	return resp
+}
+
+// MetricsHandler maneja peticiones a /metrics con métricas detalladas, en JSON o en formato Prometheus
+func MetricsHandler(srv *server.Server) server.HandlerFunc {
+	return func(req *server.HTTPRequest) *server.HTTPResponse {
+		if wantsPrometheus(req) {
 			return &server.HTTPResponse{
-				StatusCode: 500,
-				StatusText: "Internal Server Error",
-				Body:       `{"error": "failed to marshal metrics"}`,
+				StatusCode: 200,
+				StatusText: "OK",
+				Body:       srv.PrometheusMetrics(),
 				Headers: map[string]string{
-					"Content-Type": "application/json",
+					"Content-Type": server.PrometheusContentType,
 				},
 			}
 		}
 
+		window, err := server.ParseStatsWindow(req.Params["window"])
+		if err != nil {
+			return server.BadRequest(err.Error())
+		}
+
+		metrics := srv.GetMetricsWindow(window)
+
+		metricsJSON, err := json.MarshalIndent(metrics, "", "  ")
+		if err != nil {
+			return server.InternalError("failed to marshal metrics")
+		}
+
 		return &server.HTTPResponse{
 			StatusCode: 200,
 			StatusText: "OK",
@@ -90,6 +145,23 @@ func MetricsHandler(srv *server.Server) server.HandlerFunc {
 	}
 }
 
+// wantsPrometheus decide el formato de /metrics: ?format=prometheus|json tiene prioridad;
+// si no, Prometheus cuando el Accept pide texto plano u OpenMetrics y no JSON
+func wantsPrometheus(req *server.HTTPRequest) bool {
+	switch strings.ToLower(req.Params["format"]) {
+	case "prometheus":
+		return true
+	case "json":
+		return false
+	}
+
+	accept := strings.ToLower(req.GetHeader("Accept"))
+	if strings.Contains(accept, "application/json") {
+		return false
+	}
+	return strings.Contains(accept, "text/plain") || strings.Contains(accept, "application/openmetrics-text")
+}
+
 // EchoHandler maneja peticiones a /echo
 func EchoHandler(req *server.HTTPRequest) *server.HTTPResponse {
 	response := fmt.Sprintf(`<!DOCTYPE html>
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"GoDocker/server"
+	"context"
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 )
 
 // MockServer para testing del StatusHandler
@@ -78,6 +80,47 @@ func TestStatusHandler(t *testing.T) {
 	}
 }
 
+func TestMetricsHandlerFormats(t *testing.T) {
+	srv := server.NewServer(":8080", 10)
+	handler := MetricsHandler(srv)
+
+	tests := []struct {
+		name        string
+		params      map[string]string
+		accept      string
+		contentType string
+	}{
+		{"default is JSON", map[string]string{}, "", "application/json"},
+		{"format param", map[string]string{"format": "prometheus"}, "", server.PrometheusContentType},
+		{"prometheus scraper Accept", map[string]string{}, "text/plain;version=0.0.4;q=0.5,*/*;q=0.1", server.PrometheusContentType},
+		{"openmetrics Accept", map[string]string{}, "application/openmetrics-text;version=1.0.0", server.PrometheusContentType},
+		{"JSON Accept wins", map[string]string{}, "application/json, text/plain", "application/json"},
+		{"format param wins over Accept", map[string]string{"format": "json"}, "text/plain", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &server.HTTPRequest{
+				Method:  "GET",
+				Path:    "/metrics",
+				Version: "HTTP/1.1",
+				Headers: map[string]string{"Accept": tt.accept},
+				Params:  tt.params,
+			}
+			resp := handler(req)
+			if resp.StatusCode != 200 {
+				t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+			}
+			if resp.Headers["Content-Type"] != tt.contentType {
+				t.Errorf("Expected Content-Type %s, got %s", tt.contentType, resp.Headers["Content-Type"])
+			}
+			if tt.contentType == server.PrometheusContentType && !strings.Contains(resp.Body, "# TYPE gohttp_http_requests_total counter") {
+				t.Error("Prometheus output missing request counter family")
+			}
+		})
+	}
+}
+
 func TestPingHandler(t *testing.T) {
 	expectedStatus := 200
 	expectedBody := "pong"
@@ -230,3 +273,77 @@ func TestEchoHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsHandlerWindow(t *testing.T) {
+	srv := server.NewServer(":8080", 10)
+	handler := MetricsHandler(srv)
+
+	tests := []struct {
+		window string
+		status int
+		want   string
+	}{
+		{"", 200, "5m"},
+		{"1m", 200, "1m"},
+		{"15m", 200, "15m"},
+		{"90s", 200, "1m30s"},
+		{"1h", 400, ""},
+		{"1s", 400, ""},
+		{"soon", 400, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			req := &server.HTTPRequest{
+				Method:  "GET",
+				Path:    "/metrics",
+				Version: "HTTP/1.1",
+				Headers: map[string]string{},
+				Params:  map[string]string{"window": tt.window},
+			}
+			resp := handler(req)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.status, resp.StatusCode, resp.Body)
+			}
+			if tt.status != 200 {
+				return
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			traffic := body["traffic"].(map[string]interface{})
+			if traffic["window"] != tt.want {
+				t.Errorf("Expected window %s, got %v", tt.want, traffic["window"])
+			}
+			if _, ok := traffic["windows"].(map[string]interface{})["15m"]; !ok {
+				t.Error("Missing 15m summary")
+			}
+		})
+	}
+}
+
+func TestHealthHandlers(t *testing.T) {
+	srv := server.NewServer("127.0.0.1:0", 1)
+
+	for name, handler := range map[string]server.HandlerFunc{"healthz": HealthzHandler(srv), "readyz": ReadyzHandler(srv)} {
+		resp := handler(&server.HTTPRequest{Method: "GET", Path: "/" + name, Headers: map[string]string{}, Params: map[string]string{}})
+		if resp.StatusCode != 200 {
+			t.Errorf("%s: expected 200, got %d: %s", name, resp.StatusCode, resp.Body)
+		}
+		var report server.HealthReport
+		if err := json.Unmarshal([]byte(resp.Body), &report); err != nil || report.Status != server.HealthPass || len(report.Checks) == 0 {
+			t.Errorf("%s: unexpected report %s (%v)", name, resp.Body, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+
+	resp := ReadyzHandler(srv)(&server.HTTPRequest{Method: "GET", Path: "/readyz", Headers: map[string]string{}, Params: map[string]string{}})
+	if resp.StatusCode != 503 || !strings.Contains(resp.Body, "shutting down") {
+		t.Errorf("Expected 503 during shutdown, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
@@ -85,20 +85,44 @@ func partition(arr []int, low, high int) int {
 	return i + 1
 }
 
+// fileParam es el archivo, relativo al directorio pruebas/, de las rutas IO-bound
+var fileParam = server.StringParam("name", "Archivo dentro del directorio pruebas/").Require().WithExample("numbers.txt")
+
+// Parámetros de cada ruta: los valida Bind y los publica la especificación OpenAPI
+var (
+	sortFileParams = []server.Param{
+		fileParam,
+		server.EnumParam("algo", "Algoritmo de ordenamiento", "merge", "quick").WithDefault("quick"),
+	}
+	wordCountParams = []server.Param{fileParam}
+	grepParams      = []server.Param{
+		fileParam,
+		server.StringParam("pattern", "Expresión regular (sintaxis RE2)").Require().WithExample("^4"),
+	}
+	compressParams = []server.Param{
+		fileParam,
+		server.EnumParam("codec", "Compresor (xz requiere el binario en el PATH)", "gzip", "xz").Require(),
+	}
+	hashFileParams = []server.Param{
+		fileParam,
+		server.EnumParam("algo", "Algoritmo", "sha256").Require(),
+	}
+)
+
 // SortFileHandler ordena números enteros en el archivo indicado.
 // Query params: name=FILE, algo=merge|quick (quick = in-memory, merge -> also in-memory here but placeholder)
 func SortFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	filename, _ := req.Params["name"]
-	algo, _ := req.Params["algo"]
-	if filename == "" {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"missing name parameter"}`, Headers: map[string]string{"Content-Type": "application/json"}}
+	params, errResp := server.Bind(req, sortFileParams...)
+	if errResp != nil {
+		return errResp
 	}
+	filename, algo := params.String("name"), params.String("algo")
 
 	name := getFilePath(filename)
 	start := time.Now()
 	f, err := os.Open(name)
 	if err != nil {
-		return &server.HTTPResponse{StatusCode: 404, StatusText: "Not Found", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.NotFound(err.Error())
 	}
 	defer f.Close()
 
@@ -122,23 +146,20 @@ func SortFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		nums = append(nums, v)
 	}
 	if err := scanner.Err(); err != nil {
-		return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.InternalError(err.Error())
 	}
 
-	// Elegir algoritmo
+	// Elegir algoritmo (quick por defecto)
 	if algo == "merge" {
 		nums = mergeSort(nums)
-	} else if algo == "quick" {
-		quickSort(nums)
 	} else {
-		// Por defecto usar quicksort
 		quickSort(nums)
 	}
 
 	outName := getFilePath(filename + ".sorted")
 	of, err := os.Create(outName)
 	if err != nil {
-		return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.InternalError(err.Error())
 	}
 	defer of.Close()
 
@@ -156,14 +177,15 @@ func SortFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // WordCountHandler cuenta líneas, palabras y bytes (wc-like)
 func WordCountHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	filename, _ := req.Params["name"]
-	if filename == "" {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"missing name parameter"}`, Headers: map[string]string{"Content-Type": "application/json"}}
+	params, errResp := server.Bind(req, wordCountParams...)
+	if errResp != nil {
+		return errResp
 	}
+	filename := params.String("name")
 	name := getFilePath(filename)
 	f, err := os.Open(name)
 	if err != nil {
-		return &server.HTTPResponse{StatusCode: 404, StatusText: "Not Found", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.NotFound(err.Error())
 	}
 	defer f.Close()
 
@@ -194,7 +216,7 @@ func WordCountHandler(req *server.HTTPRequest) *server.HTTPResponse {
 			break
 		}
 		if err != nil {
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 	}
 	if inWord {
@@ -208,20 +230,20 @@ func WordCountHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // GrepHandler busca un patrón regex en el archivo y devuelve número de coincidencias y primeras 10 líneas coincidentes
 func GrepHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	filename, _ := req.Params["name"]
-	pattern, _ := req.Params["pattern"]
-	if filename == "" || pattern == "" {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"missing name or pattern parameter"}`, Headers: map[string]string{"Content-Type": "application/json"}}
+	params, errResp := server.Bind(req, grepParams...)
+	if errResp != nil {
+		return errResp
 	}
-	re, err := regexp.Compile(pattern)
+	filename := params.String("name")
+	re, err := regexp.Compile(params.String("pattern"))
 	if err != nil {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: fmt.Sprintf(`{"error":"invalid regex: %s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.InvalidParamsResponse([]server.ParamError{{Name: "pattern", Reason: "is not a valid regex: " + err.Error()}})
 	}
 
 	name := getFilePath(filename)
 	f, err := os.Open(name)
 	if err != nil {
-		return &server.HTTPResponse{StatusCode: 404, StatusText: "Not Found", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.NotFound(err.Error())
 	}
 	defer f.Close()
 
@@ -241,7 +263,7 @@ func GrepHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.InternalError(err.Error())
 	}
 
 	res := map[string]interface{}{"matches": matches, "first_lines": firstLines}
@@ -251,28 +273,28 @@ func GrepHandler(req *server.HTTPRequest) *server.HTTPResponse {
 
 // CompressHandler comprime un archivo usando gzip o xz (si xz está disponible)
 func CompressHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	filename, _ := req.Params["name"]
-	codec, _ := req.Params["codec"]
-	if filename == "" || codec == "" {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"missing name or codec parameter"}`, Headers: map[string]string{"Content-Type": "application/json"}}
+	params, errResp := server.Bind(req, compressParams...)
+	if errResp != nil {
+		return errResp
 	}
+	filename, codec := params.String("name"), params.String("codec")
 	name := getFilePath(filename)
 	if codec == "gzip" {
 		in, err := os.Open(name)
 		if err != nil {
-			return &server.HTTPResponse{StatusCode: 404, StatusText: "Not Found", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.NotFound(err.Error())
 		}
 		defer in.Close()
 		outName := getFilePath(filename + ".gz")
 		out, err := os.Create(outName)
 		if err != nil {
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		gw := gzip.NewWriter(out)
 		if _, err := io.Copy(gw, in); err != nil {
 			gw.Close()
 			out.Close()
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		gw.Close()
 		out.Close()
@@ -286,51 +308,48 @@ func CompressHandler(req *server.HTTPRequest) *server.HTTPResponse {
 		cmd := exec.Command("xz", "-c", name)
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		if err := cmd.Start(); err != nil {
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		out, err := os.Create(outName)
 		if err != nil {
 			stdout.Close()
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		if _, err := io.Copy(out, stdout); err != nil {
 			out.Close()
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		out.Close()
 		if err := cmd.Wait(); err != nil {
-			return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+			return server.InternalError(err.Error())
 		}
 		fi, _ := os.Stat(outName)
 		res := map[string]interface{}{"output": outName, "size": fi.Size()}
 		data, _ := json.MarshalIndent(res, "", "  ")
 		return &server.HTTPResponse{StatusCode: 200, StatusText: "OK", Body: string(data), Headers: map[string]string{"Content-Type": "application/json"}}
 	}
-	return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"unsupported codec"}`, Headers: map[string]string{"Content-Type": "application/json"}}
+	return server.BadRequest("unsupported codec")
 }
 
 // HashFileHandler calcula hash (sha256) de un archivo
 func HashFileHandler(req *server.HTTPRequest) *server.HTTPResponse {
-	filename, _ := req.Params["name"]
-	algo, _ := req.Params["algo"]
-	if filename == "" || algo == "" {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"missing name or algo parameter"}`, Headers: map[string]string{"Content-Type": "application/json"}}
-	}
-	if algo != "sha256" {
-		return &server.HTTPResponse{StatusCode: 400, StatusText: "Bad Request", Body: `{"error":"unsupported algo"}`, Headers: map[string]string{"Content-Type": "application/json"}}
+	params, errResp := server.Bind(req, hashFileParams...)
+	if errResp != nil {
+		return errResp
 	}
+	filename := params.String("name")
 	name := getFilePath(filename)
 	f, err := os.Open(name)
 	if err != nil {
-		return &server.HTTPResponse{StatusCode: 404, StatusText: "Not Found", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.NotFound(err.Error())
 	}
 	defer f.Close()
 	h := sha256.New()
 	if _, err := io.Copy(h, f); err != nil {
-		return &server.HTTPResponse{StatusCode: 500, StatusText: "Internal Error", Body: fmt.Sprintf(`{"error":"%s"}`, err.Error()), Headers: map[string]string{"Content-Type": "application/json"}}
+		return server.InternalError(err.Error())
 	}
 	sum := hex.EncodeToString(h.Sum(nil))
 	res := map[string]interface{}{"algo": "sha256", "hex": sum}
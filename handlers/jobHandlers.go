@@ -2,21 +2,39 @@ package handlers
 
 import (
 	"GoDocker/server"
-	"encoding/json"
+	"errors"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// maxJobPageSize es el límite de jobs por página de /jobs
+const maxJobPageSize = 500
+
+// jobListColumns son las columnas de /jobs en CSV y texto
+var jobListColumns = []string{"job_id", "task", "status", "priority", "owner", "progress", "created_at", "completed_at", "error"}
+
+var jobListParams = []server.Param{
+	server.StringParam("status", "Estados separados por coma (queued, running, done, error, canceled, timeout)").WithExample("done,error"),
+	server.StringParam("task", "Tareas separadas por coma").WithExample("pi,factor"),
+	server.StringParam("priority", "Prioridades separadas por coma (low, normal, high)"),
+	server.StringParam("owner", "ID de la identidad que envió el job; solo los admins pueden ver los de otros"),
+	server.StringParam("created_after", "Creados desde este instante (RFC 3339)").WithExample("2026-01-01T00:00:00Z"),
+	server.StringParam("created_before", "Creados antes de este instante (RFC 3339)"),
+	server.StringParam("completed_after", "Terminados desde este instante (RFC 3339)"),
+	server.StringParam("completed_before", "Terminados antes de este instante (RFC 3339)"),
+	server.EnumParam("sort", "Orden; con - es descendente", server.JobSorts...).WithDefault("-created_at"),
+	server.IntParam("limit", "Jobs por página").Between(1, maxJobPageSize).WithDefault("50"),
+	server.StringParam("cursor", "Valor de next_cursor de la página anterior"),
+}
+
 // JobSubmitHandler maneja /jobs/submit
 func JobSubmitHandler(jm *server.JobManager) server.HandlerFunc {
 	return func(req *server.HTTPRequest) *server.HTTPResponse {
 		task, _ := req.Params["task"]
 		if task == "" {
-			return &server.HTTPResponse{
-				StatusCode: 400,
-				StatusText: "Bad Request",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "missing task parameter"}`,
-			}
+			return server.BadRequest("missing task parameter")
 		}
 
 		// Obtener prioridad (por defecto normal)
@@ -30,36 +48,43 @@ func JobSubmitHandler(jm *server.JobManager) server.HandlerFunc {
 			}
 		}
 
-		// Copiar parámetros (excepto task y prio)
+		// Copiar parámetros (excepto task, prio y format, que es el de esta respuesta)
 		params := make(map[string]string)
 		for k, v := range req.Params {
-			if k != "task" && k != "prio" {
+			if k != "task" && k != "prio" && k != "format" {
 				params[k] = v
 			}
 		}
 
+		// Registrar el dueño si la petición viene autenticada
+		owner := ""
+		if req.Identity != nil {
+			owner = req.Identity.ID
+		}
+
 		// Enviar trabajo
-		job, err := jm.Submit(task, params, priority)
+		job, err := jm.SubmitFor(owner, task, params, priority)
 		if err != nil {
+			if errors.Is(err, server.ErrUnknownTask) {
+				return server.BadRequest(err.Error())
+			}
+			if errors.Is(err, server.ErrCircuitOpen) {
+				return server.NewProblem(503, err.Error()).
+					WithType(server.ProblemCircuitOpen).
+					WithHeader("Retry-After", "30").
+					Response()
+			}
+
 			if err.Error() == "queue full" {
 				retryAfter := 5000 // 5 segundos
-				return &server.HTTPResponse{
-					StatusCode: 503,
-					StatusText: "Service Unavailable",
-					Headers: map[string]string{
-						"Content-Type": "application/json",
-						"Retry-After":  strconv.Itoa(retryAfter / 1000),
-					},
-					Body: `{"error": "queue full", "retry_after_ms": ` + strconv.Itoa(retryAfter) + `}`,
-				}
+				return server.NewProblem(503, "queue full").
+					WithType(server.ProblemOverloaded).
+					With("retry_after_ms", retryAfter).
+					WithHeader("Retry-After", strconv.Itoa(retryAfter/1000)).
+					Response()
 			}
 
-			return &server.HTTPResponse{
-				StatusCode: 500,
-				StatusText: "Internal Server Error",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "` + err.Error() + `"}`,
-			}
+			return server.InternalError(err.Error())
 		}
 
 		result := map[string]interface{}{
@@ -67,38 +92,132 @@ func JobSubmitHandler(jm *server.JobManager) server.HandlerFunc {
 			"status": job.Status,
 		}
 
-		jsonData, _ := json.MarshalIndent(result, "", "  ")
+		return server.NewResult(result).Response()
+	}
+}
 
-		return &server.HTTPResponse{
-			StatusCode: 200,
-			StatusText: "OK",
-			Headers:    map[string]string{"Content-Type": "application/json"},
-			Body:       string(jsonData),
+// JobListHandler maneja /jobs: lista los jobs con filtros, orden y paginación por cursor.
+// Con autenticación, una identidad que no es admin solo ve sus propios jobs.
+func JobListHandler(jm *server.JobManager) server.HandlerFunc {
+	return func(req *server.HTTPRequest) *server.HTTPResponse {
+		// Los errores de Bind y los de las listas y fechas se informan juntos
+		params, errs := server.BindParams(req.Params, jobListParams)
+
+		query := server.JobQuery{
+			Tasks:  splitList(params.String("task")),
+			Sort:   params.String("sort"),
+			Limit:  params.Int("limit"),
+			Cursor: params.String("cursor"),
+		}
+		for _, name := range splitList(params.String("status")) {
+			status := server.JobStatus(name)
+			if !isJobStatus(status) {
+				errs = append(errs, server.ParamError{Name: "status", Reason: "unknown status " + name})
+				continue
+			}
+			query.Statuses = append(query.Statuses, status)
 		}
+		for _, name := range splitList(params.String("priority")) {
+			priority, ok := server.ParseJobPriority(name)
+			if !ok {
+				errs = append(errs, server.ParamError{Name: "priority", Reason: "must be one of: low, normal, high"})
+				continue
+			}
+			query.Priorities = append(query.Priorities, priority)
+		}
+		for _, bound := range []struct {
+			name   string
+			target *time.Time
+		}{
+			{"created_after", &query.CreatedAfter}, {"created_before", &query.CreatedBefore},
+			{"completed_after", &query.CompletedAfter}, {"completed_before", &query.CompletedBefore},
+		} {
+			if !params.Has(bound.name) {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, params.String(bound.name))
+			if err != nil {
+				errs = append(errs, server.ParamError{Name: bound.name, Reason: "must be an RFC 3339 timestamp"})
+				continue
+			}
+			*bound.target = t
+		}
+
+		if params.Has("owner") {
+			query.Owner, query.OwnerSet = params.String("owner"), true
+		}
+		if identity := req.Identity; identity != nil && !identity.Admin {
+			if query.OwnerSet && query.Owner != identity.ID {
+				return server.Forbidden("only admins can list jobs of other identities")
+			}
+			query.Owner, query.OwnerSet = identity.ID, true
+		}
+
+		if len(errs) > 0 {
+			sortParamErrors(errs, jobListParams)
+			return server.InvalidParamsResponse(errs)
+		}
+
+		page, err := jm.ListJobs(query)
+		if errors.Is(err, server.ErrInvalidCursor) {
+			return server.InvalidParamsResponse([]server.ParamError{{Name: "cursor", Reason: "is invalid or was issued for another sort"}})
+		}
+		if err != nil {
+			return server.BadRequest(err.Error())
+		}
+
+		return server.NewResult(page).Table(jobListColumns, page.Jobs).Records(page.Jobs).Response()
 	}
 }
 
+// sortParamErrors ordena los errores según el orden de los parámetros en specs
+func sortParamErrors(errs []server.ParamError, specs []server.Param) {
+	position := make(map[string]int, len(specs))
+	for i, spec := range specs {
+		position[spec.Name] = i
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return position[errs[i].Name] < position[errs[j].Name]
+	})
+}
+
+// splitList separa un parámetro con valores separados por coma, sin vacíos
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// isJobStatus indica si el estado existe
+func isJobStatus(status server.JobStatus) bool {
+	for _, known := range server.JobStatuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
 // JobStatusHandler maneja /jobs/status
 func JobStatusHandler(jm *server.JobManager) server.HandlerFunc {
 	return func(req *server.HTTPRequest) *server.HTTPResponse {
 		jobID, _ := req.Params["id"]
 		if jobID == "" {
-			return &server.HTTPResponse{
-				StatusCode: 400,
-				StatusText: "Bad Request",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "missing id parameter"}`,
-			}
+			return server.BadRequest("missing id parameter")
 		}
 
 		job, err := jm.GetJob(jobID)
 		if err != nil {
-			return &server.HTTPResponse{
-				StatusCode: 404,
-				StatusText: "Not Found",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "job not found"}`,
-			}
+			return server.NotFound("job not found")
+		}
+
+		// Parámetros y resultados son del dueño del job (o de un admin), igual que en GET /jobs
+		if !canManageJob(req.Identity, job) {
+			return server.Forbidden("only the job owner or an admin can read this job")
 		}
 
 		info := job.GetInfo()
@@ -115,14 +234,7 @@ func JobStatusHandler(jm *server.JobManager) server.HandlerFunc {
 			result["error"] = err
 		}
 
-		jsonData, _ := json.MarshalIndent(result, "", "  ")
-
-		return &server.HTTPResponse{
-			StatusCode: 200,
-			StatusText: "OK",
-			Headers:    map[string]string{"Content-Type": "application/json"},
-			Body:       string(jsonData),
-		}
+		return server.NewResult(result).Response()
 	}
 }
 
@@ -131,22 +243,17 @@ func JobResultHandler(jm *server.JobManager) server.HandlerFunc {
 	return func(req *server.HTTPRequest) *server.HTTPResponse {
 		jobID, _ := req.Params["id"]
 		if jobID == "" {
-			return &server.HTTPResponse{
-				StatusCode: 400,
-				StatusText: "Bad Request",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "missing id parameter"}`,
-			}
+			return server.BadRequest("missing id parameter")
 		}
 
 		job, err := jm.GetJob(jobID)
 		if err != nil {
-			return &server.HTTPResponse{
-				StatusCode: 404,
-				StatusText: "Not Found",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "job not found"}`,
-			}
+			return server.NotFound("job not found")
+		}
+
+		// Parámetros y resultados son del dueño del job (o de un admin), igual que en GET /jobs
+		if !canManageJob(req.Identity, job) {
+			return server.Forbidden("only the job owner or an admin can read this job")
 		}
 
 		info := job.GetInfo()
@@ -162,25 +269,11 @@ func JobResultHandler(jm *server.JobManager) server.HandlerFunc {
 				result["error"] = err
 			}
 
-			jsonData, _ := json.MarshalIndent(result, "", "  ")
-
-			return &server.HTTPResponse{
-				StatusCode: 200,
-				StatusText: "OK",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       string(jsonData),
-			}
+			return server.NewResult(result).Response()
 		}
 
 		// Retornar resultado completo
-		jsonData, _ := json.MarshalIndent(info, "", "  ")
-
-		return &server.HTTPResponse{
-			StatusCode: 200,
-			StatusText: "OK",
-			Headers:    map[string]string{"Content-Type": "application/json"},
-			Body:       string(jsonData),
-		}
+		return server.NewResult(info).Response()
 	}
 }
 
@@ -189,22 +282,22 @@ func JobCancelHandler(jm *server.JobManager) server.HandlerFunc {
 	return func(req *server.HTTPRequest) *server.HTTPResponse {
 		jobID, _ := req.Params["id"]
 		if jobID == "" {
-			return &server.HTTPResponse{
-				StatusCode: 400,
-				StatusText: "Bad Request",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "missing id parameter"}`,
-			}
+			return server.BadRequest("missing id parameter")
+		}
+
+		job, err := jm.GetJob(jobID)
+		if err != nil {
+			return server.NotFound("job not found")
+		}
+
+		// Solo el dueño del job (o un admin) puede cancelarlo
+		if !canManageJob(req.Identity, job) {
+			return server.Forbidden("only the job owner or an admin can cancel this job")
 		}
 
 		canceled, err := jm.CancelJob(jobID)
 		if err != nil {
-			return &server.HTTPResponse{
-				StatusCode: 404,
-				StatusText: "Not Found",
-				Headers:    map[string]string{"Content-Type": "application/json"},
-				Body:       `{"error": "job not found"}`,
-			}
+			return server.NotFound("job not found")
 		}
 
 		status := "canceled"
@@ -217,13 +310,15 @@ func JobCancelHandler(jm *server.JobManager) server.HandlerFunc {
 			"status": status,
 		}
 
-		jsonData, _ := json.MarshalIndent(result, "", "  ")
+		return server.NewResult(result).Response()
+	}
+}
 
-		return &server.HTTPResponse{
-			StatusCode: 200,
-			StatusText: "OK",
-			Headers:    map[string]string{"Content-Type": "application/json"},
-			Body:       string(jsonData),
-		}
+// canManageJob indica si la identidad puede consultar o administrar el job.
+// Sin autenticación habilitada (identity nil) se permite cualquier operación.
+func canManageJob(identity *server.Identity, job *server.Job) bool {
+	if identity == nil {
+		return true
 	}
+	return identity.Admin || job.Owner == identity.ID
 }
@@ -4,6 +4,7 @@ import (
 	"GoDocker/server"
 	"context"
 	"encoding/json"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -113,6 +114,19 @@ func TestJobStatusHandler(t *testing.T) {
 			if resp.StatusCode != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
+
+			// Los errores son problemas RFC 7807 con el status en el body
+			var problem struct {
+				Title  string `json:"title"`
+				Status int    `json:"status"`
+				Detail string `json:"detail"`
+			}
+			if resp.Headers["Content-Type"] != server.ProblemContentType {
+				t.Errorf("Expected %s, got %s", server.ProblemContentType, resp.Headers["Content-Type"])
+			}
+			if err := json.Unmarshal([]byte(resp.Body), &problem); err != nil || problem.Status != tt.expectedStatus || problem.Detail == "" {
+				t.Errorf("Unexpected problem body: %s", resp.Body)
+			}
 		})
 	}
 }
@@ -210,6 +224,35 @@ func TestServerTaskExecutor(t *testing.T) {
 	}
 }
 
+func TestServerTaskExecutorUsesCache(t *testing.T) {
+	srv := server.NewServer(":8080", 10)
+	srv.CacheRoute("", "/factor", server.CachePolicy{TTL: time.Minute})
+	executor := NewServerTaskExecutor(srv)
+	ctx := context.Background()
+
+	first, err := executor.Execute(ctx, "factor", map[string]string{"num": "360"})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if first["cache"] != "MISS" {
+		t.Errorf("Expected first execution to be a MISS, got %v", first["cache"])
+	}
+
+	// Parámetros equivalentes reutilizan el resultado, igual que la ruta HTTP
+	second, err := executor.Execute(ctx, "factor", map[string]string{"num": " 0360"})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if second["cache"] != "HIT" || second["body"] != first["body"] {
+		t.Errorf("Expected a HIT with the same body, got %v", second["cache"])
+	}
+
+	resp := srv.ServeCached(&server.HTTPRequest{Method: "GET", Path: "/factor", Params: map[string]string{"num": "360"}}, FactorHandler)
+	if resp.Headers["X-Cache"] != "HIT" {
+		t.Errorf("HTTP request should reuse the job result, got X-Cache %q", resp.Headers["X-Cache"])
+	}
+}
+
 func TestJobIntegration(t *testing.T) {
 	srv := server.NewServer(":8080", 10)
 	executor := NewServerTaskExecutor(srv)
@@ -232,6 +275,15 @@ func TestJobIntegration(t *testing.T) {
 		t.Fatalf("Job submission failed with status %d", submitResp.StatusCode)
 	}
 
+	// Una tarea que el executor no conoce es un error del cliente
+	unknownResp := JobSubmitHandler(jm)(&server.HTTPRequest{
+		Method: "POST", Path: "/jobs/submit", Version: "HTTP/1.1",
+		Headers: make(map[string]string), Params: map[string]string{"task": "rm-rf"},
+	})
+	if unknownResp.StatusCode != 400 {
+		t.Errorf("Expected 400 for an unknown task, got %d", unknownResp.StatusCode)
+	}
+
 	var submitResult map[string]interface{}
 	if err := json.Unmarshal([]byte(submitResp.Body), &submitResult); err != nil {
 		t.Fatalf("Failed to parse job submission response: %v", err)
@@ -384,3 +436,138 @@ func TestJobCancelHandlerAdvanced(t *testing.T) {
 		}
 	})
 }
+
+func TestJobCancelHandlerOwnership(t *testing.T) {
+	srv := server.NewServer(":8080", 10)
+	jm := srv.GetJobManager()
+
+	alice := &server.Identity{ID: "alice"}
+	bob := &server.Identity{ID: "bob"}
+	admin := &server.Identity{ID: "ops", Admin: true}
+
+	submitReq := &server.HTTPRequest{
+		Method: "POST", Path: "/jobs/submit", Version: "HTTP/1.1",
+		Headers: make(map[string]string), Params: map[string]string{"task": "pi", "digits": "10", "prio": "low"},
+		Identity: alice,
+	}
+	submitResp := JobSubmitHandler(jm)(submitReq)
+	if submitResp.StatusCode != 200 {
+		t.Fatalf("Job submission failed with status %d", submitResp.StatusCode)
+	}
+
+	var submitResult map[string]interface{}
+	json.Unmarshal([]byte(submitResp.Body), &submitResult)
+	jobID := submitResult["job_id"].(string)
+
+	job, _ := jm.GetJob(jobID)
+	if job.Owner != "alice" {
+		t.Errorf("Expected job owner 'alice', got '%s'", job.Owner)
+	}
+
+	tests := []struct {
+		name           string
+		identity       *server.Identity
+		expectedStatus int
+	}{
+		{"Other identity", bob, 403},
+		{"Owner", alice, 200},
+		{"Admin", admin, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &server.HTTPRequest{
+				Method: "DELETE", Path: "/jobs/cancel", Version: "HTTP/1.1",
+				Headers: make(map[string]string), Params: map[string]string{"id": jobID},
+				Identity: tt.identity,
+			}
+
+			// Estado y resultado tienen la misma restricción que la cancelación
+			for path, handler := range map[string]server.HandlerFunc{
+				"/jobs/status": JobStatusHandler(jm),
+				"/jobs/result": JobResultHandler(jm),
+			} {
+				read := &server.HTTPRequest{
+					Method: "GET", Path: path, Version: "HTTP/1.1",
+					Headers: make(map[string]string), Params: map[string]string{"id": jobID},
+					Identity: tt.identity,
+				}
+				if resp := handler(read); resp.StatusCode != tt.expectedStatus {
+					t.Errorf("Expected status %d for %s, got %d: %s", tt.expectedStatus, path, resp.StatusCode, resp.Body)
+				}
+			}
+
+			resp := JobCancelHandler(jm)(req)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, resp.StatusCode, resp.Body)
+			}
+		})
+	}
+}
+
+func TestJobListHandler(t *testing.T) {
+	srv := server.NewServer(":8080", 10)
+	jm := srv.GetJobManager()
+	// Dueños únicos: el manager carga los jobs persistidos por otros tests
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	aliceID, bobID := "alice-"+suffix, "bob-"+suffix
+	for _, owner := range []string{aliceID, aliceID, bobID} {
+		if _, err := jm.SubmitFor(owner, "compress", nil, server.PriorityLow); err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+	}
+
+	list := func(identity *server.Identity, params map[string]string) *server.HTTPResponse {
+		return JobListHandler(jm)(&server.HTTPRequest{
+			Method: "GET", Path: "/jobs", Version: "HTTP/1.1",
+			Headers: make(map[string]string), Params: params, Identity: identity,
+		})
+	}
+	decode := func(resp *server.HTTPResponse) server.JobPage {
+		var page server.JobPage
+		if err := json.Unmarshal([]byte(resp.Body), &page); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		return page
+	}
+
+	resp := list(nil, map[string]string{"task": "compress", "priority": "low", "limit": "2"})
+	page := decode(resp)
+	if resp.StatusCode != 200 || page.Count != 2 || page.Total < 3 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %d %s", resp.StatusCode, resp.Body)
+	}
+	next := decode(list(nil, map[string]string{"task": "compress", "priority": "low", "limit": "2", "cursor": page.NextCursor}))
+	if next.Count == 0 || next.Jobs[0].ID == page.Jobs[1].ID {
+		t.Errorf("Unexpected second page: %+v", next)
+	}
+
+	// Una identidad sin admin solo ve sus jobs y no puede pedir los de otra
+	alice := &server.Identity{ID: aliceID}
+	page = decode(list(alice, map[string]string{"task": "compress"}))
+	if page.Total != 2 {
+		t.Errorf("Expected alice to see her 2 jobs, got %d", page.Total)
+	}
+	if resp := list(alice, map[string]string{"owner": bobID}); resp.StatusCode != 403 {
+		t.Errorf("Expected 403 listing another owner's jobs, got %d", resp.StatusCode)
+	}
+	if page := decode(list(&server.Identity{ID: "root", Admin: true}, map[string]string{"owner": bobID, "task": "compress"})); page.Total != 1 {
+		t.Errorf("Expected an admin to see bob's job, got %d", page.Total)
+	}
+
+	resp = list(nil, map[string]string{"status": "done,lost", "created_after": "yesterday", "sort": "name"})
+	var problem struct {
+		InvalidParams []server.ParamError `json:"invalid_params"`
+	}
+	json.Unmarshal([]byte(resp.Body), &problem)
+	if resp.StatusCode != 400 || len(problem.InvalidParams) != 3 {
+		t.Fatalf("Expected status, created_after and sort to be invalid, got %d %s", resp.StatusCode, resp.Body)
+	}
+	for i, name := range []string{"status", "created_after", "sort"} {
+		if problem.InvalidParams[i].Name != name {
+			t.Errorf("Expected invalid param %d to be %s, got %s", i, name, problem.InvalidParams[i].Name)
+		}
+	}
+	if resp := list(nil, map[string]string{"cursor": "bogus"}); resp.StatusCode != 400 {
+		t.Errorf("Expected 400 for an invalid cursor, got %d", resp.StatusCode)
+	}
+}
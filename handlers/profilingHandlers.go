@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"GoDocker/server"
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefijo de las rutas de profiling (el mismo que net/http/pprof, así go tool pprof las reconoce)
+const PprofPrefix = "/debug/pprof"
+
+// Límites de duración de /profile y /trace: ocupan un worker mientras muestrean
+const (
+	defaultProfileSeconds = 30
+	defaultTraceSeconds   = 1
+	maxProfileSeconds     = 120
+)
+
+// pprofProfiles son los perfiles de runtime/pprof expuestos en /debug/pprof/<nombre>
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// samplingMu serializa la activación temporal del muestreo de block y mutex
+var samplingMu sync.Mutex
+
+// RegisterProfiling registra las rutas de profiling, todas envueltas en AdminOnly
+func RegisterProfiling(srv *server.Server) {
+	seconds := func(def int) server.Param {
+		return server.IntParam("seconds", "Duración del muestreo").Between(1, maxProfileSeconds).WithDefault(strconv.Itoa(def))
+	}
+	index := profilingDoc("Lista de perfiles disponibles")
+	index.Responses[0] = server.Response{Status: 200, Description: "Listado en texto", ContentType: "text/plain; charset=utf-8"}
+
+	srv.HandleFunc("GET", PprofPrefix, AdminOnly(PprofIndexHandler), index)
+	srv.HandleFunc("GET", PprofPrefix+"/", AdminOnly(PprofIndexHandler), index)
+	srv.HandleFunc("GET", PprofPrefix+"/profile", AdminOnly(CPUProfileHandler),
+		profilingDoc("Perfil de CPU", seconds(defaultProfileSeconds)))
+	srv.HandleFunc("GET", PprofPrefix+"/trace", AdminOnly(TraceHandler),
+		profilingDoc("Traza de ejecución", seconds(defaultTraceSeconds)))
+	for _, name := range pprofProfiles {
+		params := []server.Param{server.IntParam("debug", "0 = pprof binario, 1-2 = texto").Between(0, 2).WithDefault("0")}
+		switch name {
+		case "heap", "allocs":
+			params = append(params, server.EnumParam("gc", "1 fuerza un GC antes de escribir el perfil", "1"))
+		case "block", "mutex":
+			params = append(params, server.IntParam("seconds", "Activa el muestreo durante N segundos si estaba apagado").Between(1, maxProfileSeconds))
+		}
+		srv.HandleFunc("GET", PprofPrefix+"/"+name, AdminOnly(PprofProfileHandler(name)),
+			profilingDoc("Perfil "+name, params...))
+	}
+}
+
+// AdminOnly rechaza con 403 las peticiones sin una identidad admin. Sin autenticación
+// configurada no hay identidades, así que las rutas quedan cerradas.
+func AdminOnly(next server.HandlerFunc) server.HandlerFunc {
+	return func(req *server.HTTPRequest) *server.HTTPResponse {
+		if req.Identity == nil || !req.Identity.Admin {
+			return server.Forbidden("route requires an admin identity")
+		}
+		return next(req)
+	}
+}
+
+// /debug/pprof/ lista los perfiles disponibles
+func PprofIndexHandler(req *server.HTTPRequest) *server.HTTPResponse {
+	var body strings.Builder
+	body.WriteString("Perfiles disponibles (formato pprof; ?debug=1 para texto):\n\n")
+	body.WriteString(fmt.Sprintf("%-14s %s\n", "profile", "CPU durante ?seconds=N (default 30)"))
+	body.WriteString(fmt.Sprintf("%-14s %s\n", "trace", "traza de ejecución durante ?seconds=N (default 1)"))
+
+	profiles := pprof.Profiles()
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name() < profiles[j].Name() })
+	for _, p := range profiles {
+		body.WriteString(fmt.Sprintf("%-14s %d\n", p.Name(), p.Count()))
+	}
+
+	return &server.HTTPResponse{
+		StatusCode: 200,
+		StatusText: "OK",
+		Body:       body.String(),
+		Headers: map[string]string{
+			"Content-Type": "text/plain; charset=utf-8",
+		},
+	}
+}
+
+// /debug/pprof/profile?seconds=N
+func CPUProfileHandler(req *server.HTTPRequest) *server.HTTPResponse {
+	seconds, errResp := profileSeconds(req, defaultProfileSeconds)
+	if errResp != nil {
+		return errResp
+	}
+
+	var buf bytes.Buffer
+	// Solo puede haber un perfil de CPU activo en el proceso
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return server.ErrorResponse(409, "cpu profiling already in progress")
+	}
+	cancelled := sleepFor(req, seconds)
+	pprof.StopCPUProfile()
+	if cancelled {
+		return server.ErrorResponse(503, "profiling cancelled")
+	}
+
+	return profileResponse(buf.Bytes(), "cpu.pprof")
+}
+
+// /debug/pprof/trace?seconds=N
+func TraceHandler(req *server.HTTPRequest) *server.HTTPResponse {
+	seconds, errResp := profileSeconds(req, defaultTraceSeconds)
+	if errResp != nil {
+		return errResp
+	}
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		return server.ErrorResponse(409, "tracing already in progress")
+	}
+	cancelled := sleepFor(req, seconds)
+	trace.Stop()
+	if cancelled {
+		return server.ErrorResponse(503, "tracing cancelled")
+	}
+
+	return profileResponse(buf.Bytes(), "trace.out")
+}
+
+// /debug/pprof/<nombre>?debug=0|1|2&gc=1&seconds=N
+//
+// heap y allocs aceptan gc=1 para forzar un GC antes (datos al día). block y mutex solo
+// registran eventos con el muestreo activo: con seconds=N y muestreo apagado se activa
+// durante N segundos y se vuelve a apagar. Los perfiles son acumulados desde el arranque.
+func PprofProfileHandler(name string) server.HandlerFunc {
+	return func(req *server.HTTPRequest) *server.HTTPResponse {
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			return server.ErrorResponse(404, "unknown profile")
+		}
+
+		debug := 0
+		if val := req.Params["debug"]; val != "" {
+			d, err := strconv.Atoi(val)
+			if err != nil || d < 0 || d > 2 {
+				return server.ErrorResponse(400, "debug must be 0, 1 or 2")
+			}
+			debug = d
+		}
+
+		if req.Params["gc"] == "1" && (name == "heap" || name == "allocs") {
+			runtime.GC()
+		}
+
+		if _, ok := req.Params["seconds"]; ok && (name == "block" || name == "mutex") {
+			seconds, errResp := profileSeconds(req, 0)
+			if errResp != nil {
+				return errResp
+			}
+			if cancelled := sampleContention(req, name, seconds); cancelled {
+				return server.ErrorResponse(503, "profiling cancelled")
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := profile.WriteTo(&buf, debug); err != nil {
+			return server.ErrorResponse(500, "failed to write profile")
+		}
+		if debug > 0 {
+			return &server.HTTPResponse{
+				StatusCode: 200,
+				StatusText: "OK",
+				Body:       buf.String(),
+				Headers: map[string]string{
+					"Content-Type": "text/plain; charset=utf-8",
+				},
+			}
+		}
+		return profileResponse(buf.Bytes(), name+".pprof")
+	}
+}
+
+// sampleContention activa el muestreo de block o mutex durante seconds si estaba apagado
+func sampleContention(req *server.HTTPRequest, name string, seconds int) bool {
+	samplingMu.Lock()
+	defer samplingMu.Unlock()
+
+	if name == "mutex" {
+		previous := runtime.SetMutexProfileFraction(-1)
+		if previous == 0 {
+			runtime.SetMutexProfileFraction(1)
+			defer runtime.SetMutexProfileFraction(0)
+		}
+		return sleepFor(req, seconds)
+	}
+
+	// No hay forma de leer la tasa de block actual: se activa y se apaga al terminar
+	runtime.SetBlockProfileRate(1)
+	defer runtime.SetBlockProfileRate(0)
+	return sleepFor(req, seconds)
+}
+
+// profileSeconds lee ?seconds=N, acotado a maxProfileSeconds
+func profileSeconds(req *server.HTTPRequest, def int) (int, *server.HTTPResponse) {
+	val := req.Params["seconds"]
+	if val == "" {
+		if def <= 0 {
+			return 0, server.ErrorResponse(400, "missing required query parameter 'seconds'")
+		}
+		return def, nil
+	}
+	seconds, err := strconv.Atoi(val)
+	if err != nil || seconds <= 0 {
+		return 0, server.ErrorResponse(400, "seconds must be a positive integer")
+	}
+	if seconds > maxProfileSeconds {
+		return 0, server.ErrorResponse(400, fmt.Sprintf("seconds must be at most %d", maxProfileSeconds))
+	}
+	return seconds, nil
+}
+
+// sleepFor espera seconds o hasta que se cancele la petición; retorna true si se canceló
+func sleepFor(req *server.HTTPRequest, seconds int) bool {
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return false
+	case <-req.Context().Done():
+		return true
+	}
+}
+
+// profileResponse envía el perfil binario como descarga
+func profileResponse(data []byte, filename string) *server.HTTPResponse {
+	return &server.HTTPResponse{
+		StatusCode: 200,
+		StatusText: "OK",
+		Body:       string(data),
+		Headers: map[string]string{
+			"Content-Type":           "application/octet-stream",
+			"Content-Disposition":    fmt.Sprintf(`attachment; filename="%s"`, filename),
+			"X-Content-Type-Options": "nosniff",
+		},
+	}
+}
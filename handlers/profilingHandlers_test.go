@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"GoDocker/server"
+	"strings"
+	"testing"
+)
+
+// adminRequest arma una petición GET autenticada como admin
+func adminRequest(path string, params map[string]string) *server.HTTPRequest {
+	return &server.HTTPRequest{
+		Method:   "GET",
+		Path:     path,
+		Version:  "HTTP/1.1",
+		Headers:  map[string]string{},
+		Params:   params,
+		Identity: &server.Identity{ID: "ops", Admin: true},
+	}
+}
+
+func TestAdminOnly(t *testing.T) {
+	handler := AdminOnly(PprofIndexHandler)
+
+	for _, identity := range []*server.Identity{nil, {ID: "alice", Roles: []string{"operator"}}} {
+		req := adminRequest(PprofPrefix+"/", map[string]string{})
+		req.Identity = identity
+		if resp := handler(req); resp.StatusCode != 403 {
+			t.Errorf("Identity %+v: expected 403, got %d", identity, resp.StatusCode)
+		}
+	}
+
+	resp := handler(adminRequest(PprofPrefix+"/", map[string]string{}))
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, "goroutine") {
+		t.Errorf("Expected index for admin, got %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestPprofProfileHandler(t *testing.T) {
+	resp := PprofProfileHandler("heap")(adminRequest(PprofPrefix+"/heap", map[string]string{"gc": "1"}))
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	// El formato pprof es un protobuf comprimido con gzip
+	if !strings.HasPrefix(resp.Body, "\x1f\x8b") {
+		t.Error("Expected gzip-compressed pprof profile")
+	}
+	if resp.Headers["Content-Type"] != "application/octet-stream" {
+		t.Errorf("Unexpected Content-Type %s", resp.Headers["Content-Type"])
+	}
+
+	resp = PprofProfileHandler("goroutine")(adminRequest(PprofPrefix+"/goroutine", map[string]string{"debug": "1"}))
+	if resp.StatusCode != 200 || !strings.Contains(resp.Body, "goroutine profile:") {
+		t.Errorf("Expected text goroutine profile, got %d: %.80s", resp.StatusCode, resp.Body)
+	}
+
+	resp = PprofProfileHandler("heap")(adminRequest(PprofPrefix+"/heap", map[string]string{"debug": "7"}))
+	if resp.StatusCode != 400 {
+		t.Errorf("Expected 400 for invalid debug, got %d", resp.StatusCode)
+	}
+
+	resp = PprofProfileHandler("mutex")(adminRequest(PprofPrefix+"/mutex", map[string]string{"seconds": "1"}))
+	if resp.StatusCode != 200 {
+		t.Errorf("Expected 200 for sampled mutex profile, got %d", resp.StatusCode)
+	}
+}
+
+func TestCPUProfileAndTrace(t *testing.T) {
+	resp := CPUProfileHandler(adminRequest(PprofPrefix+"/profile", map[string]string{"seconds": "1"}))
+	if resp.StatusCode != 200 || !strings.HasPrefix(resp.Body, "\x1f\x8b") {
+		t.Errorf("Expected gzip CPU profile, got %d", resp.StatusCode)
+	}
+
+	resp = TraceHandler(adminRequest(PprofPrefix+"/trace", map[string]string{"seconds": "1"}))
+	if resp.StatusCode != 200 || !strings.HasPrefix(resp.Body, "go 1.") {
+		t.Errorf("Expected execution trace, got %d: %.20q", resp.StatusCode, resp.Body)
+	}
+
+	for _, seconds := range []string{"0", "abc", "1000"} {
+		resp := CPUProfileHandler(adminRequest(PprofPrefix+"/profile", map[string]string{"seconds": seconds}))
+		if resp.StatusCode != 400 {
+			t.Errorf("seconds=%s: expected 400, got %d", seconds, resp.StatusCode)
+		}
+	}
+}
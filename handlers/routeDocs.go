@@ -0,0 +1,417 @@
+package handlers
+
+import "GoDocker/server"
+
+// Metadata OpenAPI de cada ruta; main.go la pasa a HandleFunc junto con el handler.
+// Los parámetros son las mismas especificaciones con las que cada handler llama a Bind.
+
+// Tags de la especificación
+const (
+	tagGeneral   = "general"
+	tagBasic     = "basic"
+	tagCPU       = "cpu"
+	tagIO        = "io"
+	tagJobs      = "jobs"
+	tagOps       = "operations"
+	tagProfiling = "profiling"
+)
+
+var (
+	HelloDoc = server.RouteDoc{
+		Summary: "Página de bienvenida",
+		Tags:    []string{tagGeneral},
+		Responses: []server.Response{
+			{Status: 200, Description: "Página HTML", ContentType: "text/html; charset=utf-8"},
+		},
+	}
+
+	StatusDoc = server.RouteDoc{
+		Summary:     "Estado del servidor",
+		Description: "Conexiones, circuit breakers, readiness y resumen del runtime. `status` es `degraded` con breakers abiertos o sin readiness.",
+		Tags:        []string{tagOps},
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{
+				"status":        "ok",
+				"readiness":     "pass",
+				"stats":         map[string]interface{}{"total_connections": 1234, "active_connections": 5, "queue_size": 2},
+				"open_breakers": []string{},
+				"runtime":       map[string]interface{}{"goroutines": 42, "heap_mb": 12.5, "cpu_percent": 3.2},
+			}},
+		},
+	}
+
+	MetricsDoc = server.RouteDoc{
+		Summary:     "Métricas detalladas",
+		Description: "JSON por defecto; formato de texto de Prometheus con `format=prometheus` o un `Accept` de texto plano / OpenMetrics.",
+		Tags:        []string{tagOps},
+		Params: []server.Param{
+			server.EnumParam("format", "Formato de salida (tiene prioridad sobre Accept)", "json", "prometheus"),
+			server.StringParam("window", "Ventana de las estadísticas por endpoint (entre 15s y 15m)").WithDefault("5m").WithExample("1m"),
+		},
+		Responses: []server.Response{
+			{Status: 200, Description: "Métricas por endpoint, pools, caché, runtime..."},
+		},
+	}
+
+	HealthzDoc = server.RouteDoc{
+		Summary:     "Liveness",
+		Description: "Ejecuta los checks de liveness. 503 si falla alguno crítico.",
+		Tags:        []string{tagOps},
+		Responses:   healthResponses,
+	}
+
+	ReadyzDoc = server.RouteDoc{
+		Summary:     "Readiness",
+		Description: "Ejecuta todos los checks (saturación de colas, JobManager, disco, xz). 503 si falla alguno crítico o durante el shutdown.",
+		Tags:        []string{tagOps},
+		Responses:   healthResponses,
+	}
+
+	healthResponses = []server.Response{
+		{Status: 200, Description: "Todos los checks críticos pasan", Example: map[string]interface{}{
+			"status": "pass",
+			"checks": map[string]interface{}{
+				"job_manager": map[string]interface{}{"status": "pass", "critical": true, "duration_ms": 0.01, "cached": false},
+			},
+		}},
+		{Status: 503, Description: "Falló algún check crítico (mismo formato que el 200)", ContentType: "application/json"},
+	}
+
+	FaviconDoc = server.RouteDoc{
+		Summary:   "Favicon (vacío)",
+		Tags:      []string{tagGeneral},
+		Responses: []server.Response{{Status: 204, Description: "Sin contenido"}},
+	}
+
+	EchoDoc = server.RouteDoc{
+		Summary: "Devuelve método, path, headers y body de la petición",
+		Tags:    []string{tagGeneral},
+		Responses: []server.Response{
+			{Status: 200, Description: "Página HTML", ContentType: "text/html; charset=utf-8"},
+		},
+	}
+
+	PingDoc = server.RouteDoc{
+		Summary: "Health check simple",
+		Tags:    []string{tagOps},
+		Responses: []server.Response{
+			{Status: 200, Description: "pong", ContentType: "text/plain"},
+		},
+	}
+
+	TimeDoc = server.RouteDoc{
+		Summary: "Hora actual en varios formatos",
+		Tags:    []string{tagGeneral},
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{
+				"unix": "1760000000", "rfc3339": "2025-10-09T08:53:20Z", "rfc1123": "Thu, 09 Oct 2025 08:53:20 UTC", "formatted": "2025-10-09 08:53:20 UTC",
+			}},
+		},
+	}
+
+	HelpDoc = server.RouteDoc{
+		Summary: "Ayuda en HTML",
+		Tags:    []string{tagGeneral},
+		Responses: []server.Response{
+			{Status: 200, Description: "Página HTML", ContentType: "text/html"},
+		},
+	}
+
+	FibonacciDoc = server.RouteDoc{
+		Summary: "Primeros n números de Fibonacci",
+		Tags:    []string{tagCPU},
+		Params:  withFormat(fibonacciParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34}},
+			{Status: 413, Description: "n mayor que 1000"},
+		},
+	}
+
+	CreateFileDoc = server.RouteDoc{
+		Summary: "Crea un archivo repitiendo un contenido",
+		Tags:    []string{tagBasic},
+		Params:  createFileParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"message": "file created successfully"}},
+		},
+	}
+
+	DeleteFileDoc = server.RouteDoc{
+		Summary: "Borra un archivo",
+		Tags:    []string{tagBasic},
+		Params:  deleteFileParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"message": "file deleted successfully"}},
+		},
+	}
+
+	ReverseDoc = server.RouteDoc{
+		Summary: "Invierte un texto",
+		Tags:    []string{tagBasic},
+		Params:  textParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"reversed": "aloh"}},
+		},
+	}
+
+	ToUpperDoc = server.RouteDoc{
+		Summary: "Convierte un texto a mayúsculas",
+		Tags:    []string{tagBasic},
+		Params:  textParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"upper": "HOLA"}},
+		},
+	}
+
+	RandomDoc = server.RouteDoc{
+		Summary:     "Número pseudoaleatorio en [min, max]",
+		Description: "El rango (max - min) no puede superar 1.000.000.",
+		Tags:        []string{tagBasic},
+		Params:      randomParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"random": 42, "min": 1, "max": 100, "range": 100, "algorithm": "LCG (Linear Congruential Generator)"}},
+		},
+	}
+
+	HashDoc = server.RouteDoc{
+		Summary: "Hash DJB2 de un texto",
+		Tags:    []string{tagBasic},
+		Params:  textParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"hash": 2090320585, "text": "hola"}},
+		},
+	}
+
+	SimulateDoc = server.RouteDoc{
+		Summary: "Simula una tarea CPU durante unos segundos",
+		Tags:    []string{tagBasic},
+		Params:  simulateParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"status": "completed", "task": "demo", "duration": "2s", "operations": 123456}},
+		},
+	}
+
+	SleepDoc = server.RouteDoc{
+		Summary: "Espera activa durante unos segundos",
+		Tags:    []string{tagBasic},
+		Params:  sleepParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"status": "completed", "requested_sleep": "2s", "actual_duration": "2.000134s", "iterations": 1000}},
+		},
+	}
+
+	LoadTestDoc = server.RouteDoc{
+		Summary: "Ejecuta N tareas con una espera cada una",
+		Tags:    []string{tagBasic},
+		Params:  loadTestParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"status": "completed", "total_tasks": 10, "sleep_per_task": "100ms", "total_duration": "1.01s", "total_operations": 10, "tasks_per_second": 9.9}},
+		},
+	}
+
+	IsPrimeDoc = server.RouteDoc{
+		Summary:     "Indica si un número es primo",
+		Description: "Respuesta cacheada 1h; peticiones idénticas simultáneas comparten una ejecución.",
+		Tags:        []string{tagCPU},
+		Params:      withFormat(isPrimeParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"number": 97, "isPrime": true}},
+		},
+	}
+
+	FactorDoc = server.RouteDoc{
+		Summary:     "Divisores de un número",
+		Description: "Respuesta cacheada 1h; peticiones idénticas simultáneas comparten una ejecución.",
+		Tags:        []string{tagCPU},
+		Params:      withFormat(factorParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"number": 12, "factors": []int{1, 2, 3, 4, 6, 12}}},
+		},
+	}
+
+	PiDoc = server.RouteDoc{
+		Summary:     "Dígitos de π (fórmula de Machin)",
+		Description: "Respuesta cacheada 1h; peticiones idénticas simultáneas comparten una ejecución, que se cancela si todos los clientes se desconectan.",
+		Tags:        []string{tagCPU},
+		Params:      withFormat(piParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"digits": 10, "pi": "3.1415926535"}},
+		},
+	}
+
+	MandelbrotDoc = server.RouteDoc{
+		Summary:     "Conjunto de Mandelbrot",
+		Description: "Con `filename` además guarda una imagen PGM (esa variante no se cachea).",
+		Tags:        []string{tagCPU},
+		Params:      withFormat(mandelbrotParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{
+				"width": 2, "height": 1, "max_iter": 100, "iterations": [][]int{{3, 100}},
+				"stats": map[string]interface{}{"total_pixels": 2, "computed_pixels": 2, "coordinate_system": "complex plane from -2-2i to 2+2i"},
+			}},
+		},
+	}
+
+	MatrixMulDoc = server.RouteDoc{
+		Summary:     "Multiplica dos matrices aleatorias de size×size (size hasta 200)",
+		Description: "Las matrices se generan a partir de `seed`, así que la respuesta es determinista y se cachea.",
+		Tags:        []string{tagCPU},
+		Params:      withFormat(matrixMulParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"matrixA": [][]int{{1}}, "matrixB": [][]int{{2}}, "result": [][]int{{2}}}},
+		},
+	}
+
+	SortFileDoc = server.RouteDoc{
+		Summary: "Ordena un archivo de enteros (uno por línea)",
+		Tags:    []string{tagIO},
+		Params:  sortFileParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"output": "pruebas/numbers.txt.sorted", "count": 1000, "duration_ms": 3}},
+			{Status: 404, Description: "El archivo no existe"},
+		},
+	}
+
+	WordCountDoc = server.RouteDoc{
+		Summary: "Cuenta líneas, palabras y bytes de un archivo",
+		Tags:    []string{tagIO},
+		Params:  wordCountParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"lines": 10, "words": 42, "bytes": 256}},
+			{Status: 404, Description: "El archivo no existe"},
+		},
+	}
+
+	GrepDoc = server.RouteDoc{
+		Summary: "Busca una expresión regular en un archivo",
+		Tags:    []string{tagIO},
+		Params:  grepParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"matches": 2, "first_lines": []string{"42", "404"}}},
+			{Status: 404, Description: "El archivo no existe"},
+		},
+	}
+
+	CompressDoc = server.RouteDoc{
+		Summary: "Comprime un archivo con gzip o xz",
+		Tags:    []string{tagIO},
+		Params:  compressParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"output": "pruebas/numbers.txt.gz", "size": 1234}},
+			{Status: 404, Description: "El archivo no existe"},
+		},
+	}
+
+	HashFileDoc = server.RouteDoc{
+		Summary: "Hash de un archivo",
+		Tags:    []string{tagIO},
+		Params:  hashFileParams,
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"algo": "sha256", "hex": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}},
+			{Status: 404, Description: "El archivo no existe"},
+		},
+	}
+
+	JobSubmitDoc = server.RouteDoc{
+		Summary:     "Encola un job asincrónico",
+		Description: "El resto de los parámetros se pasan a la tarea (los mismos que su ruta, p. ej. `num` para isprime).",
+		Tags:        []string{tagJobs},
+		Params: []server.Param{
+			server.EnumParam("task", "Tarea a ejecutar", "isprime", "factor", "pi", "mandelbrot", "matrixmul", "fibonacci",
+				"sortfile", "wordcount", "grep", "compress", "hashfile").Require(),
+			server.EnumParam("prio", "Prioridad", "low", "normal", "high").WithDefault("normal"),
+			server.FormatParam,
+		},
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"job_id": "job-1760000000000000000", "status": "queued"}},
+		},
+	}
+
+	JobListDoc = server.RouteDoc{
+		Summary: "Lista y busca jobs",
+		Description: "Filtros combinables, orden y paginación por cursor: si hay más resultados la respuesta trae `next_cursor`, " +
+			"que se pasa como `cursor` con los mismos filtros. `total` y `counts` cubren todos los jobs que cumplen los filtros; " +
+			"`counts` ignora el filtro de estado. Una identidad que no es admin solo ve sus propios jobs.",
+		Tags:   []string{tagJobs},
+		Params: withFormat(jobListParams...),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{
+				"jobs": []map[string]interface{}{
+					{"job_id": "pi-1760000000000000000", "task": "pi", "status": "done", "priority": "normal", "progress": 100,
+						"created_at": "2026-01-01T10:00:00Z", "completed_at": "2026-01-01T10:00:02Z"},
+				},
+				"count":       1,
+				"total":       12,
+				"counts":      map[string]int{"queued": 0, "running": 1, "done": 10, "error": 1, "canceled": 0, "timeout": 0},
+				"sort":        "-created_at",
+				"next_cursor": "eyJzIjoiLWNyZWF0ZWRfYXQiLCJrIjoxNzYwMDAwMDAwMDAwMDAwMDAwfQ",
+			}},
+			{Status: 403, Description: "owner de otra identidad sin ser admin"},
+		},
+	}
+
+	JobStatusDoc = server.RouteDoc{
+		Summary: "Estado y progreso de un job",
+		Tags:    []string{tagJobs},
+		Params:  withFormat(server.StringParam("id", "ID del job").Require()),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"job_id": "job-1760000000000000000", "status": "running", "progress": 40, "eta_ms": 1200}},
+			{Status: 403, Description: "El job es de otra identidad y no es admin"},
+			{Status: 404, Description: "El job no existe"},
+		},
+	}
+
+	JobResultDoc = server.RouteDoc{
+		Summary: "Resultado de un job (o su estado si no terminó)",
+		Tags:    []string{tagJobs},
+		Params:  withFormat(server.StringParam("id", "ID del job").Require()),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"job_id": "job-1760000000000000000", "task": "isprime", "status": "done", "progress": 100, "result": map[string]interface{}{"number": 97, "isPrime": true}}},
+			{Status: 403, Description: "El job es de otra identidad y no es admin"},
+			{Status: 404, Description: "El job no existe"},
+		},
+	}
+
+	JobCancelDoc = server.RouteDoc{
+		Summary: "Cancela un job encolado o en ejecución",
+		Tags:    []string{tagJobs},
+		Params:  withFormat(server.StringParam("id", "ID del job").Require()),
+		Responses: []server.Response{
+			{Status: 200, Example: map[string]interface{}{"job_id": "job-1760000000000000000", "status": "canceled"}},
+			{Status: 403, Description: "El job es de otra identidad y no es admin"},
+			{Status: 404, Description: "El job no existe"},
+		},
+	}
+
+	OpenAPIDoc = server.RouteDoc{
+		Summary: "Esta especificación (OpenAPI 3)",
+		Tags:    []string{tagGeneral},
+	}
+
+	DocsDoc = server.RouteDoc{
+		Summary: "Explorador HTML de la API",
+		Tags:    []string{tagGeneral},
+		Responses: []server.Response{
+			{Status: 200, Description: "Página HTML", ContentType: "text/html; charset=utf-8"},
+		},
+	}
+)
+
+// profilingDoc arma la metadata de una ruta de /debug/pprof
+func profilingDoc(summary string, params ...server.Param) server.RouteDoc {
+	return server.RouteDoc{
+		Summary:     summary,
+		Description: "Solo para identidades admin.",
+		Tags:        []string{tagProfiling},
+		Params:      params,
+		Responses: []server.Response{
+			{Status: 200, Description: "Perfil en formato pprof (o texto con debug > 0)", ContentType: "application/octet-stream"},
+			{Status: 403, Description: "La identidad no es admin"},
+		},
+	}
+}
+
+// withFormat agrega el parámetro format a las rutas que retornan un server.Result
+func withFormat(params ...server.Param) []server.Param {
+	return append(append([]server.Param{}, params...), server.FormatParam)
+}
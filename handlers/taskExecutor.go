@@ -4,9 +4,28 @@ import (
 	"GoDocker/server"
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 )
 
+// taskHandlers son las tareas que se pueden enviar como job y el handler que las ejecuta
+var taskHandlers = map[string]server.HandlerFunc{
+	// CPU-bound tasks
+	"isprime":    IsPrimeHandler,
+	"factor":     FactorHandler,
+	"pi":         PiHandler,
+	"mandelbrot": MandelbrotHandler,
+	"matrixmul":  MatrixMulHandler,
+	"fibonacci":  FibonacciHandler,
+
+	// IO-bound tasks
+	"sortfile":  SortFileHandler,
+	"wordcount": WordCountHandler,
+	"grep":      GrepHandler,
+	"compress":  CompressHandler,
+	"hashfile":  HashFileHandler,
+}
+
 // ServerTaskExecutor implementa TaskExecutor para ejecutar tareas del servidor
 type ServerTaskExecutor struct {
 	srv *server.Server
@@ -17,6 +36,16 @@ func NewServerTaskExecutor(srv *server.Server) *ServerTaskExecutor {
 	return &ServerTaskExecutor{srv: srv}
 }
 
+// Tasks retorna las tareas conocidas (implementa server.TaskLister)
+func (e *ServerTaskExecutor) Tasks() []string {
+	tasks := make([]string, 0, len(taskHandlers))
+	for task := range taskHandlers {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
 // Execute ejecuta una tarea basándose en su nombre
 func (e *ServerTaskExecutor) Execute(ctx context.Context, task string, params map[string]string) (map[string]interface{}, error) {
 	// Crear request simulado
@@ -25,41 +54,17 @@ func (e *ServerTaskExecutor) Execute(ctx context.Context, task string, params ma
 		Path:   "/" + task,
 		Params: params,
 	}
+	// Si el job se cancela, la petición deja de esperar (y, sin otros interesados, se aborta el cálculo)
+	req = req.WithContext(ctx)
 
-	// Ejecutar handler correspondiente según el task
-	var resp *server.HTTPResponse
-
-	switch task {
-	// CPU-bound tasks
-	case "isprime":
-		resp = IsPrimeHandler(req)
-	case "factor":
-		resp = FactorHandler(req)
-	case "pi":
-		resp = PiHandler(req)
-	case "mandelbrot":
-		resp = MandelbrotHandler(req)
-	case "matrixmul":
-		resp = MatrixMulHandler(req)
-	case "fibonacci":
-		resp = FibonacciHandler(req)
-
-	// IO-bound tasks
-	case "sortfile":
-		resp = SortFileHandler(req)
-	case "wordcount":
-		resp = WordCountHandler(req)
-	case "grep":
-		resp = GrepHandler(req)
-	case "compress":
-		resp = CompressHandler(req)
-	case "hashfile":
-		resp = HashFileHandler(req)
-
-	default:
+	handler, ok := taskHandlers[task]
+	if !ok {
 		return nil, fmt.Errorf("unknown task: %s", task)
 	}
 
+	// Pasar por la caché de respuestas: los jobs reutilizan lo calculado por las rutas y viceversa
+	resp := e.srv.ServeCached(req, handler)
+
 	// Verificar si el contexto fue cancelado
 	select {
 	case <-ctx.Done():
@@ -76,6 +81,9 @@ func (e *ServerTaskExecutor) Execute(ctx context.Context, task string, params ma
 		"status_code": resp.StatusCode,
 		"body":        resp.Body,
 	}
+	if cacheStatus, ok := resp.Headers["X-Cache"]; ok {
+		result["cache"] = cacheStatus
+	}
 
 	// Intentar parsear métricas si existen en headers
 	if execTime, ok := resp.Headers["X-Exec-Time"]; ok {
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,58 +17,179 @@ import (
 func main() {
 	// Configuración
 	addr := ":8080"
-	poolSize := 50 // Número de workers en el pool
+	poolSize := 50 // Número máximo de workers en el pool
 
-	// Crear servidor
+	// Crear servidor con un pool elástico entre 8 y poolSize workers
 	srv := server.NewServer(addr, poolSize)
+	// WORKER_SCHEDULER=work_stealing usa colas locales por worker en lugar de una compartida
+	scheduler := server.Scheduler(os.Getenv("WORKER_SCHEDULER"))
+	defaultPool := server.DefaultWorkerPoolConfig(8, poolSize)
+	defaultPool.Scheduler = scheduler
+	srv.SetWorkerPoolConfig(defaultPool)
 
 	// Configurar executor de tareas para JobManager
 	executor := handlers.NewServerTaskExecutor(srv)
 	srv.GetJobManager().SetExecutor(executor)
 
 	// Registrar handlers
-	srv.HandleFunc("GET", "/", handlers.HelloHandler)
-	srv.HandleFunc("GET", "/status", handlers.StatusHandler(srv))
-	srv.HandleFunc("GET", "/metrics", handlers.MetricsHandler(srv))
-	srv.HandleFunc("GET", "/favicon.ico", handlers.FaviconHandler)
-	srv.HandleFunc("GET", "/echo", handlers.EchoHandler)
-	srv.HandleFunc("POST", "/echo", handlers.EchoHandler)
-	srv.HandleFunc("GET", "/ping", handlers.PingHandler)
-	srv.HandleFunc("GET", "/time", handlers.TimeHandler) // /time
+	srv.HandleFunc("GET", "/", handlers.HelloHandler, handlers.HelloDoc)
+	srv.HandleFunc("GET", "/status", handlers.StatusHandler(srv), handlers.StatusDoc)
+	srv.HandleFunc("GET", "/metrics", handlers.MetricsHandler(srv), handlers.MetricsDoc)
+	srv.HandleFunc("GET", "/favicon.ico", handlers.FaviconHandler, handlers.FaviconDoc)
+	srv.HandleFunc("GET", "/echo", handlers.EchoHandler, handlers.EchoDoc)
+	srv.HandleFunc("POST", "/echo", handlers.EchoHandler, handlers.EchoDoc)
+	srv.HandleFunc("GET", "/ping", handlers.PingHandler, handlers.PingDoc)
+	srv.HandleFunc("GET", "/healthz", handlers.HealthzHandler(srv), handlers.HealthzDoc)
+	srv.HandleFunc("GET", "/readyz", handlers.ReadyzHandler(srv), handlers.ReadyzDoc)
+	srv.HandleFunc("GET", "/time", handlers.TimeHandler, handlers.TimeDoc)
+
+	// Documentación: especificación OpenAPI generada de la metadata de cada ruta y su explorador
+	srv.SetAPIInfo(server.APIInfo{
+		Title:       "GoDocker HTTP Service",
+		Version:     "1.0.0",
+		Description: "Servidor HTTP con pools por tipo de carga, jobs asincrónicos, caché y métricas.",
+	})
+	srv.HandleFunc("GET", "/openapi.json", handlers.OpenAPIHandler(srv), handlers.OpenAPIDoc)
+	srv.HandleFunc("GET", "/docs", handlers.DocsHandler, handlers.DocsDoc)
 
 	// Rutas basicas
-	srv.HandleFunc("GET", "/fibonacci", handlers.FibonacciHandler) // /fibonacci?num=N
-	srv.HandleFunc("POST", "/file", handlers.CreateFileHandler)    // /createFile?name=filename&content=text&repeat=x
-	srv.HandleFunc("DELETE", "/file", handlers.DeleteFileHandler)  // /deleteFile?name=filename
-	srv.HandleFunc("PUT", "/reverse", handlers.ReverseHandler)     // /reverse?text=yourtext
-	srv.HandleFunc("PUT", "/toupper", handlers.ToUpperHandler)     // /toupper?text=yourtext
-	srv.HandleFunc("GET", "/random", handlers.RandomNumberHandler) // /random?min=x&max=y
-	srv.HandleFunc("PUT", "/hash", handlers.HashHandler)           // /hash?text=yourtext
-	srv.HandleFunc("POST", "/simulate", handlers.SimulateHandler)  // /simulate?seconds=s&task=name
-	srv.HandleFunc("POST", "/sleep", handlers.SleepHandler)        // /sleep?seconds=s
-	srv.HandleFunc("POST", "/loadtest", handlers.LoadTestHandler)  // /loadtest?tasks=n&sleep=x
-	srv.HandleFunc("GET", "/help", handlers.HelpHandler)           // /help
+	srv.HandleFunc("GET", "/fibonacci", handlers.FibonacciHandler, handlers.FibonacciDoc)
+	srv.HandleFunc("POST", "/file", handlers.CreateFileHandler, handlers.CreateFileDoc)
+	srv.HandleFunc("DELETE", "/file", handlers.DeleteFileHandler, handlers.DeleteFileDoc)
+	srv.HandleFunc("PUT", "/reverse", handlers.ReverseHandler, handlers.ReverseDoc)
+	srv.HandleFunc("PUT", "/toupper", handlers.ToUpperHandler, handlers.ToUpperDoc)
+	srv.HandleFunc("GET", "/random", handlers.RandomNumberHandler, handlers.RandomDoc)
+	srv.HandleFunc("PUT", "/hash", handlers.HashHandler, handlers.HashDoc)
+	srv.HandleFunc("POST", "/simulate", handlers.SimulateHandler, handlers.SimulateDoc)
+	srv.HandleFunc("POST", "/sleep", handlers.SleepHandler, handlers.SleepDoc)
+	srv.HandleFunc("POST", "/loadtest", handlers.LoadTestHandler, handlers.LoadTestDoc)
+	srv.HandleFunc("GET", "/help", handlers.HelpHandler, handlers.HelpDoc)
 
 	// CPU-bound
-	srv.HandleFunc("GET", "/isprime", handlers.IsPrimeHandler)       // /isprime?num=N
-	srv.HandleFunc("GET", "/factor", handlers.FactorHandler)         // /factor?num=N
-	srv.HandleFunc("GET", "/pi", handlers.PiHandler)                 // /pi?digits=N
-	srv.HandleFunc("GET", "/mandelbrot", handlers.MandelbrotHandler) // /mandelbrot?width=W&height=H&max_iter=I
-	srv.HandleFunc("GET", "/matrixmul", handlers.MatrixMulHandler)   // /matrixmul?size=N&seed=S
+	srv.HandleFunc("GET", "/isprime", handlers.IsPrimeHandler, handlers.IsPrimeDoc)
+	srv.HandleFunc("GET", "/factor", handlers.FactorHandler, handlers.FactorDoc)
+	srv.HandleFunc("GET", "/pi", handlers.PiHandler, handlers.PiDoc)
+	srv.HandleFunc("GET", "/mandelbrot", handlers.MandelbrotHandler, handlers.MandelbrotDoc)
+	srv.HandleFunc("GET", "/matrixmul", handlers.MatrixMulHandler, handlers.MatrixMulDoc)
 
 	// IO-bound (large file operations)
-	srv.HandleFunc("GET", "/sortfile", handlers.SortFileHandler)   // /sortfile?name=FILE&algo=merge|quick
-	srv.HandleFunc("GET", "/wordcount", handlers.WordCountHandler) // /wordcount?name=FILE
-	srv.HandleFunc("GET", "/grep", handlers.GrepHandler)           // /grep?name=FILE&pattern=REGEX
-	srv.HandleFunc("GET", "/compress", handlers.CompressHandler)   // /compress?name=FILE&codec=gzip|xz
-	srv.HandleFunc("GET", "/hashfile", handlers.HashFileHandler)   // /hashfile?name=FILE&algo=sha256
+	srv.HandleFunc("GET", "/sortfile", handlers.SortFileHandler, handlers.SortFileDoc)
+	srv.HandleFunc("GET", "/wordcount", handlers.WordCountHandler, handlers.WordCountDoc)
+	srv.HandleFunc("GET", "/grep", handlers.GrepHandler, handlers.GrepDoc)
+	srv.HandleFunc("GET", "/compress", handlers.CompressHandler, handlers.CompressDoc)
+	srv.HandleFunc("GET", "/hashfile", handlers.HashFileHandler, handlers.HashFileDoc)
 
 	// Job Management
 	jm := srv.GetJobManager()
-	srv.HandleFunc("POST", "/jobs/submit", handlers.JobSubmitHandler(jm))   // /jobs/submit?task=isprime&num=999999999999999999&prio=high
-	srv.HandleFunc("GET", "/jobs/status", handlers.JobStatusHandler(jm))    // /jobs/status?id=JOB_ID
-	srv.HandleFunc("GET", "/jobs/result", handlers.JobResultHandler(jm))    // /jobs/result?id=JOB_ID
-	srv.HandleFunc("DELETE", "/jobs/cancel", handlers.JobCancelHandler(jm)) // /jobs/cancel?id=JOB_ID
+	srv.HandleFunc("GET", "/jobs", handlers.JobListHandler(jm), handlers.JobListDoc)
+	srv.HandleFunc("POST", "/jobs/submit", handlers.JobSubmitHandler(jm), handlers.JobSubmitDoc)
+	srv.HandleFunc("GET", "/jobs/status", handlers.JobStatusHandler(jm), handlers.JobStatusDoc)
+	srv.HandleFunc("GET", "/jobs/result", handlers.JobResultHandler(jm), handlers.JobResultDoc)
+	srv.HandleFunc("DELETE", "/jobs/cancel", handlers.JobCancelHandler(jm), handlers.JobCancelDoc)
+
+	// Bulkheads: pools separados para que una ráfaga CPU-bound no deje sin workers al resto
+	srv.AddPool("cpu", server.DefaultWorkerPoolConfig(2, runtime.NumCPU()*2), 200)
+	ioPool := server.DefaultWorkerPoolConfig(4, 24)
+	ioPool.Scheduler = scheduler
+	srv.AddPool("io", ioPool, 500)
+	srv.AddPool("control", server.WorkerPoolConfig{MinWorkers: 4, MaxWorkers: 4}, 200)
+	// Un perfil de CPU o una traza ocupan su worker durante segundos: pool propio y chico
+	srv.AddPool("profiling", server.WorkerPoolConfig{MinWorkers: 1, MaxWorkers: 2}, 10)
+	if err := assignPools(srv); err != nil {
+		log.Fatalf("Error asignando pools: %v", err)
+	}
+
+	// Dentro del pool cpu, los cálculos cortos pasan antes que los pesados sin dejarlos sin servicio
+	if err := srv.SetScheduling("cpu",
+		server.SchedulingClass{Name: "interactive", Weight: 4, Priority: 1},
+		server.SchedulingClass{Name: "batch", Weight: 1, Priority: 0, MaxWait: 5 * time.Second},
+	); err != nil {
+		log.Fatalf("Error configurando planificación: %v", err)
+	}
+	for _, path := range []string{"/fibonacci", "/isprime", "/factor"} {
+		srv.AssignClass("", path, "interactive")
+	}
+	for _, path := range []string{"/pi", "/mandelbrot", "/matrixmul"} {
+		srv.AssignClass("", path, "batch")
+	}
+
+	// Caché de respuestas para los cálculos deterministas (también la usan los jobs).
+	// /matrixmul solo es determinista con seed (>= 1) y /mandelbrot con filename escribe un archivo.
+	for _, path := range []string{"/isprime", "/factor", "/pi", "/fibonacci"} {
+		srv.CacheRoute("GET", path, server.CachePolicy{TTL: time.Hour})
+	}
+	srv.CacheRoute("GET", "/mandelbrot", server.CachePolicy{
+		TTL:    time.Hour,
+		Params: []string{"width", "height", "max_iter"},
+		Bypass: []string{"filename"},
+	})
+	srv.CacheRoute("GET", "/matrixmul", server.CachePolicy{
+		TTL:     time.Hour,
+		Params:  []string{"size", "seed"},
+		Require: []string{"seed"},
+	})
+
+	// Peticiones idénticas simultáneas comparten una sola ejecución (p. ej. ráfagas de /pi?digits=1000)
+	srv.CoalesceRoute("GET", "/pi", "digits")
+	srv.CoalesceRoute("GET", "/mandelbrot", "width", "height", "max_iter")
+	srv.CoalesceRoute("GET", "/matrixmul", "size", "seed")
+	srv.CoalesceRoute("GET", "/factor", "num")
+	srv.CoalesceRoute("GET", "/isprime", "num")
+
+	// Circuit breakers: si una dependencia de IO (disco, xz) empieza a fallar, responder 503
+	// de inmediato en lugar de ocupar workers; lo mismo para los jobs de cada tarea
+	for _, path := range []string{"/compress", "/sortfile", "/grep", "/wordcount", "/hashfile"} {
+		srv.ProtectRoute("", path, server.DefaultBreakerConfig())
+	}
+	jm.EnableBreakers(server.DefaultBreakerConfig())
+
+	// CORS para clientes web (CORS_ORIGINS separados por coma). Va antes de la
+	// autenticación para que los preflight OPTIONS no requieran credenciales.
+	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
+		cors, err := setupCORS(strings.Split(origins, ","))
+		if err != nil {
+			log.Fatalf("Error configurando CORS: %v", err)
+		}
+		srv.Use(cors.Middleware())
+	}
+
+	// Autenticación (opcional): API keys y/o JWT según variables de entorno
+	if auth := setupAuth(); auth != nil {
+		srv.SetAuthManager(auth)
+
+		// Profiling (/debug/pprof/*): solo existe con autenticación y solo para admins
+		auth.RequireAdmin([]string{"GET"}, handlers.PprofPrefix+"/*")
+		handlers.RegisterProfiling(srv)
+	}
+
+	// Dependencias de /readyz: disco para archivos y jobs.json, xz para /compress (no crítico)
+	srv.RegisterHealthCheck(server.HealthCheck{
+		Name: "disk_files", Check: server.DiskWritableCheck("pruebas"), CacheTTL: 10 * time.Second, Critical: true,
+	})
+	srv.RegisterHealthCheck(server.HealthCheck{
+		Name: "disk_jobs", Check: server.FileDirWritableCheck("jobs.json"), CacheTTL: 10 * time.Second, Critical: true,
+	})
+	srv.RegisterHealthCheck(server.HealthCheck{
+		Name: "xz", Check: server.CommandCheck("xz"), CacheTTL: time.Minute,
+	})
+
+	// Health checks baratos: se atienden aunque la cola esté saturada
+	srv.BypassQueue("GET", "/ping")
+	srv.BypassQueue("GET", "/status")
+	srv.BypassQueue("GET", "/healthz")
+	srv.BypassQueue("GET", "/readyz")
+
+	// Al apagarse, /readyz responde 503 durante SHUTDOWN_GRACE (5s por defecto) antes de
+	// cerrar el listener, para que el balanceador deje de enviar tráfico
+	shutdownGrace := 5 * time.Second
+	if value := os.Getenv("SHUTDOWN_GRACE"); value != "" {
+		grace, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("SHUTDOWN_GRACE inválido: %v", err)
+		}
+		shutdownGrace = grace
+	}
+	srv.SetShutdownGrace(shutdownGrace)
 
 	// Iniciar servidor
 	if err := srv.Start(); err != nil {
@@ -91,3 +214,104 @@ func main() {
 
 	log.Println("Servidor cerrado exitosamente")
 }
+
+// assignPools asigna cada ruta a su bulkhead; las no listadas van al pool por defecto
+func assignPools(srv *server.Server) error {
+	pools := map[string][]string{
+		"cpu":       {"/fibonacci", "/isprime", "/factor", "/pi", "/mandelbrot", "/matrixmul"},
+		"io":        {"/file", "/sortfile", "/wordcount", "/grep", "/compress", "/hashfile", "/simulate", "/sleep", "/loadtest"},
+		"control":   {"/", "/status", "/metrics", "/ping", "/healthz", "/readyz", "/time", "/help", "/favicon.ico", "/openapi.json", "/docs", "/jobs/*"},
+		"profiling": {handlers.PprofPrefix + "/*"},
+	}
+	for pool, patterns := range pools {
+		for _, pattern := range patterns {
+			if err := srv.AssignPool("", pattern, pool); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setupCORS configura las políticas CORS del dashboard para /jobs/* y /metrics. Ambas usan
+// credenciales, así que CORS_ORIGINS debe listar orígenes concretos (no "*").
+func setupCORS(origins []string) (*server.CORS, error) {
+	cors := server.NewCORS()
+
+	err := cors.AddGroup("/jobs/*", server.CORSConfig{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST", "DELETE"},
+		AllowedHeaders:   []string{"Authorization", "X-API-Key", "Content-Type"},
+		ExposedHeaders:   []string{"Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = cors.AddGroup("/metrics", server.CORSConfig{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET"},
+		AllowedHeaders:   []string{"Authorization", "X-API-Key"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cors, nil
+}
+
+// setupAuth configura la autenticación a partir de variables de entorno:
+// API_KEYS_FILE para API keys y JWT_JWKS_FILE (con JWT_ISSUER/JWT_AUDIENCE) para bearer tokens.
+// Retorna nil si no hay ningún mecanismo configurado.
+func setupAuth() *server.AuthManager {
+	auth := server.NewAuthManager()
+	enabled := false
+
+	if keysFile := os.Getenv("API_KEYS_FILE"); keysFile != "" {
+		store, err := server.LoadAPIKeys(keysFile)
+		if err != nil {
+			log.Fatalf("Error cargando API keys: %v", err)
+		}
+		auth.AddAuthenticator(store)
+		enabled = true
+		log.Printf("Autenticación por API key habilitada (%d keys)", store.Size())
+	}
+
+	if jwksFile := os.Getenv("JWT_JWKS_FILE"); jwksFile != "" {
+		jwtAuth, err := server.NewJWTAuthenticator(server.JWTConfig{
+			JWKSFile:  jwksFile,
+			Issuer:    os.Getenv("JWT_ISSUER"),
+			Audience:  os.Getenv("JWT_AUDIENCE"),
+			ClockSkew: 30 * time.Second,
+			RolePermissions: map[string][]server.AccessRule{
+				"viewer":    {{Methods: []string{"GET"}, Pattern: "*"}},
+				"submitter": {{Methods: []string{"GET"}, Pattern: "*"}, {Methods: []string{"POST", "DELETE"}, Pattern: "/jobs/*"}},
+				"operator":  {{Pattern: "*"}},
+			},
+		})
+		if err != nil {
+			log.Fatalf("Error configurando JWT: %v", err)
+		}
+		auth.AddAuthenticator(jwtAuth)
+		enabled = true
+		log.Println("Autenticación por JWT habilitada")
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	// Rutas públicas
+	for _, path := range []string{"/", "/status", "/ping", "/healthz", "/readyz", "/time", "/help", "/favicon.ico", "/openapi.json", "/docs"} {
+		auth.AllowPublic([]string{"GET"}, path)
+	}
+
+	// Rutas administrativas que requieren roles específicos
+	auth.RequireRoles([]string{"GET"}, "/metrics", "operator")
+	auth.RequireRoles([]string{"DELETE"}, "/jobs/cancel", "submitter", "operator")
+
+	return auth
+}
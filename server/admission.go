@@ -0,0 +1,128 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// Motivos por los que se rechaza una petición ya parseada
+const (
+	RejectQueueFull    = "queue_full"    // La cola de conexiones está llena
+	RejectQueueTimeout = "queue_timeout" // La petición esperó en cola más del plazo
+	RejectShuttingDown = "shutting_down" // El servidor se está apagando
+)
+
+// AdmissionConfig configura el control de admisión de la cola de conexiones
+type AdmissionConfig struct {
+	MaxQueueWait      time.Duration // Peticiones que esperaron más se descartan con 503 (0 = sin límite)
+	DefaultRetryAfter time.Duration // Retry-After cuando todavía no hay throughput observado
+	MaxRetryAfter     time.Duration // Tope del Retry-After calculado
+}
+
+// DefaultAdmissionConfig retorna la configuración usada por NewServer
+func DefaultAdmissionConfig() AdmissionConfig {
+	return AdmissionConfig{
+		MaxQueueWait:      10 * time.Second,
+		DefaultRetryAfter: 5 * time.Second,
+		MaxRetryAfter:     60 * time.Second,
+	}
+}
+
+// throughputMeter mide peticiones completadas por segundo en una ventana deslizante
+type throughputMeter struct {
+	mu      sync.Mutex
+	buckets []int64 // Completadas por segundo
+	stamps  []int64 // Segundo unix al que corresponde cada bucket
+}
+
+// newThroughputMeter crea un medidor con una ventana de los últimos seconds segundos
+func newThroughputMeter(seconds int) *throughputMeter {
+	return &throughputMeter{
+		buckets: make([]int64, seconds),
+		stamps:  make([]int64, seconds),
+	}
+}
+
+// Record registra una petición completada
+func (tm *throughputMeter) Record() {
+	now := time.Now().Unix()
+	idx := int(now % int64(len(tm.buckets)))
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.stamps[idx] != now {
+		tm.stamps[idx] = now
+		tm.buckets[idx] = 0
+	}
+	tm.buckets[idx]++
+}
+
+// Rate retorna las peticiones completadas por segundo en la ventana
+func (tm *throughputMeter) Rate() float64 {
+	now := time.Now().Unix()
+	window := int64(len(tm.buckets))
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	var total int64
+	for i, stamp := range tm.stamps {
+		if now-stamp < window {
+			total += tm.buckets[i]
+		}
+	}
+	return float64(total) / float64(window)
+}
+
+// SetAdmissionConfig configura el control de admisión
+func (s *Server) SetAdmissionConfig(config AdmissionConfig) {
+	s.admission = config
+}
+
+// BypassQueue hace que la ruta se atienda directamente en la etapa de lectura,
+// sin pasar por la cola de conexiones (pensado para health checks baratos)
+func (s *Server) BypassQueue(method, path string) {
+	s.bypassMu.Lock()
+	defer s.bypassMu.Unlock()
+	s.bypassRoutes[method+" "+path] = true
+}
+
+// bypassesQueue indica si la petición se atiende sin encolar
+func (s *Server) bypassesQueue(req *HTTPRequest) bool {
+	s.bypassMu.RLock()
+	defer s.bypassMu.RUnlock()
+	return s.bypassRoutes[req.Method+" "+req.Path]
+}
+
+// retryAfter estima en cuántos segundos se habrá drenado la cola del pool
+func (s *Server) retryAfter(bh *Bulkhead) int {
+	config := s.admission
+	seconds := config.DefaultRetryAfter.Seconds()
+
+	if rate := bh.throughput.Rate(); rate > 0 {
+		seconds = float64(bh.queue.Size()) / rate
+	}
+
+	seconds = math.Min(math.Ceil(seconds), config.MaxRetryAfter.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return int(seconds)
+}
+
+// shedRequest responde 503 con Retry-After, registra el motivo y cierra la conexión
+func (s *Server) shedRequest(conn net.Conn, connID int64, req *HTTPRequest, reason string) {
+	defer s.closeConnection(conn)
+
+	s.rejections.Increment(reason)
+	retry := s.retryAfter(s.bulkheadFor(req))
+	log.Printf("Connection %d rechazada (%s): %s %s", connID, reason, req.Method, req.Path)
+
+	s.respond(conn, req, unavailableProblem("server overloaded", retry).
+		WithType(ProblemOverloaded).
+		With("reason", reason).
+		Response())
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sendRaw envía una petición y retorna la status line y los headers de la respuesta
+func sendRaw(t *testing.T, addr, request string) (int, map[string]string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte(request))
+
+	reader := bufio.NewReader(conn)
+	statusLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	parts := strings.Fields(statusLine)
+	code, _ := strconv.Atoi(parts[1])
+
+	headers := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err != nil || line == "" {
+			break
+		}
+		if kv := strings.SplitN(line, ":", 2); len(kv) == 2 {
+			headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return code, headers
+}
+
+func TestLoadShedding(t *testing.T) {
+	release := make(chan struct{})
+	srv := NewServer("127.0.0.1:0", 1)
+	srv.jobManager.persistenceFile = ""
+	srv.AddPool(DefaultPool, WorkerPoolConfig{MinWorkers: 1}, 1)
+	queue := srv.bulkheads[DefaultPool].queue
+	srv.HandleFunc("GET", "/slow", func(req *HTTPRequest) *HTTPResponse {
+		<-release
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+	})
+	srv.HandleFunc("GET", "/ping", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: "pong"}
+	})
+	srv.BypassQueue("GET", "/ping")
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+	defer close(release)
+
+	// Ocupar el único worker y después el único lugar de la cola
+	waitFor := func(cond func() bool) {
+		deadline := time.Now().Add(3 * time.Second)
+		for !cond() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	occupied := []func() bool{
+		func() bool { return srv.busyWorkers.Get() == 1 },
+		func() bool { return queue.Size() == 1 },
+	}
+	for _, cond := range occupied {
+		conn, err := net.Dial("tcp", srv.Addr())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: test\r\n\r\n"))
+		waitFor(cond)
+	}
+
+	code, headers := sendRaw(t, srv.Addr(), "GET /slow HTTP/1.1\r\nHost: test\r\n\r\n")
+	if code != 503 {
+		t.Fatalf("Expected 503 when the queue is full, got %d", code)
+	}
+	if retry, err := strconv.Atoi(headers["Retry-After"]); err != nil || retry < 1 {
+		t.Errorf("Expected a positive Retry-After, got %q", headers["Retry-After"])
+	}
+	if got := srv.rejections.Get(RejectQueueFull); got != 1 {
+		t.Errorf("Expected 1 queue_full rejection, got %d", got)
+	}
+
+	// Las rutas que no pasan por la cola siguen respondiendo
+	if code, _ := sendRaw(t, srv.Addr(), "GET /ping HTTP/1.1\r\nHost: test\r\n\r\n"); code != 200 {
+		t.Errorf("Expected bypass route to answer 200 under load, got %d", code)
+	}
+}
+
+func TestRetryAfterEstimate(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 1)
+	srv.jobManager.persistenceFile = ""
+
+	bh := srv.bulkheads[DefaultPool]
+
+	// Sin throughput observado se usa el valor por defecto
+	if got := srv.retryAfter(bh); got != 5 {
+		t.Errorf("Expected default Retry-After 5, got %d", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		bh.queue.Enqueue(i)
+	}
+	for i := 0; i < 50; i++ {
+		bh.throughput.Record() // 5 peticiones/s en la ventana de 10s
+	}
+	if got := srv.retryAfter(bh); got != 2 {
+		t.Errorf("Expected Retry-After 2 (10 queued / 5 rps), got %d", got)
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// APIKeyHeader es el header donde los clientes envían su API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKey representa una API key registrada. La key nunca se guarda en claro, solo su hash.
+type APIKey struct {
+	ID     string       `json:"id"`
+	Hash   string       `json:"hash"` // "sha256:<hex>" o solo "<hex>"
+	Admin  bool         `json:"admin"`
+	Roles  []string     `json:"roles,omitempty"`
+	Routes []AccessRule `json:"routes"`
+}
+
+// apiKeyFile es el formato del archivo de API keys
+type apiKeyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// APIKeyStore autentica peticiones por API key usando hashes cargados desde archivo
+type APIKeyStore struct {
+	mu     sync.RWMutex
+	path   string
+	byHash map[string]*APIKey // hash hex -> key
+}
+
+// HashAPIKey retorna el hash con el que se debe guardar una API key en el archivo
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// LoadAPIKeys carga las API keys desde un archivo JSON
+func LoadAPIKeys(path string) (*APIKeyStore, error) {
+	store := &APIKeyStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload vuelve a leer el archivo de keys, reemplazando las actuales
+func (s *APIKeyStore) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("error leyendo API keys: %w", err)
+	}
+
+	var file apiKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("error parseando API keys: %w", err)
+	}
+
+	byHash := make(map[string]*APIKey, len(file.Keys))
+	for i := range file.Keys {
+		key := &file.Keys[i]
+		if key.ID == "" {
+			return fmt.Errorf("API key %d sin id", i)
+		}
+		hash := strings.ToLower(strings.TrimPrefix(key.Hash, "sha256:"))
+		if len(hash) != sha256.Size*2 {
+			return fmt.Errorf("API key %q con hash inválido", key.ID)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return fmt.Errorf("API key %q con hash inválido", key.ID)
+		}
+		byHash[hash] = key
+	}
+
+	s.mu.Lock()
+	s.byHash = byHash
+	s.mu.Unlock()
+	return nil
+}
+
+// Size retorna la cantidad de keys cargadas
+func (s *APIKeyStore) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.byHash)
+}
+
+// Scheme retorna el esquema usado en WWW-Authenticate
+func (s *APIKeyStore) Scheme() string {
+	return "ApiKey"
+}
+
+// Authenticate valida la API key enviada en el header X-API-Key
+func (s *APIKeyStore) Authenticate(req *HTTPRequest) (*Identity, error) {
+	presented := req.GetHeader(APIKeyHeader)
+	if presented == "" {
+		return nil, ErrNoCredentials
+	}
+
+	sum := sha256.Sum256([]byte(presented))
+	hash := hex.EncodeToString(sum[:])
+
+	s.mu.RLock()
+	key, ok := s.byHash[hash]
+	s.mu.RUnlock()
+
+	// Comparación en tiempo constante sobre el hash encontrado
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(strings.ToLower(key.Hash), "sha256:")), []byte(hash)) != 1 {
+		return nil, fmt.Errorf("unknown API key")
+	}
+
+	return &Identity{
+		ID:     key.ID,
+		Admin:  key.Admin,
+		Roles:  key.Roles,
+		Rules:  key.Routes,
+		Source: "api_key",
+	}, nil
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ErrNoCredentials indica que la petición no trae credenciales para un autenticador
+var ErrNoCredentials = errors.New("no credentials")
+
+// AccessRule define qué métodos puede usar una identidad sobre un patrón de rutas.
+// Pattern acepta rutas exactas ("/jobs/status"), prefijos ("/jobs/*") o "*" para todas.
+type AccessRule struct {
+	Methods []string `json:"methods"`
+	Pattern string   `json:"pattern"`
+}
+
+// Matches indica si la regla cubre el método y path dados
+func (ar AccessRule) Matches(method, path string) bool {
+	if !ar.matchesMethod(method) {
+		return false
+	}
+	return matchPattern(ar.Pattern, path)
+}
+
+// matchesMethod indica si el método está permitido por la regla (vacío o "*" = todos)
+func (ar AccessRule) matchesMethod(method string) bool {
+	if len(ar.Methods) == 0 {
+		return true
+	}
+	for _, m := range ar.Methods {
+		if m == "*" || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern compara un path contra un patrón exacto, de prefijo ("/x/*") o "*"
+func matchPattern(pattern, path string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		prefix := strings.TrimSuffix(pattern, "/*")
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return pattern == path
+}
+
+// Identity representa al cliente autenticado de una petición
+type Identity struct {
+	ID     string       `json:"id"`
+	Admin  bool         `json:"admin"`
+	Roles  []string     `json:"roles,omitempty"`
+	Rules  []AccessRule `json:"-"`
+	Source string       `json:"source"` // Mecanismo que la autenticó (api_key, ...)
+}
+
+// Allows indica si la identidad puede acceder al método y path dados
+func (id *Identity) Allows(method, path string) bool {
+	if id.Admin {
+		return true
+	}
+	for _, rule := range id.Rules {
+		if rule.Matches(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole indica si la identidad tiene el rol dado
+func (id *Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator valida las credenciales de una petición.
+// Retorna ErrNoCredentials si la petición no trae credenciales de su tipo.
+type Authenticator interface {
+	Authenticate(req *HTTPRequest) (*Identity, error)
+	Scheme() string
+}
+
+// roleRequirement exige alguno de los roles para las rutas que cubre la regla
+type roleRequirement struct {
+	rule  AccessRule
+	roles []string
+}
+
+// AuthManager coordina autenticación y autorización de las peticiones
+type AuthManager struct {
+	mu             sync.RWMutex
+	authenticators []Authenticator
+	publicRules    []AccessRule
+	requirements   []roleRequirement
+}
+
+// NewAuthManager crea un gestor de autenticación con los autenticadores dados
+func NewAuthManager(authenticators ...Authenticator) *AuthManager {
+	return &AuthManager{
+		authenticators: authenticators,
+	}
+}
+
+// AddAuthenticator agrega un mecanismo de autenticación
+func (am *AuthManager) AddAuthenticator(a Authenticator) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.authenticators = append(am.authenticators, a)
+}
+
+// AllowPublic permite el acceso sin credenciales a las rutas que cubre la regla
+func (am *AuthManager) AllowPublic(methods []string, pattern string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.publicRules = append(am.publicRules, AccessRule{Methods: methods, Pattern: pattern})
+}
+
+// RequireRoles exige que la identidad tenga al menos uno de los roles para acceder
+// a las rutas que cubre la regla. Los admins siempre cumplen el requisito.
+func (am *AuthManager) RequireRoles(methods []string, pattern string, roles ...string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.requirements = append(am.requirements, roleRequirement{
+		rule:  AccessRule{Methods: methods, Pattern: pattern},
+		roles: roles,
+	})
+}
+
+// RequireAdmin restringe las rutas que cubre la regla a identidades admin
+func (am *AuthManager) RequireAdmin(methods []string, pattern string) {
+	am.RequireRoles(methods, pattern)
+}
+
+// requiredRoles retorna los requisitos de rol que aplican a la ruta
+func (am *AuthManager) requiredRoles(method, path string) [][]string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	var required [][]string
+	for _, req := range am.requirements {
+		if req.rule.Matches(method, path) {
+			required = append(required, req.roles)
+		}
+	}
+	return required
+}
+
+// hasAnyRole indica si la identidad tiene alguno de los roles
+func (id *Identity) hasAnyRole(roles []string) bool {
+	for _, role := range roles {
+		if id.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublic indica si la ruta es accesible sin credenciales
+func (am *AuthManager) isPublic(method, path string) bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	for _, rule := range am.publicRules {
+		if rule.Matches(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate prueba cada autenticador hasta encontrar credenciales
+func (am *AuthManager) authenticate(req *HTTPRequest) (*Identity, error) {
+	am.mu.RLock()
+	authenticators := am.authenticators
+	am.mu.RUnlock()
+
+	for _, a := range authenticators {
+		identity, err := a.Authenticate(req)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return identity, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// challenge construye el header WWW-Authenticate con los esquemas soportados
+func (am *AuthManager) challenge() string {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	schemes := make([]string, 0, len(am.authenticators))
+	for _, a := range am.authenticators {
+		schemes = append(schemes, a.Scheme()+` realm="GoDocker"`)
+	}
+	return strings.Join(schemes, ", ")
+}
+
+// Middleware retorna el middleware que autentica y autoriza cada petición
+func (am *AuthManager) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(req *HTTPRequest) *HTTPResponse {
+			required := am.requiredRoles(req.Method, req.Path)
+
+			identity, err := am.authenticate(req)
+			if err != nil {
+				if errors.Is(err, ErrNoCredentials) && len(required) == 0 && am.isPublic(req.Method, req.Path) {
+					return next(req)
+				}
+				if !errors.Is(err, ErrNoCredentials) {
+					log.Printf("Autenticación rechazada para %s %s: %v", req.Method, req.Path, err)
+				}
+				return am.unauthorized(err)
+			}
+
+			req.Identity = identity
+
+			if !identity.Allows(req.Method, req.Path) && !am.isPublic(req.Method, req.Path) {
+				return forbiddenResponse("identity is not allowed to access this route")
+			}
+
+			// Requisitos de rol de la ruta (los admins siempre pasan)
+			for _, roles := range required {
+				if identity.Admin || identity.hasAnyRole(roles) {
+					continue
+				}
+				if len(roles) == 0 {
+					return forbiddenResponse("route requires an admin identity")
+				}
+				return forbiddenResponse("route requires one of roles: " + strings.Join(roles, ", "))
+			}
+
+			return next(req)
+		}
+	}
+}
+
+// unauthorized construye la respuesta 401
+func (am *AuthManager) unauthorized(err error) *HTTPResponse {
+	message := "invalid credentials"
+	if errors.Is(err, ErrNoCredentials) {
+		message = "missing credentials"
+	}
+	return NewProblem(401, message).WithHeader("WWW-Authenticate", am.challenge()).Response()
+}
+
+// forbiddenResponse construye la respuesta 403
+func forbiddenResponse(message string) *HTTPResponse {
+	return Forbidden(message)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeysFile(t *testing.T) string {
+	t.Helper()
+	content := `{"keys":[
+		{"id":"ops","hash":"` + HashAPIKey("admin-key") + `","admin":true},
+		{"id":"alice","hash":"` + HashAPIKey("alice-key") + `","routes":[{"methods":["GET"],"pattern":"/jobs/*"}]}
+	]}`
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write keys file: %v", err)
+	}
+	return path
+}
+
+func TestAccessRuleMatches(t *testing.T) {
+	tests := []struct {
+		rule   AccessRule
+		method string
+		path   string
+		want   bool
+	}{
+		{AccessRule{Pattern: "*"}, "DELETE", "/file", true},
+		{AccessRule{Methods: []string{"GET"}, Pattern: "/jobs/*"}, "GET", "/jobs/status", true},
+		{AccessRule{Methods: []string{"GET"}, Pattern: "/jobs/*"}, "GET", "/jobs", true},
+		{AccessRule{Methods: []string{"GET"}, Pattern: "/jobs/*"}, "GET", "/jobsx", false},
+		{AccessRule{Methods: []string{"GET"}, Pattern: "/jobs/*"}, "DELETE", "/jobs/cancel", false},
+		{AccessRule{Pattern: "/ping"}, "GET", "/ping/x", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rule.Matches(tt.method, tt.path); got != tt.want {
+			t.Errorf("%+v.Matches(%s, %s) = %v, want %v", tt.rule, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	store, err := LoadAPIKeys(writeKeysFile(t))
+	if err != nil {
+		t.Fatalf("LoadAPIKeys failed: %v", err)
+	}
+
+	auth := NewAuthManager(store)
+	auth.AllowPublic([]string{"GET"}, "/ping")
+
+	var seen *Identity
+	handler := auth.Middleware()(func(req *HTTPRequest) *HTTPResponse {
+		seen = req.Identity
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		key            string
+		expectedStatus int
+		expectedID     string
+	}{
+		{"Public route without key", "GET", "/ping", "", 200, ""},
+		{"Missing key", "GET", "/jobs/status", "", 401, ""},
+		{"Unknown key", "GET", "/jobs/status", "nope", 401, ""},
+		{"Allowed route", "GET", "/jobs/status", "alice-key", 200, "alice"},
+		{"Forbidden method", "DELETE", "/jobs/cancel", "alice-key", 403, ""},
+		{"Admin", "DELETE", "/file", "admin-key", 200, "ops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := &HTTPRequest{Method: tt.method, Path: tt.path, Headers: map[string]string{}, Params: map[string]string{}}
+			if tt.key != "" {
+				req.Headers["x-api-key"] = tt.key
+			}
+
+			resp := handler(req)
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if resp.StatusCode == 401 && resp.Headers["WWW-Authenticate"] == "" {
+				t.Error("401 response should include WWW-Authenticate")
+			}
+			if tt.expectedID != "" && (seen == nil || seen.ID != tt.expectedID) {
+				t.Errorf("Expected identity %s, got %+v", tt.expectedID, seen)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeysRejectsInvalidHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	os.WriteFile(path, []byte(`{"keys":[{"id":"x","hash":"plaintext"}]}`), 0600)
+
+	if _, err := LoadAPIKeys(path); err == nil {
+		t.Error("Expected error for invalid hash")
+	}
+}
@@ -0,0 +1,425 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BreakerState es el estado de un circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Deja pasar todo y mide fallos
+	BreakerOpen     BreakerState = "open"      // Rechaza todo hasta OpenTimeout
+	BreakerHalfOpen BreakerState = "half_open" // Deja pasar algunas pruebas para verificar recuperación
+)
+
+// RejectCircuitOpen es el motivo de rechazo cuando el breaker de la ruta está abierto
+const RejectCircuitOpen = "circuit_open"
+
+// ErrCircuitOpen se retorna cuando un breaker abierto rechaza la operación
+var ErrCircuitOpen = errors.New("circuit open")
+
+// BreakerConfig define cuándo se abre un circuit breaker y cómo se recupera
+type BreakerConfig struct {
+	WindowSize       int           // Cantidad de resultados recientes evaluados
+	MinRequests      int           // Resultados mínimos en la ventana antes de poder abrir
+	FailureRatio     float64       // Proporción de fallos (incluye lentas) que abre el circuito
+	SlowCallDuration time.Duration // Las llamadas más lentas cuentan como fallo (0 = no)
+	OpenTimeout      time.Duration // Tiempo abierto antes de pasar a half-open
+	HalfOpenProbes   int           // Pruebas exitosas necesarias en half-open para cerrar
+}
+
+// DefaultBreakerConfig retorna una configuración razonable para rutas y jobs
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		WindowSize:       20,
+		MinRequests:      5,
+		FailureRatio:     0.5,
+		SlowCallDuration: 10 * time.Second,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenProbes:   2,
+	}
+}
+
+// BreakerTicket identifica una llamada admitida por Allow. Record y Cancel lo usan para
+// descartar los resultados de llamadas admitidas antes del último cambio de estado y para
+// contar como prueba solo las admitidas en half-open.
+type BreakerTicket struct {
+	generation uint64
+	probe      bool
+}
+
+// CircuitBreaker corta las llamadas a una dependencia que está fallando
+type CircuitBreaker struct {
+	name   string
+	config BreakerConfig
+	mu     sync.Mutex
+
+	state    BreakerState
+	outcomes []bool // Ring buffer de resultados: true = fallo
+	next     int
+	filled   int
+	failures int
+
+	openedAt       time.Time
+	generation     uint64 // Aumenta en cada cambio de estado (ver BreakerTicket)
+	probesInFlight int
+	probesOK       int
+
+	trips          int64
+	rejected       int64
+	lastTransition time.Time
+	lastError      string
+	totalLatency   time.Duration
+	calls          int64
+	now            func() time.Time
+}
+
+// NewCircuitBreaker crea un breaker cerrado
+func NewCircuitBreaker(name string, config BreakerConfig) *CircuitBreaker {
+	defaults := DefaultBreakerConfig()
+	if config.WindowSize <= 0 {
+		config.WindowSize = defaults.WindowSize
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = defaults.MinRequests
+	}
+	if config.FailureRatio <= 0 || config.FailureRatio > 1 {
+		config.FailureRatio = defaults.FailureRatio
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = defaults.HalfOpenProbes
+	}
+
+	return &CircuitBreaker{
+		name:     name,
+		config:   config,
+		state:    BreakerClosed,
+		outcomes: make([]bool, config.WindowSize),
+		now:      time.Now,
+	}
+}
+
+// Allow indica si la llamada puede ejecutarse. Cada Allow exitoso debe terminar en
+// Record (con el resultado) o en Cancel (si la llamada no llegó a ejecutarse), pasando
+// el ticket recibido.
+func (cb *CircuitBreaker) Allow() (BreakerTicket, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == BreakerOpen && cb.now().Sub(cb.openedAt) >= cb.config.OpenTimeout {
+		cb.transitionLocked(BreakerHalfOpen)
+	}
+
+	ticket := BreakerTicket{generation: cb.generation}
+	switch cb.state {
+	case BreakerOpen:
+		cb.rejected++
+		return ticket, false
+	case BreakerHalfOpen:
+		// Solo las pruebas necesarias para decidir; el resto sigue fallando rápido
+		if cb.probesInFlight+cb.probesOK >= cb.config.HalfOpenProbes {
+			cb.rejected++
+			return ticket, false
+		}
+		cb.probesInFlight++
+		ticket.probe = true
+	}
+	return ticket, true
+}
+
+// Record registra el resultado de una llamada permitida por Allow. Los resultados de
+// llamadas admitidas en un estado anterior solo cuentan para la latencia.
+func (cb *CircuitBreaker) Record(ticket BreakerTicket, err error, latency time.Duration) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.calls++
+	cb.totalLatency += latency
+
+	failed := err != nil || (cb.config.SlowCallDuration > 0 && latency >= cb.config.SlowCallDuration)
+	if err != nil {
+		cb.lastError = err.Error()
+	} else if failed {
+		cb.lastError = fmt.Sprintf("slow call: %v", latency.Round(time.Millisecond))
+	}
+
+	if ticket.generation != cb.generation {
+		return
+	}
+	switch cb.state {
+	case BreakerHalfOpen:
+		cb.probesInFlight--
+		if failed {
+			cb.transitionLocked(BreakerOpen)
+			return
+		}
+		cb.probesOK++
+		if cb.probesOK >= cb.config.HalfOpenProbes {
+			cb.transitionLocked(BreakerClosed)
+		}
+	case BreakerClosed:
+		cb.recordOutcomeLocked(failed)
+		if cb.filled >= cb.config.MinRequests &&
+			float64(cb.failures)/float64(cb.filled) >= cb.config.FailureRatio {
+			cb.transitionLocked(BreakerOpen)
+		}
+	}
+}
+
+// Cancel libera un Allow cuya llamada no llegó a ejecutarse (p. ej. rechazada por cola llena)
+func (cb *CircuitBreaker) Cancel(ticket BreakerTicket) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if ticket.probe && ticket.generation == cb.generation {
+		cb.probesInFlight--
+	}
+}
+
+// recordOutcomeLocked agrega un resultado a la ventana. Requiere cb.mu.
+func (cb *CircuitBreaker) recordOutcomeLocked(failed bool) {
+	if cb.filled == len(cb.outcomes) {
+		if cb.outcomes[cb.next] {
+			cb.failures--
+		}
+	} else {
+		cb.filled++
+	}
+	cb.outcomes[cb.next] = failed
+	if failed {
+		cb.failures++
+	}
+	cb.next = (cb.next + 1) % len(cb.outcomes)
+}
+
+// transitionLocked cambia de estado y reinicia los contadores correspondientes. Requiere cb.mu.
+func (cb *CircuitBreaker) transitionLocked(state BreakerState) {
+	if cb.state == state {
+		return
+	}
+	log.Printf("Circuit breaker %s: %s -> %s", cb.name, cb.state, state)
+
+	cb.state = state
+	cb.generation++
+	cb.lastTransition = cb.now()
+	cb.probesInFlight = 0
+	cb.probesOK = 0
+
+	switch state {
+	case BreakerOpen:
+		cb.openedAt = cb.lastTransition
+		cb.trips++
+	case BreakerClosed:
+		// Empezar con la ventana limpia tras recuperarse
+		for i := range cb.outcomes {
+			cb.outcomes[i] = false
+		}
+		cb.next, cb.filled, cb.failures = 0, 0, 0
+	}
+}
+
+// State retorna el estado actual (un breaker abierto vencido se informa como half-open)
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == BreakerOpen && cb.now().Sub(cb.openedAt) >= cb.config.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return cb.state
+}
+
+// RetryAfter retorna cuánto falta para que el breaker abierto admita pruebas
+func (cb *CircuitBreaker) RetryAfter() time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state != BreakerOpen {
+		return 0
+	}
+	remaining := cb.config.OpenTimeout - cb.now().Sub(cb.openedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Stats retorna el estado y los contadores del breaker
+func (cb *CircuitBreaker) Stats() map[string]interface{} {
+	state := cb.State()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	ratio := 0.0
+	if cb.filled > 0 {
+		ratio = float64(cb.failures) / float64(cb.filled)
+	}
+	avgLatency := 0.0
+	if cb.calls > 0 {
+		avgLatency = float64(cb.totalLatency.Microseconds()) / float64(cb.calls) / 1000
+	}
+
+	stats := map[string]interface{}{
+		"state":          state,
+		"failure_ratio":  math.Round(ratio*1000) / 1000,
+		"window_calls":   cb.filled,
+		"window_fails":   cb.failures,
+		"trips":          cb.trips,
+		"rejected":       cb.rejected,
+		"avg_latency_ms": math.Round(avgLatency*100) / 100,
+	}
+	if !cb.lastTransition.IsZero() {
+		stats["last_transition"] = cb.lastTransition.Format(time.RFC3339)
+	}
+	if cb.lastError != "" {
+		stats["last_error"] = cb.lastError
+	}
+	return stats
+}
+
+// BreakerRegistry crea y agrupa breakers por nombre con una configuración común
+type BreakerRegistry struct {
+	mu       sync.RWMutex
+	config   BreakerConfig
+	breakers map[string]*CircuitBreaker
+}
+
+// NewBreakerRegistry crea un registro vacío
+func NewBreakerRegistry(config BreakerConfig) *BreakerRegistry {
+	return &BreakerRegistry{
+		config:   config,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get retorna el breaker con ese nombre, creándolo si no existe
+func (r *BreakerRegistry) Get(name string) *CircuitBreaker {
+	r.mu.RLock()
+	cb, ok := r.breakers[name]
+	r.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cb, ok := r.breakers[name]; ok {
+		return cb
+	}
+	cb = NewCircuitBreaker(name, r.config)
+	r.breakers[name] = cb
+	return cb
+}
+
+// Lookup retorna el breaker con ese nombre sin crearlo, o nil si no existe
+func (r *BreakerRegistry) Lookup(name string) *CircuitBreaker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.breakers[name]
+}
+
+// Add registra un breaker con configuración propia
+func (r *BreakerRegistry) Add(name string, config BreakerConfig) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cb := NewCircuitBreaker(name, config)
+	r.breakers[name] = cb
+	return cb
+}
+
+// Snapshot retorna las estadísticas de todos los breakers por nombre
+func (r *BreakerRegistry) Snapshot() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stats := make(map[string]interface{}, len(r.breakers))
+	for name, cb := range r.breakers {
+		stats[name] = cb.Stats()
+	}
+	return stats
+}
+
+// States retorna solo el estado de cada breaker, ordenable para /status
+func (r *BreakerRegistry) States() map[string]BreakerState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	states := make(map[string]BreakerState, len(r.breakers))
+	for name, cb := range r.breakers {
+		states[name] = cb.State()
+	}
+	return states
+}
+
+// ProtectRoute agrega un circuit breaker a las rutas que cubre el patrón. Con el circuito
+// abierto, las peticiones se rechazan con 503 en la etapa de lectura, sin ocupar un worker.
+// Cuentan como fallo las respuestas 5xx y las que superan SlowCallDuration.
+func (s *Server) ProtectRoute(method, pattern string, config BreakerConfig) {
+	name := pattern
+	if method != "" {
+		name = method + " " + pattern
+	}
+
+	s.routeBreakers.Add(name, config)
+
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.breakerRoutes.add(method, pattern, name)
+}
+
+// breakerFor retorna el breaker que protege la ruta, o nil
+func (s *Server) breakerFor(req *HTTPRequest) *CircuitBreaker {
+	s.poolMu.RLock()
+	name, ok := s.breakerRoutes.lookup(req.Method, req.Path)
+	s.poolMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return s.routeBreakers.Get(name)
+}
+
+// BreakerStates retorna el estado de los breakers de rutas y de tareas del JobManager
+func (s *Server) BreakerStates() map[string]BreakerState {
+	states := s.routeBreakers.States()
+	for task, state := range s.jobManager.breakers.States() {
+		states["job:"+task] = state
+	}
+	return states
+}
+
+// OpenBreakers retorna los nombres de los breakers que no están cerrados, ordenados
+func (s *Server) OpenBreakers() []string {
+	var open []string
+	for name, state := range s.BreakerStates() {
+		if state != BreakerClosed {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
+
+// rejectOpenCircuit responde 503 sin ejecutar el handler porque el breaker de la ruta está abierto
+func (s *Server) rejectOpenCircuit(conn net.Conn, connID int64, req *HTTPRequest, cb *CircuitBreaker) {
+	defer s.closeConnection(conn)
+
+	s.rejections.Increment(RejectCircuitOpen)
+	retry := int(math.Ceil(cb.RetryAfter().Seconds()))
+	if retry < 1 {
+		retry = 1
+	}
+	log.Printf("Connection %d rechazada (%s %s): %s %s", connID, RejectCircuitOpen, cb.name, req.Method, req.Path)
+
+	s.respond(conn, req, unavailableProblem("circuit open: the endpoint is failing, try again later", retry).
+		WithType(ProblemCircuitOpen).
+		With("reason", RejectCircuitOpen).
+		With("breaker", cb.name).
+		Response())
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerStateMachine(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreaker("test", BreakerConfig{
+		WindowSize:       10,
+		MinRequests:      4,
+		FailureRatio:     0.5,
+		SlowCallDuration: time.Second,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenProbes:   2,
+	})
+	cb.now = func() time.Time { return now }
+	fail := errors.New("boom")
+
+	// allow pide permiso y retorna el ticket, fallando si el breaker rechaza la llamada
+	allow := func() BreakerTicket {
+		t.Helper()
+		ticket, ok := cb.Allow()
+		if !ok {
+			t.Fatalf("Expected the call to be allowed in state %s", cb.State())
+		}
+		return ticket
+	}
+
+	// Por debajo de MinRequests no abre aunque todo falle
+	for i := 0; i < 3; i++ {
+		cb.Record(allow(), fail, time.Millisecond)
+	}
+	if cb.State() != BreakerClosed {
+		t.Fatalf("Expected closed below MinRequests, got %s", cb.State())
+	}
+
+	// Una llamada lenta cuenta como fallo y supera el umbral; otra admitida antes de abrir
+	// termina después
+	late := allow()
+	cb.Record(allow(), nil, 2*time.Second)
+	if cb.State() != BreakerOpen {
+		t.Fatalf("Expected open after 4/4 failures, got %s", cb.State())
+	}
+	if _, ok := cb.Allow(); ok {
+		t.Error("Open breaker should reject calls")
+	}
+	if cb.RetryAfter() != 10*time.Second {
+		t.Errorf("Expected RetryAfter 10s, got %v", cb.RetryAfter())
+	}
+
+	// Vencido OpenTimeout: half-open admite solo HalfOpenProbes pruebas
+	now = now.Add(11 * time.Second)
+	first, second := allow(), allow()
+	if _, ok := cb.Allow(); ok {
+		t.Error("Half-open breaker should reject calls beyond the probes")
+	}
+
+	// La llamada admitida con el circuito cerrado no cuenta como prueba
+	cb.Record(late, nil, time.Millisecond)
+	cb.Cancel(late)
+	if _, ok := cb.Allow(); ok {
+		t.Error("A stale result should not free a probe slot")
+	}
+
+	// Una prueba fallida vuelve a abrir
+	cb.Record(first, nil, time.Millisecond)
+	cb.Record(second, fail, time.Millisecond)
+	if cb.State() != BreakerOpen {
+		t.Fatalf("Expected open after a failed probe, got %s", cb.State())
+	}
+
+	// Pruebas exitosas cierran; una prueba cancelada libera su lugar
+	now = now.Add(11 * time.Second)
+	cb.Cancel(allow())
+	first, second = allow(), allow()
+	cb.Record(first, nil, time.Millisecond)
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("Expected half-open until every probe succeeds, got %s", cb.State())
+	}
+	cb.Record(second, nil, time.Millisecond)
+	if cb.State() != BreakerClosed {
+		t.Fatalf("Expected closed after successful probes, got %s", cb.State())
+	}
+
+	stats := cb.Stats()
+	if stats["trips"].(int64) != 2 || stats["window_calls"].(int) != 0 {
+		t.Errorf("Unexpected stats after recovery: %v", stats)
+	}
+}
+
+func TestRouteCircuitBreaker(t *testing.T) {
+	var calls int64
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/compress", func(req *HTTPRequest) *HTTPResponse {
+		atomic.AddInt64(&calls, 1)
+		return &HTTPResponse{StatusCode: 500, StatusText: "Internal Server Error"}
+	})
+	srv.ProtectRoute("GET", "/compress", BreakerConfig{MinRequests: 3, FailureRatio: 1, OpenTimeout: time.Minute})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		if code, _ := sendRaw(t, srv.Addr(), "GET /compress HTTP/1.1\r\nHost: test\r\n\r\n"); code != 500 {
+			t.Fatalf("Expected 500 from the handler, got %d", code)
+		}
+	}
+
+	code, headers := sendRaw(t, srv.Addr(), "GET /compress HTTP/1.1\r\nHost: test\r\n\r\n")
+	if code != 503 || headers["Retry-After"] == "" {
+		t.Errorf("Expected 503 with Retry-After once the breaker is open, got %d %v", code, headers)
+	}
+	if atomic.LoadInt64(&calls) != 3 {
+		t.Errorf("Handler should not run with the breaker open, ran %d times", calls)
+	}
+	if srv.rejections.Get(RejectCircuitOpen) != 1 {
+		t.Errorf("Expected 1 circuit_open rejection, got %v", srv.rejections.Snapshot())
+	}
+	if states := srv.BreakerStates(); states["GET /compress"] != BreakerOpen {
+		t.Errorf("Expected GET /compress open in states, got %v", states)
+	}
+}
+
+// failingExecutor falla siempre, simulando una dependencia caída (p. ej. xz ausente)
+type failingExecutor struct{}
+
+func (failingExecutor) Tasks() []string {
+	return []string{"compress", "isprime"}
+}
+
+func (failingExecutor) Execute(ctx context.Context, task string, params map[string]string) (map[string]interface{}, error) {
+	return nil, errors.New("xz not found")
+}
+
+func TestJobManagerCircuitBreaker(t *testing.T) {
+	jm := NewJobManager(100, time.Minute, time.Minute, "")
+	defer jm.Shutdown()
+	jm.SetExecutor(failingExecutor{})
+	jm.EnableBreakers(BreakerConfig{MinRequests: 2, FailureRatio: 1, OpenTimeout: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		job, err := jm.Submit("compress", nil, PriorityNormal)
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		deadline := time.Now().Add(3 * time.Second)
+		for job.GetInfo()["status"] != JobError && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	if _, err := jm.Submit("compress", nil, PriorityNormal); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen once the breaker trips, got %v", err)
+	}
+	// Otras tareas no se ven afectadas
+	if _, err := jm.Submit("isprime", nil, PriorityNormal); err != nil {
+		t.Errorf("Submit for another task failed: %v", err)
+	}
+	if state := jm.BreakerStats()["compress"].(map[string]interface{})["state"]; state != BreakerOpen {
+		t.Errorf("Expected compress breaker open, got %v", state)
+	}
+
+	// Las tareas desconocidas se rechazan sin crear breakers
+	if _, err := jm.Submit("no-such-task", nil, PriorityNormal); !errors.Is(err, ErrUnknownTask) {
+		t.Errorf("Expected ErrUnknownTask, got %v", err)
+	}
+	if stats := jm.BreakerStats(); len(stats) != 2 {
+		t.Errorf("Expected breakers only for the executor's tasks, got %v", stats)
+	}
+}
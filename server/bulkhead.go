@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultPool es el pool que atiende las rutas sin pool asignado
+const DefaultPool = "default"
+
+// defaultQueueCapacity es la capacidad de la cola del pool por defecto
+const defaultQueueCapacity = 1000
+
+// Bulkhead es un pool de workers con su propia cola. Una ráfaga de peticiones
+// asignadas a un pool solo ocupa sus workers y su cola, no los de los demás.
+type Bulkhead struct {
+	name       string
+	pool       *WorkerPool
+	queue      Queue
+	throughput *throughputMeter // Peticiones completadas por segundo en este pool
+}
+
+// newBulkhead crea un pool con su cola según el scheduler configurado
+func newBulkhead(name string, config WorkerPoolConfig, queueCapacity int) *Bulkhead {
+	pool := NewElasticWorkerPool(config)
+
+	var queue Queue = NewTaskQueue(queueCapacity)
+	if config.Scheduler == SchedulerWorkStealing {
+		// Una cola local por cada worker que el pool puede llegar a tener; el pool informa
+		// cuántos están vivos para repartir solo entre sus colas
+		queue = NewStealingQueue(queueCapacity, pool.config.MaxWorkers)
+	}
+
+	return &Bulkhead{
+		name:       name,
+		pool:       pool,
+		queue:      queue,
+		throughput: newThroughputMeter(10),
+	}
+}
+
+// Stats retorna el estado del pool, su utilización y la profundidad de su cola
+func (bh *Bulkhead) Stats() map[string]interface{} {
+	stats := bh.pool.Stats()
+
+	utilization := 0.0
+	if size := stats["size"].(int); size > 0 {
+		utilization = float64(stats["busy"].(int64)) / float64(size)
+	}
+	stats["utilization"] = math.Round(utilization*1000) / 1000
+	stats["queue_size"] = bh.queue.Size()
+	stats["queue_capacity"] = bh.queue.Capacity()
+	stats["throughput_rps"] = math.Round(bh.throughput.Rate()*100) / 100
+	switch queue := bh.queue.(type) {
+	case *FairQueue:
+		stats["scheduler"] = SchedulerFair
+		stats["classes"] = queue.ClassStats()
+	case *StealingQueue:
+		stats["steals"] = queue.Steals()
+	}
+	return stats
+}
+
+// routeAssignment asigna un patrón de rutas a un valor (pool, clase de planificación...)
+type routeAssignment struct {
+	method  string // "" = cualquier método
+	pattern string
+	value   string
+}
+
+// routeTable resuelve la asignación más específica que cubre una ruta
+type routeTable []routeAssignment
+
+// add agrega una asignación manteniendo los patrones más específicos primero
+func (t *routeTable) add(method, pattern, value string) {
+	*t = append(*t, routeAssignment{method: strings.ToUpper(method), pattern: pattern, value: value})
+	sort.SliceStable(*t, func(i, j int) bool {
+		return patternSpecificity((*t)[i].pattern) > patternSpecificity((*t)[j].pattern)
+	})
+}
+
+// lookup retorna el valor asignado a la ruta, si hay uno
+func (t routeTable) lookup(method, path string) (string, bool) {
+	for _, route := range t {
+		if (route.method == "" || route.method == method) && matchPattern(route.pattern, path) {
+			return route.value, true
+		}
+	}
+	return "", false
+}
+
+// AddPool crea (o reemplaza) un pool con nombre y su propia cola; debe llamarse antes de Start
+func (s *Server) AddPool(name string, config WorkerPoolConfig, queueCapacity int) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.bulkheads[name] = newBulkhead(name, config, queueCapacity)
+}
+
+// AssignPool hace que las rutas que cubre el patrón ("/matrixmul", "/jobs/*") se atiendan
+// en el pool indicado. Con method vacío aplica a cualquier método; si varias asignaciones
+// cubren una ruta se usa el patrón más específico.
+func (s *Server) AssignPool(method, pattern, pool string) error {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+
+	if _, ok := s.bulkheads[pool]; !ok {
+		return fmt.Errorf("pool %q no existe", pool)
+	}
+
+	s.poolRoutes.add(method, pattern, pool)
+	return nil
+}
+
+// GetPool retorna el worker pool con ese nombre (p. ej. para redimensionarlo), o nil
+func (s *Server) GetPool(name string) *WorkerPool {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+	if bh, ok := s.bulkheads[name]; ok {
+		return bh.pool
+	}
+	return nil
+}
+
+// bulkheadFor retorna el pool que atiende la petición
+func (s *Server) bulkheadFor(req *HTTPRequest) *Bulkhead {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+
+	if pool, ok := s.poolRoutes.lookup(req.Method, req.Path); ok {
+		return s.bulkheads[pool]
+	}
+	return s.bulkheads[DefaultPool]
+}
+
+// eachBulkhead ejecuta fn sobre todos los pools, en orden por nombre
+func (s *Server) eachBulkhead(fn func(bh *Bulkhead)) {
+	s.poolMu.RLock()
+	bulkheads := make([]*Bulkhead, 0, len(s.bulkheads))
+	for _, bh := range s.bulkheads {
+		bulkheads = append(bulkheads, bh)
+	}
+	s.poolMu.RUnlock()
+
+	sort.Slice(bulkheads, func(i, j int) bool { return bulkheads[i].name < bulkheads[j].name })
+	for _, bh := range bulkheads {
+		fn(bh)
+	}
+}
+
+// queueTotals retorna la ocupación y capacidad sumadas de todas las colas
+func (s *Server) queueTotals() (size, capacity int64) {
+	s.eachBulkhead(func(bh *Bulkhead) {
+		size += bh.queue.Size()
+		capacity += bh.queue.Capacity()
+	})
+	return size, capacity
+}
+
+// poolStats retorna las estadísticas de cada pool por nombre
+func (s *Server) poolStats() map[string]interface{} {
+	stats := make(map[string]interface{})
+	s.eachBulkhead(func(bh *Bulkhead) {
+		stats[bh.name] = bh.Stats()
+	})
+	return stats
+}
+
+// poolScalingEvent es un evento de escalado con el pool que lo produjo
+type poolScalingEvent struct {
+	Pool string `json:"pool"`
+	ScalingEvent
+}
+
+// aggregatePoolStats suma los pools para el bloque worker_pool de /metrics: tamaño,
+// tamaño objetivo, escalados y los últimos eventos de todos los pools
+func aggregatePoolStats(pools map[string]interface{}, busy int64) map[string]interface{} {
+	size, target := 0, 0
+	var scaleUps, scaleDowns int64
+	var events []poolScalingEvent
+	for name, pool := range pools {
+		stats := pool.(map[string]interface{})
+		size += stats["size"].(int)
+		target += stats["target_size"].(int)
+		scaleUps += stats["scale_ups"].(int64)
+		scaleDowns += stats["scale_downs"].(int64)
+		for _, event := range stats["scaling_events"].([]ScalingEvent) {
+			events = append(events, poolScalingEvent{Pool: name, ScalingEvent: event})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	if len(events) > maxScalingEvents {
+		events = events[len(events)-maxScalingEvents:]
+	}
+
+	return map[string]interface{}{
+		"size":           size,
+		"target_size":    target,
+		"busy_workers":   busy,
+		"idle_workers":   int64(size) - busy,
+		"scale_ups":      scaleUps,
+		"scale_downs":    scaleDowns,
+		"scaling_events": events,
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBulkheadIsolation(t *testing.T) {
+	release := make(chan struct{})
+	srv := NewServer("127.0.0.1:0", 1)
+	srv.jobManager.persistenceFile = ""
+	srv.AddPool("cpu", WorkerPoolConfig{MinWorkers: 1}, 1)
+	srv.AddPool("control", WorkerPoolConfig{MinWorkers: 1}, 10)
+	srv.HandleFunc("GET", "/matrixmul", func(req *HTTPRequest) *HTTPResponse {
+		<-release
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+	})
+	srv.HandleFunc("GET", "/ping", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: "pong"}
+	})
+	if err := srv.AssignPool("GET", "/matrixmul", "cpu"); err != nil {
+		t.Fatalf("AssignPool failed: %v", err)
+	}
+	if err := srv.AssignPool("", "/ping", "control"); err != nil {
+		t.Fatalf("AssignPool failed: %v", err)
+	}
+	if err := srv.AssignPool("GET", "/pi", "gpu"); err == nil {
+		t.Error("AssignPool should fail for an unknown pool")
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+	defer close(release)
+
+	// Saturar el pool cpu: un worker ocupado y la cola llena
+	cpu := srv.bulkheads["cpu"]
+	for _, occupied := range []func() bool{
+		func() bool { return cpu.pool.Stats()["busy"].(int64) == 1 },
+		func() bool { return cpu.queue.Size() == 1 },
+	} {
+		conn, err := net.Dial("tcp", srv.Addr())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("GET /matrixmul HTTP/1.1\r\nHost: test\r\n\r\n"))
+		deadline := time.Now().Add(3 * time.Second)
+		for !occupied() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if code, _ := sendRaw(t, srv.Addr(), "GET /matrixmul HTTP/1.1\r\nHost: test\r\n\r\n"); code != 503 {
+		t.Errorf("Expected 503 from the saturated cpu pool, got %d", code)
+	}
+	if code, _ := sendRaw(t, srv.Addr(), "GET /ping HTTP/1.1\r\nHost: test\r\n\r\n"); code != 200 {
+		t.Errorf("Expected control pool to answer 200 while cpu is saturated, got %d", code)
+	}
+
+	pools := srv.GetMetrics()["pools"].(map[string]interface{})
+	cpuStats := pools["cpu"].(map[string]interface{})
+	if cpuStats["utilization"].(float64) != 1 || cpuStats["queue_size"].(int64) != 1 {
+		t.Errorf("Unexpected cpu pool stats: %v", cpuStats)
+	}
+	if _, ok := pools[DefaultPool]; !ok {
+		t.Error("Expected default pool in metrics")
+	}
+}
+
+func TestAggregatePoolStats(t *testing.T) {
+	now := time.Now()
+	pool := func(size, target int, ups int64, events ...ScalingEvent) map[string]interface{} {
+		return map[string]interface{}{
+			"size": size, "target_size": target, "scale_ups": ups, "scale_downs": int64(0), "scaling_events": events,
+		}
+	}
+	pools := map[string]interface{}{
+		"cpu":     pool(3, 4, 2, ScalingEvent{Time: now.Add(-time.Second), From: 1, To: 3, Reason: "queue_depth"}),
+		"default": pool(2, 2, 1, ScalingEvent{Time: now, From: 2, To: 4, Reason: "resize"}),
+	}
+
+	stats := aggregatePoolStats(pools, 1)
+	if stats["size"] != 5 || stats["target_size"] != 6 || stats["scale_ups"] != int64(3) || stats["idle_workers"] != int64(4) {
+		t.Errorf("Unexpected aggregated stats: %v", stats)
+	}
+	events := stats["scaling_events"].([]poolScalingEvent)
+	if len(events) != 2 || events[0].Pool != "cpu" || events[1].Pool != "default" {
+		t.Errorf("Expected events of every pool in time order, got %+v", events)
+	}
+}
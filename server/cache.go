@@ -0,0 +1,440 @@
+package server
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// entryOverhead aproxima lo que ocupa una entrada además de su clave y su respuesta
+const entryOverhead = 256
+
+// CacheConfig define el presupuesto de memoria de la caché de respuestas
+type CacheConfig struct {
+	MaxBytes      int64         // Memoria total para respuestas cacheadas
+	MaxEntryBytes int64         // Respuestas más grandes no se cachean (0 = MaxBytes/16)
+	DefaultTTL    time.Duration // TTL de las rutas que no definen uno
+}
+
+// DefaultCacheConfig retorna la configuración por defecto: 64 MB, hasta 4 MB por respuesta, 10 minutos
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		MaxBytes:      64 << 20,
+		MaxEntryBytes: 4 << 20,
+		DefaultTTL:    10 * time.Minute,
+	}
+}
+
+// CachePolicy define cómo se cachea una ruta
+type CachePolicy struct {
+	TTL     time.Duration // Vigencia de una respuesta (0 = DefaultTTL de la caché)
+	Params  []string      // Parámetros que forman la clave (vacío = todos)
+	Require []string      // Solo se cachea si vienen todos estos parámetros (p. ej. seed)
+	Bypass  []string      // Si viene alguno no se cachea ni se agrupa (efectos secundarios)
+}
+
+// cacheEntry es una respuesta cacheada
+type cacheEntry struct {
+	key      string
+	response *HTTPResponse
+	size     int64
+	stored   time.Time
+	expires  time.Time
+}
+
+// cacheRoute acumula las estadísticas de una ruta cacheada
+type cacheRoute struct {
+	policy CachePolicy
+	hits   int64 // (atómico)
+	misses int64 // (atómico)
+}
+
+// ResponseCache es una caché LRU de respuestas con TTL y presupuesto de memoria.
+// Solo guarda respuestas 200 de las rutas que la activan con Server.CacheRoute.
+type ResponseCache struct {
+	mu      sync.Mutex
+	config  CacheConfig
+	entries map[string]*list.Element
+	lru     *list.List // Frente = usada más recientemente
+	bytes   int64
+
+	hits        int64
+	misses      int64
+	bypassed    int64 // Peticiones que no pudieron usar la caché (parámetros o Cache-Control)
+	evictions   int64 // Entradas descartadas por el presupuesto de memoria
+	expirations int64 // Entradas descartadas por TTL
+	oversized   int64 // Respuestas no guardadas por superar MaxEntryBytes
+
+	now func() time.Time
+}
+
+// NewResponseCache crea una caché con la configuración dada
+func NewResponseCache(config CacheConfig) *ResponseCache {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultCacheConfig().MaxBytes
+	}
+	if config.MaxEntryBytes <= 0 || config.MaxEntryBytes > config.MaxBytes {
+		config.MaxEntryBytes = config.MaxBytes / 16
+	}
+	if config.DefaultTTL <= 0 {
+		config.DefaultTTL = DefaultCacheConfig().DefaultTTL
+	}
+
+	return &ResponseCache{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get retorna una copia de la respuesta cacheada y su antigüedad, si existe y está vigente
+func (c *ResponseCache) Get(key string) (*HTTPResponse, time.Duration, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, 0, 0, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	now := c.now()
+	if !now.Before(entry.expires) {
+		c.removeLocked(elem)
+		c.expirations++
+		c.misses++
+		return nil, 0, 0, false
+	}
+
+	c.lru.MoveToFront(elem)
+	c.hits++
+	return copyResponse(entry.response), now.Sub(entry.stored), entry.expires.Sub(now), true
+}
+
+// Set guarda una copia de la respuesta, descartando las menos usadas si no alcanza la memoria.
+// Retorna false si la respuesta supera MaxEntryBytes.
+func (c *ResponseCache) Set(key string, resp *HTTPResponse, ttl time.Duration) bool {
+	if ttl <= 0 {
+		ttl = c.config.DefaultTTL
+	}
+
+	size := int64(len(key)+len(resp.Body)+len(resp.StatusText)) + entryOverhead
+	for name, value := range resp.Headers {
+		size += int64(len(name) + len(value))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if size > c.config.MaxEntryBytes {
+		c.oversized++
+		return false
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+
+	// Primero lo vencido, después lo menos usado
+	now := c.now()
+	c.purgeExpiredLocked(now)
+	for c.bytes+size > c.config.MaxBytes && c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Back())
+		c.evictions++
+	}
+
+	entry := &cacheEntry{
+		key:      key,
+		response: copyResponse(resp),
+		size:     size,
+		stored:   now,
+		expires:  now.Add(ttl),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += size
+	return true
+}
+
+// purgeExpiredLocked descarta las entradas vencidas; requiere c.mu
+func (c *ResponseCache) purgeExpiredLocked(now time.Time) {
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if !now.Before(elem.Value.(*cacheEntry).expires) {
+			c.removeLocked(elem)
+			c.expirations++
+		}
+		elem = prev
+	}
+}
+
+// removeLocked quita una entrada; requiere c.mu
+func (c *ResponseCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// recordBypass cuenta una petición que no pudo usar la caché
+func (c *ResponseCache) recordBypass() {
+	c.mu.Lock()
+	c.bypassed++
+	c.mu.Unlock()
+}
+
+// Purge vacía la caché
+func (c *ResponseCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// Len retorna la cantidad de respuestas cacheadas
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Stats retorna ocupación y contadores de la caché
+func (c *ResponseCache) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hitRatio := 0.0
+	if total := c.hits + c.misses; total > 0 {
+		hitRatio = float64(c.hits) / float64(total)
+	}
+
+	return map[string]interface{}{
+		"entries":         c.lru.Len(),
+		"bytes":           c.bytes,
+		"max_bytes":       c.config.MaxBytes,
+		"max_entry_bytes": c.config.MaxEntryBytes,
+		"hits":            c.hits,
+		"misses":          c.misses,
+		"hit_ratio":       math.Round(hitRatio*1000) / 1000,
+		"bypassed":        c.bypassed,
+		"evictions":       c.evictions,
+		"expirations":     c.expirations,
+		"oversized":       c.oversized,
+	}
+}
+
+// CacheKey construye la clave de una petición a partir de sus parámetros normalizados:
+// ordenados por nombre, sin espacios alrededor y con los enteros en forma canónica
+// ("007" y "+7" son "7"). Si params no está vacío, solo esos parámetros forman la clave.
+func CacheKey(method, path string, reqParams map[string]string, params []string) string {
+	values := url.Values{}
+	include := func(name, value string) {
+		value = strings.TrimSpace(value)
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			value = strconv.FormatInt(n, 10)
+		}
+		values.Set(name, value)
+	}
+
+	if len(params) == 0 {
+		for name, value := range reqParams {
+			include(name, value)
+		}
+	} else {
+		for _, name := range params {
+			if value, ok := reqParams[name]; ok {
+				include(name, value)
+			}
+		}
+	}
+
+	// Encode ordena por nombre
+	return strings.ToUpper(method) + " " + path + "?" + values.Encode()
+}
+
+// copyResponse copia la respuesta para que los middlewares no modifiquen la guardada
+func copyResponse(resp *HTTPResponse) *HTTPResponse {
+	headers := make(map[string]string, len(resp.Headers)+3)
+	for name, value := range resp.Headers {
+		headers[name] = value
+	}
+	return &HTTPResponse{
+		StatusCode: resp.StatusCode,
+		StatusText: resp.StatusText,
+		Headers:    headers,
+		Body:       resp.Body,
+		problem:    resp.problem,
+		result:     resp.result,
+	}
+}
+
+// SetCacheConfig reemplaza la caché de respuestas por una vacía con la configuración dada
+func (s *Server) SetCacheConfig(config CacheConfig) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.cache = NewResponseCache(config)
+}
+
+// GetCache retorna la caché de respuestas
+func (s *Server) GetCache() *ResponseCache {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+	return s.cache
+}
+
+// CacheRoute activa la caché para las rutas que cubre el patrón. Solo tiene sentido en rutas
+// deterministas: la misma petición (según CacheKey) debe producir siempre la misma respuesta.
+func (s *Server) CacheRoute(method, pattern string, policy CachePolicy) {
+	name := pattern
+	if method != "" {
+		name = method + " " + pattern
+	}
+
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.cacheRoutes.add(method, pattern, name)
+	s.cachePolicies[name] = &cacheRoute{policy: policy}
+}
+
+// cacheRouteFor retorna la política de caché de la ruta, o nil
+func (s *Server) cacheRouteFor(method, path string) (*cacheRoute, *ResponseCache) {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+	name, ok := s.cacheRoutes.lookup(method, path)
+	if !ok {
+		return nil, nil
+	}
+	return s.cachePolicies[name], s.cache
+}
+
+// ServeCached atiende la petición con el handler pasando por la caché si la ruta la tiene
+// activa; en un miss, las peticiones idénticas en curso se agrupan (ver ServeCoalesced).
+// Agrega X-Cache (HIT, MISS o BYPASS), Age y Cache-Control. El cliente puede pedir una
+// respuesta nueva con "Cache-Control: no-cache" o que no se guarde con "no-store".
+func (s *Server) ServeCached(req *HTTPRequest, handler HandlerFunc) *HTTPResponse {
+	route, cache := s.cacheRouteFor(req.Method, req.Path)
+	if route == nil {
+		return s.ServeCoalesced(req, handler)
+	}
+
+	// Con efectos secundarios (p. ej. un archivo a escribir) cada petición ejecuta el handler:
+	// agruparla con otra igual descartaría sus efectos
+	if route.policy.sideEffects(req.Params) {
+		cache.recordBypass()
+		return withCacheHeaders(handler(req), "BYPASS", "", 0, 0)
+	}
+	if !route.policy.cacheable(req.Params) {
+		cache.recordBypass()
+		return withCacheHeaders(s.ServeCoalesced(req, handler), "BYPASS", "", 0, 0)
+	}
+
+	directives := strings.ToLower(req.GetHeader("Cache-Control"))
+	noCache := strings.Contains(directives, "no-cache")
+	noStore := strings.Contains(directives, "no-store")
+
+	key := CacheKey(req.Method, req.Path, req.Params, route.policy.Params)
+	if !noCache && !noStore {
+		if resp, age, remaining, ok := cache.Get(key); ok {
+			atomic.AddInt64(&route.hits, 1)
+			return withCacheHeaders(resp, "HIT", s.cacheScope(req), age, remaining)
+		}
+	} else {
+		cache.recordBypass()
+	}
+
+	atomic.AddInt64(&route.misses, 1)
+	resp := s.ServeCoalesced(req, handler)
+	if resp == nil || resp.StatusCode != 200 {
+		return resp
+	}
+
+	ttl := route.policy.TTL
+	if ttl <= 0 {
+		ttl = cache.config.DefaultTTL
+	}
+	if noStore || !cache.Set(key, resp, ttl) {
+		return withCacheHeaders(resp, "MISS", "", 0, 0)
+	}
+	return withCacheHeaders(resp, "MISS", s.cacheScope(req), 0, ttl)
+}
+
+// cacheable indica si la petición trae todos los parámetros de Require
+func (p CachePolicy) cacheable(params map[string]string) bool {
+	for _, name := range p.Require {
+		if strings.TrimSpace(params[name]) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// sideEffects indica si la petición trae alguno de los parámetros de Bypass
+func (p CachePolicy) sideEffects(params map[string]string) bool {
+	for _, name := range p.Bypass {
+		if _, ok := params[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheScope retorna "private" si la respuesta depende de credenciales (la petición viene
+// autenticada o la ruta no es pública), para que un proxy compartido no la entregue a otros
+// clientes; si no, "public"
+func (s *Server) cacheScope(req *HTTPRequest) string {
+	if req.Identity != nil || (s.authManager != nil && !s.authManager.isPublic(req.Method, req.Path)) {
+		return "private"
+	}
+	return "public"
+}
+
+// withCacheHeaders agrega los headers de caché; con maxAge 0 la respuesta no es reutilizable.
+// scope es "public" o "private" (ver cacheScope).
+func withCacheHeaders(resp *HTTPResponse, status, scope string, age, maxAge time.Duration) *HTTPResponse {
+	if resp == nil {
+		return nil
+	}
+	if resp.Headers == nil {
+		resp.Headers = make(map[string]string)
+	}
+	resp.Headers["X-Cache"] = status
+	if maxAge > 0 {
+		resp.Headers["Cache-Control"] = fmt.Sprintf("%s, max-age=%d", scope, int(maxAge.Seconds()))
+		resp.Headers["Age"] = strconv.Itoa(int(age.Seconds()))
+	} else if resp.StatusCode == 200 {
+		resp.Headers["Cache-Control"] = "no-store"
+	}
+	return resp
+}
+
+// cacheMiddleware pasa por la caché las rutas que la tienen activa
+func (s *Server) cacheMiddleware(next HandlerFunc) HandlerFunc {
+	return func(req *HTTPRequest) *HTTPResponse {
+		return s.ServeCached(req, next)
+	}
+}
+
+// CacheStats retorna las estadísticas de la caché y los aciertos por ruta
+func (s *Server) CacheStats() map[string]interface{} {
+	s.poolMu.RLock()
+	cache := s.cache
+	routes := make(map[string]interface{}, len(s.cachePolicies))
+	for name, route := range s.cachePolicies {
+		routes[name] = map[string]interface{}{
+			"hits":   atomic.LoadInt64(&route.hits),
+			"misses": atomic.LoadInt64(&route.misses),
+		}
+	}
+	s.poolMu.RUnlock()
+
+	stats := cache.Stats()
+	stats["routes"] = routes
+	return stats
+}
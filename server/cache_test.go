@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheLRUAndBudget(t *testing.T) {
+	cache := NewResponseCache(CacheConfig{MaxBytes: 3 * (entryOverhead + 110), MaxEntryBytes: 1000, DefaultTTL: time.Minute})
+	body := strings.Repeat("x", 100)
+
+	for _, key := range []string{"a", "b", "c"} {
+		if !cache.Set(key, &HTTPResponse{StatusCode: 200, Body: body}, 0) {
+			t.Fatalf("Set(%s) failed", key)
+		}
+	}
+	if cache.Len() != 3 {
+		t.Fatalf("Expected 3 entries, got %d", cache.Len())
+	}
+
+	// Usar "a" la vuelve la más reciente: la próxima en salir es "b"
+	if _, _, _, ok := cache.Get("a"); !ok {
+		t.Fatal("Expected hit for a")
+	}
+	cache.Set("d", &HTTPResponse{StatusCode: 200, Body: body}, 0)
+
+	if _, _, _, ok := cache.Get("b"); ok {
+		t.Error("Least recently used entry b should have been evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, _, _, ok := cache.Get(key); !ok {
+			t.Errorf("Expected %s to remain cached", key)
+		}
+	}
+
+	if cache.Set("big", &HTTPResponse{StatusCode: 200, Body: strings.Repeat("x", 2000)}, 0) {
+		t.Error("Responses above MaxEntryBytes should not be cached")
+	}
+
+	stats := cache.Stats()
+	if stats["evictions"].(int64) != 1 || stats["oversized"].(int64) != 1 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+	if stats["bytes"].(int64) > 3*(entryOverhead+110) {
+		t.Errorf("Cache exceeded its memory budget: %v bytes", stats["bytes"])
+	}
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	now := time.Now()
+	cache := NewResponseCache(DefaultCacheConfig())
+	cache.now = func() time.Time { return now }
+
+	cache.Set("k", &HTTPResponse{StatusCode: 200, Body: "v"}, 10*time.Second)
+
+	now = now.Add(4 * time.Second)
+	_, age, remaining, ok := cache.Get("k")
+	if !ok || age != 4*time.Second || remaining != 6*time.Second {
+		t.Errorf("Expected hit with age 4s and 6s remaining, got ok=%v age=%v remaining=%v", ok, age, remaining)
+	}
+
+	now = now.Add(6 * time.Second)
+	if _, _, _, ok := cache.Get("k"); ok {
+		t.Error("Expired entry should not be returned")
+	}
+	if cache.Len() != 0 || cache.Stats()["expirations"].(int64) != 1 {
+		t.Errorf("Expired entry should be removed, stats: %v", cache.Stats())
+	}
+}
+
+func TestCacheKeyNormalization(t *testing.T) {
+	a := CacheKey("get", "/matrixmul", map[string]string{"size": "010", "seed": " 7"}, nil)
+	b := CacheKey("GET", "/matrixmul", map[string]string{"seed": "+7", "size": "10"}, nil)
+	if a != b {
+		t.Errorf("Equivalent params should produce the same key: %q vs %q", a, b)
+	}
+
+	// Con Params, los demás parámetros no fragmentan la caché
+	c := CacheKey("GET", "/pi", map[string]string{"digits": "50", "nonce": "1"}, []string{"digits"})
+	d := CacheKey("GET", "/pi", map[string]string{"digits": "50", "nonce": "2"}, []string{"digits"})
+	if c != d {
+		t.Errorf("Params outside the key should be ignored: %q vs %q", c, d)
+	}
+
+	if CacheKey("GET", "/pi", map[string]string{"digits": "50"}, nil) == CacheKey("GET", "/pi", map[string]string{"digits": "51"}, nil) {
+		t.Error("Different params should produce different keys")
+	}
+}
+
+func TestServeCached(t *testing.T) {
+	var calls int64
+	handler := func(req *HTTPRequest) *HTTPResponse {
+		n := atomic.AddInt64(&calls, 1)
+		if req.Params["size"] == "0" {
+			return &HTTPResponse{StatusCode: 400, StatusText: "Bad Request"}
+		}
+		return &HTTPResponse{
+			StatusCode: 200,
+			StatusText: "OK",
+			Body:       fmt.Sprintf("result %s #%d", req.Params["size"], n),
+			Headers:    map[string]string{"Content-Type": "text/plain"},
+		}
+	}
+
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.CacheRoute("GET", "/matrixmul", CachePolicy{TTL: time.Minute, Require: []string{"seed"}})
+	req := func(params map[string]string, headers map[string]string) *HTTPResponse {
+		return srv.ServeCached(&HTTPRequest{Method: "GET", Path: "/matrixmul", Params: params, Headers: headers}, handler)
+	}
+
+	first := req(map[string]string{"size": "3", "seed": "1"}, nil)
+	second := req(map[string]string{"size": "3", "seed": "1"}, nil)
+	if first.Headers["X-Cache"] != "MISS" || second.Headers["X-Cache"] != "HIT" || second.Body != first.Body {
+		t.Fatalf("Expected MISS then HIT with the same body, got %q/%q", first.Headers["X-Cache"], second.Headers["X-Cache"])
+	}
+	if !strings.HasPrefix(second.Headers["Cache-Control"], "public, max-age=") || second.Headers["Age"] != "0" {
+		t.Errorf("Unexpected cache headers on hit: %v", second.Headers)
+	}
+
+	// Modificar la respuesta entregada no altera la cacheada
+	second.Headers["X-Extra"] = "1"
+	if third := req(map[string]string{"size": "3", "seed": "1"}, nil); third.Headers["X-Extra"] != "" {
+		t.Error("Cached response was mutated through a returned copy")
+	}
+
+	// Sin seed el resultado no es determinista: no se cachea
+	req(map[string]string{"size": "3"}, nil)
+	if r := req(map[string]string{"size": "3"}, nil); r.Headers["X-Cache"] != "BYPASS" {
+		t.Errorf("Expected BYPASS without seed, got %q", r.Headers["X-Cache"])
+	}
+
+	// Los errores no se cachean
+	req(map[string]string{"size": "0", "seed": "1"}, nil)
+	if r := req(map[string]string{"size": "0", "seed": "1"}, nil); r.Headers["X-Cache"] != "" {
+		t.Errorf("Error responses should not carry cache headers, got %q", r.Headers["X-Cache"])
+	}
+
+	// no-cache fuerza recalcular y refresca la entrada
+	before := atomic.LoadInt64(&calls)
+	fresh := req(map[string]string{"size": "3", "seed": "1"}, map[string]string{"Cache-Control": "no-cache"})
+	if atomic.LoadInt64(&calls) != before+1 || fresh.Body == first.Body {
+		t.Error("no-cache should recompute the response")
+	}
+	if r := req(map[string]string{"size": "3", "seed": "1"}, nil); r.Body != fresh.Body {
+		t.Error("no-cache should refresh the cached entry")
+	}
+
+	routes := srv.CacheStats()["routes"].(map[string]interface{})
+	if hits := routes["GET /matrixmul"].(map[string]interface{})["hits"].(int64); hits != 3 {
+		t.Errorf("Expected 3 hits for GET /matrixmul, got %d", hits)
+	}
+}
+
+func TestCacheRouteOverHTTP(t *testing.T) {
+	var calls int64
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/isprime", func(req *HTTPRequest) *HTTPResponse {
+		atomic.AddInt64(&calls, 1)
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: `{"is_prime":true}`}
+	})
+	srv.HandleFunc("GET", "/time", func(req *HTTPRequest) *HTTPResponse {
+		atomic.AddInt64(&calls, 1)
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: time.Now().String()}
+	})
+	srv.CacheRoute("", "/isprime", CachePolicy{})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		sendRaw(t, srv.Addr(), "GET /isprime?num=17 HTTP/1.1\r\nHost: test\r\n\r\n")
+	}
+	_, headers := sendRaw(t, srv.Addr(), "GET /isprime?num=017 HTTP/1.1\r\nHost: test\r\n\r\n")
+	if headers["X-Cache"] != "HIT" || headers["Age"] == "" {
+		t.Errorf("Expected HIT with Age, got %v", headers)
+	}
+	if atomic.LoadInt64(&calls) != 1 {
+		t.Errorf("Handler should run once for equivalent requests, ran %d times", calls)
+	}
+
+	// Las rutas sin política no pasan por la caché
+	_, headers = sendRaw(t, srv.Addr(), "GET /time HTTP/1.1\r\nHost: test\r\n\r\n")
+	if _, ok := headers["X-Cache"]; ok {
+		t.Errorf("Uncached route should not carry X-Cache, got %v", headers)
+	}
+}
+
+func TestServeCachedSideEffectsNotCoalesced(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.CacheRoute("GET", "/mandelbrot", CachePolicy{Params: []string{"width"}, Bypass: []string{"filename"}})
+	srv.CoalesceRoute("GET", "/mandelbrot", "width")
+
+	// El handler espera a que ambas peticiones estén ejecutándose: si se agruparan, solo
+	// una llegaría y la espera vencería
+	var entered sync.WaitGroup
+	entered.Add(2)
+	both := make(chan struct{})
+	go func() {
+		entered.Wait()
+		close(both)
+	}()
+	handler := func(req *HTTPRequest) *HTTPResponse {
+		entered.Done()
+		select {
+		case <-both:
+		case <-time.After(2 * time.Second):
+		}
+		return &HTTPResponse{StatusCode: 200, Body: req.Params["filename"], Headers: map[string]string{}}
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 2)
+	for i, filename := range []string{"a.pgm", "b.pgm"} {
+		wg.Add(1)
+		go func(i int, filename string) {
+			defer wg.Done()
+			resp := srv.ServeCached(&HTTPRequest{
+				Method: "GET", Path: "/mandelbrot",
+				Params: map[string]string{"width": "100", "filename": filename},
+			}, handler)
+			if resp.Headers["X-Cache"] != "BYPASS" || resp.Headers["X-Coalesced"] != "" {
+				t.Errorf("Expected an uncoalesced BYPASS, got %v", resp.Headers)
+			}
+			bodies[i] = resp.Body
+		}(i, filename)
+	}
+	wg.Wait()
+
+	select {
+	case <-both:
+	default:
+		t.Fatal("Requests with different filenames shared a single execution")
+	}
+	if bodies[0] != "a.pgm" || bodies[1] != "b.pgm" {
+		t.Errorf("Each request should get its own result, got %v", bodies)
+	}
+}
+
+func TestServeCachedPrivateScope(t *testing.T) {
+	handler := func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, Body: "ok", Headers: map[string]string{}}
+	}
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.CacheRoute("GET", "/isprime", CachePolicy{TTL: time.Minute})
+	srv.CacheRoute("GET", "/factor", CachePolicy{TTL: time.Minute})
+	serve := func(path string, identity *Identity) string {
+		resp := srv.ServeCached(&HTTPRequest{Method: "GET", Path: path, Params: map[string]string{"num": "7"}, Identity: identity}, handler)
+		return resp.Headers["Cache-Control"]
+	}
+
+	if cc := serve("/isprime", nil); !strings.HasPrefix(cc, "public, max-age=") {
+		t.Errorf("Expected a public response without authentication, got %q", cc)
+	}
+	// Un HIT para una petición autenticada tampoco puede quedar en un proxy compartido
+	if cc := serve("/isprime", &Identity{ID: "alice"}); !strings.HasPrefix(cc, "private, max-age=") {
+		t.Errorf("Expected a private response for an authenticated request, got %q", cc)
+	}
+
+	// Con autenticación habilitada, solo las rutas públicas son "public"
+	auth := NewAuthManager()
+	auth.AllowPublic([]string{"GET"}, "/isprime")
+	srv.authManager = auth
+	if cc := serve("/isprime", nil); !strings.HasPrefix(cc, "public, ") {
+		t.Errorf("Expected a public route to stay public, got %q", cc)
+	}
+	if cc := serve("/factor", nil); !strings.HasPrefix(cc, "private, ") {
+		t.Errorf("Expected a protected route to be private, got %q", cc)
+	}
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// flight es una ejecución compartida por todas las peticiones idénticas que llegan mientras corre
+type flight struct {
+	done    chan struct{}
+	resp    *HTTPResponse
+	waiters int // Peticiones esperando el resultado (protegido por Coalescer.mu)
+	cancel  context.CancelFunc
+}
+
+// coalesceRoute es la configuración y estadísticas de una ruta con coalescing
+type coalesceRoute struct {
+	params    []string // Parámetros que forman la clave (vacío = todos)
+	requests  int64    // (atómico)
+	coalesced int64    // Peticiones que se unieron a una ejecución en curso (atómico)
+}
+
+// Coalescer agrupa peticiones idénticas en curso (singleflight): la primera ejecuta el
+// handler y las que llegan mientras tanto esperan y reciben una copia de la misma respuesta.
+// La ejecución compartida solo se cancela cuando todas las peticiones que la esperan se fueron.
+type Coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+
+	executions int64 // Ejecuciones compartidas iniciadas
+	coalesced  int64 // Peticiones que reutilizaron una ejecución en curso
+	abandoned  int64 // Peticiones que dejaron de esperar (cliente desconectado, job cancelado)
+	cancelled  int64 // Ejecuciones canceladas por quedarse sin peticiones
+}
+
+// NewCoalescer crea un coalescer vacío
+func NewCoalescer() *Coalescer {
+	return &Coalescer{flights: make(map[string]*flight)}
+}
+
+// Do ejecuta fn una sola vez por clave entre las llamadas concurrentes. fn recibe un contexto
+// que se cancela cuando todos los que esperan abandonan (su ctx terminó). Retorna la respuesta
+// (una copia por llamador), si se reutilizó una ejecución en curso, y el error del ctx si el
+// llamador se fue antes de que terminara.
+func (c *Coalescer) Do(ctx context.Context, key string, fn func(ctx context.Context) *HTTPResponse) (*HTTPResponse, bool, error) {
+	c.mu.Lock()
+	f, shared := c.flights[key]
+	if shared {
+		f.waiters++
+		c.coalesced++
+	} else {
+		flightCtx, cancel := context.WithCancel(context.Background())
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		c.flights[key] = f
+		c.executions++
+		go c.run(key, f, flightCtx, fn)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.resp == nil {
+			return nil, shared, nil
+		}
+		return copyResponse(f.resp), shared, nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.abandoned++
+		f.waiters--
+		if f.waiters == 0 {
+			// Nadie más espera: cancelar y dejar que la próxima petición empiece de cero
+			f.cancel()
+			c.cancelled++
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+		}
+		c.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+// run ejecuta la función compartida y publica su respuesta
+func (c *Coalescer) run(key string, f *flight, ctx context.Context, fn func(ctx context.Context) *HTTPResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic in coalesced execution %s: %v", key, r)
+			f.resp = InternalError("internal server error")
+		}
+
+		c.mu.Lock()
+		if c.flights[key] == f {
+			delete(c.flights, key)
+		}
+		c.mu.Unlock()
+		f.cancel()
+		close(f.done)
+	}()
+
+	f.resp = fn(ctx)
+}
+
+// InFlight retorna la cantidad de ejecuciones compartidas en curso
+func (c *Coalescer) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.flights)
+}
+
+// Stats retorna los contadores del coalescer
+func (c *Coalescer) Stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"in_flight":  len(c.flights),
+		"executions": c.executions,
+		"coalesced":  c.coalesced,
+		"abandoned":  c.abandoned,
+		"cancelled":  c.cancelled,
+	}
+}
+
+// CoalesceRoute activa el coalescing en las rutas que cubre el patrón: las peticiones
+// idénticas en curso (según CacheKey sobre keyParams, o todos los parámetros) comparten
+// una sola ejecución del handler. Solo tiene sentido en rutas deterministas y sin efectos.
+func (s *Server) CoalesceRoute(method, pattern string, keyParams ...string) {
+	name := pattern
+	if method != "" {
+		name = method + " " + pattern
+	}
+
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.coalesceRoutes.add(method, pattern, name)
+	s.coalescePolicies[name] = &coalesceRoute{params: keyParams}
+}
+
+// coalesceRouteFor retorna la configuración de coalescing de la ruta, o nil
+func (s *Server) coalesceRouteFor(method, path string) *coalesceRoute {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+	name, ok := s.coalesceRoutes.lookup(method, path)
+	if !ok {
+		return nil
+	}
+	return s.coalescePolicies[name]
+}
+
+// ServeCoalesced atiende la petición uniéndola a una ejecución idéntica en curso si la ruta
+// tiene coalescing. Las peticiones que se unieron llevan el header X-Coalesced. Si el
+// contexto de la petición termina antes que la ejecución, responde 503.
+func (s *Server) ServeCoalesced(req *HTTPRequest, handler HandlerFunc) *HTTPResponse {
+	route := s.coalesceRouteFor(req.Method, req.Path)
+	if route == nil {
+		return handler(req)
+	}
+	atomic.AddInt64(&route.requests, 1)
+
+	key := CacheKey(req.Method, req.Path, req.Params, route.params)
+	resp, shared, err := s.coalescer.Do(req.Context(), key, func(ctx context.Context) *HTTPResponse {
+		return handler(req.WithContext(ctx))
+	})
+	if err != nil {
+		return NewProblem(503, "request cancelled").
+			WithType(ProblemRequestCancelled).
+			With("reason", err.Error()).
+			Response()
+	}
+	if shared {
+		atomic.AddInt64(&route.coalesced, 1)
+		if resp != nil {
+			resp.Headers["X-Coalesced"] = "true"
+		}
+	}
+	return resp
+}
+
+// CoalesceStats retorna las estadísticas del coalescer y las peticiones agrupadas por ruta
+func (s *Server) CoalesceStats() map[string]interface{} {
+	s.poolMu.RLock()
+	routes := make(map[string]interface{}, len(s.coalescePolicies))
+	for name, route := range s.coalescePolicies {
+		routes[name] = map[string]interface{}{
+			"requests":  atomic.LoadInt64(&route.requests),
+			"coalesced": atomic.LoadInt64(&route.coalesced),
+		}
+	}
+	s.poolMu.RUnlock()
+
+	stats := s.coalescer.Stats()
+	stats["routes"] = routes
+	return stats
+}
+
+// watchDisconnect cancela la petición si el cliente resetea la conexión mientras se atiende.
+// Un EOF no cuenta: puede ser un half-close legítimo (shutdown(SHUT_WR), curl --http1.0) de un
+// cliente que sigue esperando la respuesta. La función retornada detiene la vigilancia; debe
+// llamarse antes de cerrar la conexión.
+func watchDisconnect(conn net.Conn, cancel context.CancelFunc) func() {
+	var stopping int32
+	done := make(chan struct{})
+
+	conn.SetReadDeadline(time.Time{})
+	go func() {
+		defer close(done)
+		// Peek sobre un lector propio: los bytes que lleguen después de la petición no se
+		// descartan, y si llegan se deja de vigilar
+		_, err := bufio.NewReader(conn).Peek(1)
+		if isConnReset(err) && atomic.LoadInt32(&stopping) == 0 {
+			cancel()
+		}
+	}()
+
+	return func() {
+		atomic.StoreInt32(&stopping, 1)
+		conn.SetReadDeadline(time.Now())
+		<-done
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescerSharesExecution(t *testing.T) {
+	c := NewCoalescer()
+	release := make(chan struct{})
+	var calls int64
+
+	const waiters = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, waiters)
+	shared := int64(0)
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, wasShared, err := c.Do(context.Background(), "pi?digits=500", func(ctx context.Context) *HTTPResponse {
+				atomic.AddInt64(&calls, 1)
+				<-release
+				return &HTTPResponse{StatusCode: 200, Body: "3.14159", Headers: map[string]string{}}
+			})
+			if err != nil {
+				t.Errorf("Do returned error: %v", err)
+				return
+			}
+			if wasShared {
+				atomic.AddInt64(&shared, 1)
+			}
+			bodies[i] = resp.Body
+		}(i)
+	}
+
+	// Esperar a que todos estén unidos a la misma ejecución
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Stats()["coalesced"].(int64) < waiters-1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected a single execution, got %d", calls)
+	}
+	if shared != waiters-1 {
+		t.Errorf("Expected %d shared responses, got %d", waiters-1, shared)
+	}
+	for i, body := range bodies {
+		if body != "3.14159" {
+			t.Errorf("Waiter %d got body %q", i, body)
+		}
+	}
+	if c.InFlight() != 0 {
+		t.Errorf("Expected no flights after completion, got %d", c.InFlight())
+	}
+
+	// Terminada la ejecución, una nueva petición vuelve a ejecutar
+	c.Do(context.Background(), "pi?digits=500", func(ctx context.Context) *HTTPResponse {
+		atomic.AddInt64(&calls, 1)
+		return &HTTPResponse{StatusCode: 200, Headers: map[string]string{}}
+	})
+	if calls != 2 {
+		t.Errorf("Expected a new execution after completion, got %d calls", calls)
+	}
+}
+
+func TestCoalescerCancelsOnlyWhenAllWaitersLeave(t *testing.T) {
+	c := NewCoalescer()
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	fn := func(ctx context.Context) *HTTPResponse {
+		close(started)
+		<-ctx.Done()
+		close(aborted)
+		return nil
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { _, _, err := c.Do(ctx1, "k", fn); errs <- err }()
+	<-started
+	go func() { _, _, err := c.Do(ctx2, "k", fn); errs <- err }()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Stats()["coalesced"].(int64) < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Se va el primero: el cálculo sigue para el segundo
+	cancel1()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Expected context.Canceled for the leaving waiter, got %v", err)
+	}
+	select {
+	case <-aborted:
+		t.Fatal("Shared execution was aborted while a waiter remained")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Se va el último: se cancela
+	cancel2()
+	<-errs
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shared execution was not cancelled after every waiter left")
+	}
+
+	stats := c.Stats()
+	if stats["abandoned"].(int64) != 2 || stats["cancelled"].(int64) != 1 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestCoalesceRouteOverHTTP(t *testing.T) {
+	var calls int64
+	release := make(chan struct{})
+	srv := NewServer("127.0.0.1:0", 4)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/pi", func(req *HTTPRequest) *HTTPResponse {
+		atomic.AddInt64(&calls, 1)
+		<-release
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: "3.14"}
+	})
+	srv.CoalesceRoute("GET", "/pi", "digits")
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	var wg sync.WaitGroup
+	var coalescedHeaders int64
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, headers := sendRaw(t, srv.Addr(), "GET /pi?digits=50&nonce=x HTTP/1.1\r\nHost: test\r\n\r\n")
+			if code != 200 {
+				t.Errorf("Expected 200, got %d", code)
+			}
+			if headers["X-Coalesced"] == "true" {
+				atomic.AddInt64(&coalescedHeaders, 1)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for srv.coalescer.Stats()["coalesced"].(int64) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 || coalescedHeaders != 2 {
+		t.Errorf("Expected 1 execution and 2 coalesced responses, got %d and %d", calls, coalescedHeaders)
+	}
+	routes := srv.CoalesceStats()["routes"].(map[string]interface{})
+	if got := routes["GET /pi"].(map[string]interface{})["coalesced"].(int64); got != 2 {
+		t.Errorf("Expected 2 coalesced requests for GET /pi, got %d", got)
+	}
+}
+
+func TestCoalesceClientDisconnectCancels(t *testing.T) {
+	cancelled := make(chan struct{})
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/pi", func(req *HTTPRequest) *HTTPResponse {
+		select {
+		case <-req.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+		return &HTTPResponse{StatusCode: 503, StatusText: "Service Unavailable"}
+	})
+	srv.CoalesceRoute("GET", "/pi")
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	conn, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn.Write([]byte("GET /pi?digits=900 HTTP/1.1\r\nHost: test\r\n\r\n"))
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.coalescer.InFlight() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// Cerrar con linger 0 envía un reset, como un cliente que aborta
+	conn.(*net.TCPConn).SetLinger(0)
+	conn.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Shared execution was not cancelled after the only client disconnected")
+	}
+}
+
+func TestCoalesceHalfCloseKeepsServing(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/pi", func(req *HTTPRequest) *HTTPResponse {
+		select {
+		case <-req.Context().Done():
+			return &HTTPResponse{StatusCode: 500, StatusText: "Internal Server Error"}
+		case <-time.After(200 * time.Millisecond):
+		}
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: "3.14"}
+	})
+	srv.CoalesceRoute("GET", "/pi")
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	conn, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /pi?digits=10 HTTP/1.1\r\nHost: test\r\n\r\n"))
+	// Half-close: el cliente no envía nada más pero sigue esperando la respuesta
+	conn.(*net.TCPConn).CloseWrite()
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	data, _ := io.ReadAll(conn)
+	if !strings.HasPrefix(string(data), "HTTP/1.1 200") || !strings.HasSuffix(string(data), "3.14") {
+		t.Errorf("Expected the response after a half-close, got %q", data)
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CORSConfig define la política CORS de un grupo de rutas
+type CORSConfig struct {
+	AllowedOrigins   []string      // Orígenes exactos, "*" o comodines como "https://*.example.com"
+	AllowedMethods   []string      // Métodos permitidos (por defecto GET, HEAD, POST)
+	AllowedHeaders   []string      // Headers que el cliente puede enviar ("*" = cualquiera)
+	ExposedHeaders   []string      // Headers de la respuesta visibles para el navegador
+	AllowCredentials bool          // Permite cookies/Authorization en peticiones cross-origin
+	MaxAge           time.Duration // Tiempo que el navegador puede cachear el preflight
+}
+
+// corsGroup asocia un patrón de rutas a su política
+type corsGroup struct {
+	pattern string
+	config  CORSConfig
+}
+
+// CORS aplica políticas CORS por grupo de rutas y responde los preflight OPTIONS
+type CORS struct {
+	mu     sync.RWMutex
+	groups []corsGroup
+}
+
+// NewCORS crea un gestor CORS sin grupos configurados
+func NewCORS() *CORS {
+	return &CORS{}
+}
+
+// AddGroup aplica la política a las rutas que cubre el patrón ("/jobs/*", "/metrics", "*").
+// Si varios grupos cubren una ruta se usa el patrón más específico. Rechaza "*" junto con
+// AllowCredentials: cualquier sitio podría leer respuestas con las credenciales del usuario.
+func (c *CORS) AddGroup(pattern string, config CORSConfig) error {
+	if config.AllowCredentials && config.allowsAnyOrigin() {
+		return fmt.Errorf("cors %s: origin \"*\" cannot be combined with AllowCredentials", pattern)
+	}
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = []string{"GET", "HEAD", "POST"}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups = append(c.groups, corsGroup{pattern: pattern, config: config})
+
+	// Más específico primero: exactos antes que prefijos, prefijos largos antes que cortos
+	sort.SliceStable(c.groups, func(i, j int) bool {
+		return patternSpecificity(c.groups[i].pattern) > patternSpecificity(c.groups[j].pattern)
+	})
+	return nil
+}
+
+// patternSpecificity ordena patrones de más a menos específico
+func patternSpecificity(pattern string) int {
+	switch {
+	case pattern == "*":
+		return 0
+	case strings.HasSuffix(pattern, "/*"):
+		return len(pattern)
+	default:
+		return 1 << 20
+	}
+}
+
+// policyFor retorna la política que aplica a la ruta
+func (c *CORS) policyFor(path string) (*CORSConfig, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i := range c.groups {
+		if matchPattern(c.groups[i].pattern, path) {
+			return &c.groups[i].config, true
+		}
+	}
+	return nil, false
+}
+
+// Middleware retorna el middleware CORS. Debe registrarse antes que la autenticación
+// para que los preflight (que no llevan credenciales) se respondan sin llegar al handler.
+func (c *CORS) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(req *HTTPRequest) *HTTPResponse {
+			origin := req.GetHeader("Origin")
+			if origin == "" {
+				return next(req)
+			}
+
+			config, ok := c.policyFor(req.Path)
+
+			// Preflight: OPTIONS con Access-Control-Request-Method
+			if req.Method == "OPTIONS" && req.GetHeader("Access-Control-Request-Method") != "" {
+				if !ok {
+					return next(req)
+				}
+				return config.preflight(req, origin)
+			}
+
+			resp := next(req)
+			if ok && config.originAllowed(origin) {
+				if resp.Headers == nil {
+					resp.Headers = make(map[string]string)
+				}
+				config.setOriginHeaders(resp.Headers, origin)
+				if len(config.ExposedHeaders) > 0 {
+					resp.Headers["Access-Control-Expose-Headers"] = strings.Join(config.ExposedHeaders, ", ")
+				}
+			}
+			return resp
+		}
+	}
+}
+
+// preflight construye la respuesta a una petición OPTIONS de preflight
+func (config *CORSConfig) preflight(req *HTTPRequest, origin string) *HTTPResponse {
+	method := req.GetHeader("Access-Control-Request-Method")
+	requested := splitHeaderList(req.GetHeader("Access-Control-Request-Headers"))
+
+	if !config.originAllowed(origin) || !config.methodAllowed(method) || !config.headersAllowed(requested) {
+		return NewProblem(403, "CORS preflight rejected").
+			WithHeader("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers").
+			Response()
+	}
+
+	headers := map[string]string{
+		"Access-Control-Allow-Methods": strings.Join(config.AllowedMethods, ", "),
+		"Vary":                         "Origin, Access-Control-Request-Method, Access-Control-Request-Headers",
+	}
+	config.setOriginHeaders(headers, origin)
+
+	if len(requested) > 0 {
+		if config.allowsAnyHeader() {
+			headers["Access-Control-Allow-Headers"] = strings.Join(requested, ", ")
+		} else {
+			headers["Access-Control-Allow-Headers"] = strings.Join(config.AllowedHeaders, ", ")
+		}
+	}
+
+	if config.MaxAge > 0 {
+		headers["Access-Control-Max-Age"] = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+
+	return &HTTPResponse{
+		StatusCode: 204,
+		StatusText: "No Content",
+		Headers:    headers,
+	}
+}
+
+// setOriginHeaders agrega Allow-Origin, Allow-Credentials y Vary
+func (config *CORSConfig) setOriginHeaders(headers map[string]string, origin string) {
+	// Con "*" no hay credenciales (ver AddGroup); con una lista hay que reflejar el origen
+	if config.allowsAnyOrigin() {
+		headers["Access-Control-Allow-Origin"] = "*"
+	} else {
+		headers["Access-Control-Allow-Origin"] = origin
+		addVary(headers, "Origin")
+	}
+
+	if config.AllowCredentials {
+		headers["Access-Control-Allow-Credentials"] = "true"
+	}
+}
+
+// originAllowed compara el origen contra la lista exacta o con comodines
+func (config *CORSConfig) originAllowed(origin string) bool {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if star := strings.Index(allowed, "*"); star != -1 {
+			prefix, suffix := allowed[:star], allowed[star+1:]
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowsAnyOrigin indica si la política acepta cualquier origen
+func (config *CORSConfig) allowsAnyOrigin() bool {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// methodAllowed indica si el método solicitado en el preflight está permitido
+func (config *CORSConfig) methodAllowed(method string) bool {
+	for _, m := range config.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAnyHeader indica si la política acepta cualquier header
+func (config *CORSConfig) allowsAnyHeader() bool {
+	for _, h := range config.AllowedHeaders {
+		if h == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// headersAllowed indica si todos los headers solicitados están permitidos
+func (config *CORSConfig) headersAllowed(requested []string) bool {
+	if config.allowsAnyHeader() {
+		return true
+	}
+	for _, header := range requested {
+		found := false
+		for _, allowed := range config.AllowedHeaders {
+			if strings.EqualFold(allowed, header) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// addVary agrega un valor al header Vary sin duplicarlo
+func addVary(headers map[string]string, value string) {
+	vary := headers["Vary"]
+	for _, existing := range splitHeaderList(vary) {
+		if strings.EqualFold(existing, value) {
+			return
+		}
+	}
+	if vary == "" {
+		headers["Vary"] = value
+	} else {
+		headers["Vary"] = vary + ", " + value
+	}
+}
+
+// splitHeaderList separa una lista de headers separada por comas
+func splitHeaderList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	cors := NewCORS()
+	cors.AddGroup("/jobs/*", CORSConfig{
+		AllowedOrigins:   []string{"https://dashboard.example.com", "https://*.internal.example.com"},
+		AllowedMethods:   []string{"GET", "DELETE"},
+		AllowedHeaders:   []string{"Authorization"},
+		ExposedHeaders:   []string{"Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	cors.AddGroup("*", CORSConfig{AllowedOrigins: []string{"*"}})
+
+	router := NewRouter()
+	router.Register("GET", "/jobs/status", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, Headers: map[string]string{}}
+	})
+	router.Register("GET", "/ping", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, Headers: map[string]string{}}
+	})
+	router.Use(cors.Middleware())
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		expectedOrigin string
+	}{
+		{"Preflight allowed", "OPTIONS", "/jobs/cancel", map[string]string{
+			"Origin": "https://dashboard.example.com", "Access-Control-Request-Method": "DELETE",
+			"Access-Control-Request-Headers": "authorization",
+		}, 204, "https://dashboard.example.com"},
+		{"Preflight wildcard subdomain", "OPTIONS", "/jobs/status", map[string]string{
+			"Origin": "https://a.internal.example.com", "Access-Control-Request-Method": "GET",
+		}, 204, "https://a.internal.example.com"},
+		{"Preflight bad origin", "OPTIONS", "/jobs/status", map[string]string{
+			"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET",
+		}, 403, ""},
+		{"Preflight bad method", "OPTIONS", "/jobs/status", map[string]string{
+			"Origin": "https://dashboard.example.com", "Access-Control-Request-Method": "PUT",
+		}, 403, ""},
+		{"Preflight bad header", "OPTIONS", "/jobs/status", map[string]string{
+			"Origin": "https://dashboard.example.com", "Access-Control-Request-Method": "GET",
+			"Access-Control-Request-Headers": "X-Custom",
+		}, 403, ""},
+		{"Simple request", "GET", "/jobs/status", map[string]string{"Origin": "https://dashboard.example.com"}, 200, "https://dashboard.example.com"},
+		{"Catch-all group", "GET", "/ping", map[string]string{"Origin": "https://any.example"}, 200, "*"},
+		{"No origin", "GET", "/ping", map[string]string{}, 200, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := router.Handle(&HTTPRequest{Method: tt.method, Path: tt.path, Headers: tt.headers})
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if got := resp.Headers["Access-Control-Allow-Origin"]; got != tt.expectedOrigin {
+				t.Errorf("Expected Allow-Origin %q, got %q", tt.expectedOrigin, got)
+			}
+		})
+	}
+
+	preflight := router.Handle(&HTTPRequest{Method: "OPTIONS", Path: "/jobs/cancel", Headers: map[string]string{
+		"Origin": "https://dashboard.example.com", "Access-Control-Request-Method": "DELETE",
+	}})
+	if preflight.Headers["Access-Control-Max-Age"] != "600" || preflight.Headers["Access-Control-Allow-Credentials"] != "true" {
+		t.Errorf("Unexpected preflight headers: %v", preflight.Headers)
+	}
+}
+
+func TestCORSRejectsWildcardWithCredentials(t *testing.T) {
+	cors := NewCORS()
+	if err := cors.AddGroup("/jobs/*", CORSConfig{
+		AllowedOrigins:   []string{"https://dashboard.example.com", "*"},
+		AllowCredentials: true,
+	}); err == nil {
+		t.Fatal("Expected an error for \"*\" with AllowCredentials")
+	}
+	if len(cors.groups) != 0 {
+		t.Errorf("The rejected policy should not be registered, got %d groups", len(cors.groups))
+	}
+
+	// Los comodines de subdominio siguen permitidos con credenciales
+	if err := cors.AddGroup("/jobs/*", CORSConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowCredentials: true,
+	}); err != nil {
+		t.Errorf("Unexpected error for a subdomain wildcard: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"sync"
 	"sync/atomic"
 )
 
@@ -43,3 +44,58 @@ func (c *Counter) Reset() {
 func (c *Counter) Add(delta int64) int64 {
 	return atomic.AddInt64(&c.value, delta)
 }
+
+// ReasonCounter agrupa contadores atómicos por motivo (por ejemplo, causas de rechazo)
+type ReasonCounter struct {
+	mu       sync.RWMutex
+	counters map[string]*Counter
+}
+
+// NewReasonCounter crea un contador por motivo; los motivos dados aparecen aunque valgan 0
+func NewReasonCounter(reasons ...string) *ReasonCounter {
+	rc := &ReasonCounter{counters: make(map[string]*Counter)}
+	for _, reason := range reasons {
+		rc.counters[reason] = NewCounter()
+	}
+	return rc
+}
+
+// Increment incrementa el contador del motivo y retorna el nuevo valor
+func (rc *ReasonCounter) Increment(reason string) int64 {
+	rc.mu.RLock()
+	counter, ok := rc.counters[reason]
+	rc.mu.RUnlock()
+
+	if !ok {
+		rc.mu.Lock()
+		if counter, ok = rc.counters[reason]; !ok {
+			counter = NewCounter()
+			rc.counters[reason] = counter
+		}
+		rc.mu.Unlock()
+	}
+
+	return counter.Increment()
+}
+
+// Get obtiene el valor actual del contador de un motivo
+func (rc *ReasonCounter) Get(reason string) int64 {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	if counter, ok := rc.counters[reason]; ok {
+		return counter.Get()
+	}
+	return 0
+}
+
+// Snapshot retorna una copia de todos los contadores
+func (rc *ReasonCounter) Snapshot() map[string]int64 {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	snapshot := make(map[string]int64, len(rc.counters))
+	for reason, counter := range rc.counters {
+		snapshot[reason] = counter.Get()
+	}
+	return snapshot
+}
@@ -0,0 +1,406 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Formatos de salida de los resultados de los handlers
+const (
+	FormatJSON    = "json"    // JSON indentado (por defecto)
+	FormatCompact = "compact" // JSON sin indentación
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatText    = "text"
+)
+
+// formatContentTypes es el Content-Type de cada formato
+var formatContentTypes = map[string]string{
+	FormatJSON:    "application/json",
+	FormatCompact: "application/json",
+	FormatCSV:     "text/csv; charset=utf-8",
+	FormatNDJSON:  "application/x-ndjson",
+	FormatText:    "text/plain; charset=utf-8",
+}
+
+// acceptFormats son los media types que se negocian con Accept, en orden de preferencia
+// ante un empate (*/* elige JSON). El JSON compacto solo se pide con ?format=compact.
+var acceptFormats = []struct {
+	mediaType string
+	format    string
+}{
+	{"application/json", FormatJSON},
+	{"text/csv", FormatCSV},
+	{"application/x-ndjson", FormatNDJSON},
+	{"application/ndjson", FormatNDJSON},
+	{"text/plain", FormatText},
+}
+
+// FormatParam es el parámetro con el que se elige el formato de un resultado; tiene prioridad sobre Accept
+var FormatParam = EnumParam("format", "Formato de salida (tiene prioridad sobre Accept)",
+	FormatJSON, FormatCompact, FormatCSV, FormatNDJSON, FormatText)
+
+// Result es el valor estructurado que retorna un handler. El servidor lo serializa en el
+// formato que pide el cliente; sin tabla ni registros explícitos, CSV, NDJSON y texto se
+// derivan del valor (ver deriveTable).
+type Result struct {
+	Value interface{}
+
+	status  int
+	columns []string
+	rows    interface{}
+	records interface{}
+}
+
+// NewResult crea un resultado 200 con el valor dado
+func NewResult(value interface{}) *Result {
+	return &Result{Value: value, status: 200}
+}
+
+// WithStatus cambia el status de la respuesta
+func (r *Result) WithStatus(status int) *Result {
+	r.status = status
+	return r
+}
+
+// Table define las filas de CSV y texto. rows es un slice cuyos elementos son slices (una
+// columna por elemento), mapas o structs (una columna por nombre en columns) o escalares
+// (una sola columna). columns puede ser nil para una tabla sin encabezado, como una matriz.
+func (r *Result) Table(columns []string, rows interface{}) *Result {
+	r.columns = columns
+	r.rows = rows
+	return r
+}
+
+// Records define los elementos de NDJSON (un slice; una línea por elemento). Sin Records,
+// NDJSON emite una línea por fila de la tabla o por elemento del valor si es un slice.
+func (r *Result) Records(records interface{}) *Result {
+	r.records = records
+	return r
+}
+
+// Response construye la respuesta en JSON indentado; el servidor la vuelve a serializar
+// al enviarla si el cliente pidió otro formato
+func (r *Result) Response() *HTTPResponse {
+	body, err := json.MarshalIndent(r.Value, "", "  ")
+	if err != nil {
+		return InternalError("failed to encode result")
+	}
+	return &HTTPResponse{
+		StatusCode: r.status,
+		StatusText: StatusText(r.status),
+		Body:       string(body),
+		Headers:    map[string]string{"Content-Type": formatContentTypes[FormatJSON]},
+		result:     r,
+	}
+}
+
+// resultFormat elige el formato: ?format=... o, si no está, el Accept de la petición
+func resultFormat(req *HTTPRequest) (string, *HTTPResponse) {
+	if value := strings.TrimSpace(req.Params["format"]); value != "" {
+		values, errs := BindParams(map[string]string{"format": value}, []Param{FormatParam})
+		if len(errs) > 0 {
+			return "", InvalidParamsResponse(errs)
+		}
+		return values.String("format"), nil
+	}
+
+	offers := make([]string, len(acceptFormats))
+	for i, f := range acceptFormats {
+		offers[i] = f.mediaType
+	}
+	chosen := Negotiate(req.GetHeader("Accept"), offers...)
+	for _, f := range acceptFormats {
+		if f.mediaType == chosen {
+			return f.format, nil
+		}
+	}
+	return FormatJSON, nil
+}
+
+// encodeResult serializa el resultado de resp en el formato que pide la petición.
+// Retorna una respuesta nueva: resp puede estar en la caché o compartida.
+func encodeResult(req *HTTPRequest, resp *HTTPResponse) *HTTPResponse {
+	format, errResp := resultFormat(req)
+	if errResp != nil {
+		return errResp
+	}
+
+	out := *resp
+	out.result = nil
+	out.Headers = make(map[string]string, len(resp.Headers)+1)
+	for name, value := range resp.Headers {
+		out.Headers[name] = value
+	}
+	out.Headers["Vary"] = "Accept"
+	if format == FormatJSON {
+		return &out
+	}
+
+	body, err := resp.result.encode(format)
+	if err != nil {
+		return InternalError("failed to encode result as " + format)
+	}
+	out.Body = body
+	out.Headers["Content-Type"] = formatContentTypes[format]
+	return &out
+}
+
+// encode serializa el resultado en un formato distinto del JSON indentado
+func (r *Result) encode(format string) (string, error) {
+	if format == FormatCompact {
+		body, err := json.Marshal(r.Value)
+		return string(body), err
+	}
+
+	columns, rows, keyValue, err := r.table()
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case FormatCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if len(columns) > 0 {
+			w.Write(columns)
+		}
+		for _, row := range rows {
+			w.Write(row)
+		}
+		w.Flush()
+		return buf.String(), w.Error()
+
+	case FormatNDJSON:
+		records, err := r.ndjsonRecords(columns)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		for _, record := range records {
+			line, err := json.Marshal(record)
+			if err != nil {
+				return "", err
+			}
+			b.Write(line)
+			b.WriteByte('\n')
+		}
+		return b.String(), nil
+
+	case FormatText:
+		var b strings.Builder
+		if keyValue {
+			// Los objetos se listan como "clave: valor"
+			for _, row := range rows {
+				fmt.Fprintf(&b, "%s: %s\n", row[0], row[1])
+			}
+			return b.String(), nil
+		}
+		if len(columns) > 0 {
+			b.WriteString(strings.Join(columns, "\t") + "\n")
+		}
+		for _, row := range rows {
+			b.WriteString(strings.Join(row, "\t") + "\n")
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// table retorna encabezado y filas ya formateadas. keyValue indica que la tabla se derivó
+// de un objeto (columnas key y value).
+func (r *Result) table() ([]string, [][]string, bool, error) {
+	if r.rows == nil {
+		return deriveTable(r.Value)
+	}
+	rows, err := normalize(r.rows)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	items, _ := rows.([]interface{})
+	out := make([][]string, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case []interface{}:
+			out[i] = cells(v)
+		case map[string]interface{}:
+			row := make([]interface{}, len(r.columns))
+			for j, column := range r.columns {
+				row[j] = v[column]
+			}
+			out[i] = cells(row)
+		default:
+			out[i] = []string{cell(v)}
+		}
+	}
+	return r.columns, out, false, nil
+}
+
+// ndjsonRecords retorna los elementos de NDJSON: los registros explícitos, las filas de la
+// tabla (como objetos si tiene encabezado) o los elementos del valor si es un slice
+func (r *Result) ndjsonRecords(columns []string) ([]interface{}, error) {
+	source := r.records
+	if source == nil && r.rows != nil {
+		source = r.rows
+	}
+	if source == nil {
+		source = r.Value
+	}
+	value, err := normalize(source)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return []interface{}{value}, nil
+	}
+
+	// Las filas de una tabla con encabezado salen como objetos
+	if r.records == nil && r.rows != nil && len(columns) > 0 {
+		for i, item := range items {
+			if row, ok := item.([]interface{}); ok && len(row) == len(columns) {
+				record := make(map[string]interface{}, len(columns))
+				for j, column := range columns {
+					record[column] = row[j]
+				}
+				items[i] = record
+			}
+		}
+	}
+	return items, nil
+}
+
+// deriveTable arma la tabla de un valor sin tabla explícita:
+//   - slice de objetos: una columna por clave (la unión, ordenada)
+//   - slice de slices: una fila por elemento, sin encabezado
+//   - slice de escalares: una sola columna "value"
+//   - objeto: filas key/value, con las claves anidadas aplanadas con puntos
+func deriveTable(v interface{}) ([]string, [][]string, bool, error) {
+	value, err := normalize(v)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	switch typed := value.(type) {
+	case []interface{}:
+		if len(typed) == 0 {
+			return nil, nil, false, nil
+		}
+		if _, ok := typed[0].(map[string]interface{}); ok {
+			keys := map[string]bool{}
+			for _, item := range typed {
+				if obj, ok := item.(map[string]interface{}); ok {
+					for key := range obj {
+						keys[key] = true
+					}
+				}
+			}
+			columns := make([]string, 0, len(keys))
+			for key := range keys {
+				columns = append(columns, key)
+			}
+			sort.Strings(columns)
+			rows := make([][]string, len(typed))
+			for i, item := range typed {
+				obj, _ := item.(map[string]interface{})
+				row := make([]string, len(columns))
+				for j, column := range columns {
+					row[j] = cell(obj[column])
+				}
+				rows[i] = row
+			}
+			return columns, rows, false, nil
+		}
+		if _, ok := typed[0].([]interface{}); ok {
+			rows := make([][]string, len(typed))
+			for i, item := range typed {
+				if row, ok := item.([]interface{}); ok {
+					rows[i] = cells(row)
+				} else {
+					rows[i] = []string{cell(item)}
+				}
+			}
+			return nil, rows, false, nil
+		}
+		rows := make([][]string, len(typed))
+		for i, item := range typed {
+			rows[i] = []string{cell(item)}
+		}
+		return []string{"value"}, rows, false, nil
+
+	case map[string]interface{}:
+		var rows [][]string
+		flatten("", typed, &rows)
+		sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+		return []string{"key", "value"}, rows, true, nil
+	}
+	return []string{"value"}, [][]string{{cell(value)}}, false, nil
+}
+
+// flatten agrega una fila por hoja del objeto, con las claves anidadas unidas por puntos
+func flatten(prefix string, obj map[string]interface{}, rows *[][]string) {
+	for key, value := range obj {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(key, nested, rows)
+			continue
+		}
+		*rows = append(*rows, []string{key, cell(value)})
+	}
+}
+
+// normalize convierte un valor a la forma genérica de JSON (mapas, slices, json.Number...)
+// para recorrer igual structs, mapas tipados y slices de cualquier tipo
+func normalize(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if kind := reflect.TypeOf(v).Kind(); kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map ||
+		kind == reflect.Struct || kind == reflect.Ptr {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var out interface{}
+		err = decoder.Decode(&out)
+		return out, err
+	}
+	return v, nil
+}
+
+// cells formatea una fila
+func cells(row []interface{}) []string {
+	out := make([]string, len(row))
+	for i, value := range row {
+		out[i] = cell(value)
+	}
+	return out
+}
+
+// cell formatea un valor para CSV o texto: los escalares tal cual y lo anidado como JSON compacto
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool, int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// encodeFor serializa el resultado como lo haría el servidor para la petición
+func encodeFor(t *testing.T, resp *HTTPResponse, params map[string]string, accept string) *HTTPResponse {
+	t.Helper()
+	req := &HTTPRequest{Method: "GET", Path: "/test", Params: params, Headers: map[string]string{}}
+	if accept != "" {
+		req.Headers["Accept"] = accept
+	}
+	return finalizeResponse(req, resp)
+}
+
+func TestResultFormats(t *testing.T) {
+	matrix := map[string]interface{}{"size": 2, "result": [][]int{{1, 2}, {3, 4}}}
+	resp := NewResult(matrix).Table(nil, matrix["result"]).Response()
+
+	tests := []struct {
+		format      string
+		contentType string
+		body        string
+	}{
+		{FormatCompact, "application/json", `{"result":[[1,2],[3,4]],"size":2}`},
+		{FormatCSV, "text/csv; charset=utf-8", "1,2\n3,4\n"},
+		{FormatNDJSON, "application/x-ndjson", "[1,2]\n[3,4]\n"},
+		{FormatText, "text/plain; charset=utf-8", "1\t2\n3\t4\n"},
+	}
+	for _, tt := range tests {
+		out := encodeFor(t, resp, map[string]string{"format": tt.format}, "")
+		if out.StatusCode != 200 || out.Headers["Content-Type"] != tt.contentType || out.Body != tt.body {
+			t.Errorf("format=%s: got %d %q %q", tt.format, out.StatusCode, out.Headers["Content-Type"], out.Body)
+		}
+		if out.Headers["Vary"] != "Accept" {
+			t.Errorf("format=%s: expected Vary: Accept", tt.format)
+		}
+	}
+
+	// JSON indentado por defecto, sin tocar la respuesta original (puede estar en la caché)
+	out := encodeFor(t, resp, map[string]string{}, "")
+	if out.Body != resp.Body || !strings.Contains(out.Body, "\n  ") || resp.Headers["Vary"] != "" {
+		t.Errorf("Unexpected default JSON: %q (original headers %v)", out.Body, resp.Headers)
+	}
+}
+
+func TestResultNegotiation(t *testing.T) {
+	resp := NewResult([]int{0, 1, 1, 2}).Response()
+
+	tests := []struct {
+		params map[string]string
+		accept string
+		want   string
+	}{
+		{nil, "text/csv", "value\n0\n1\n1\n2\n"},
+		{nil, "application/x-ndjson", "0\n1\n1\n2\n"},
+		{nil, "text/plain", "value\n0\n1\n1\n2\n"},
+		{nil, "text/html,application/xhtml+xml,*/*;q=0.8", resp.Body},
+		{map[string]string{"format": "compact"}, "text/csv", "[0,1,1,2]"},
+	}
+	for _, tt := range tests {
+		if out := encodeFor(t, resp, tt.params, tt.accept); out.Body != tt.want {
+			t.Errorf("params=%v Accept=%q: got %q, want %q", tt.params, tt.accept, out.Body, tt.want)
+		}
+	}
+
+	out := encodeFor(t, resp, map[string]string{"format": "xml"}, "")
+	if out.StatusCode != 400 || out.Headers["Content-Type"] != ProblemContentType || !strings.Contains(out.Body, `"name":"format"`) {
+		t.Errorf("Expected a 400 problem for an unknown format, got %d %s", out.StatusCode, out.Body)
+	}
+}
+
+func TestResultDerivedTables(t *testing.T) {
+	type job struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	jobs := NewResult([]job{{"a", "done"}, {"b", "queued"}}).Response()
+	if out := encodeFor(t, jobs, map[string]string{"format": "csv"}, ""); out.Body != "id,status\na,done\nb,queued\n" {
+		t.Errorf("Unexpected CSV for a slice of structs: %q", out.Body)
+	}
+	if out := encodeFor(t, jobs, map[string]string{"format": "ndjson"}, ""); out.Body != "{\"id\":\"a\",\"status\":\"done\"}\n{\"id\":\"b\",\"status\":\"queued\"}\n" {
+		t.Errorf("Unexpected NDJSON for a slice of structs: %q", out.Body)
+	}
+
+	// Los objetos se aplanan en filas key/value
+	info := NewResult(map[string]interface{}{
+		"job_id": "job-1", "result": map[string]interface{}{"count": 3, "lines": []string{"a", "b,c"}},
+	}).Response()
+	if out := encodeFor(t, info, map[string]string{"format": "csv"}, ""); out.Body != "key,value\njob_id,job-1\nresult.count,3\nresult.lines,\"[\"\"a\"\",\"\"b,c\"\"]\"\n" {
+		t.Errorf("Unexpected CSV for an object: %q", out.Body)
+	}
+	if out := encodeFor(t, info, map[string]string{"format": "text"}, ""); out.Body != "job_id: job-1\nresult.count: 3\nresult.lines: [\"a\",\"b,c\"]\n" {
+		t.Errorf("Unexpected text for an object: %q", out.Body)
+	}
+	if out := encodeFor(t, info, map[string]string{"format": "ndjson"}, ""); strings.Count(out.Body, "\n") != 1 {
+		t.Errorf("Expected a single NDJSON line for an object: %q", out.Body)
+	}
+
+	// Una tabla con encabezado sale como objetos en NDJSON
+	fib := NewResult([]int{0, 1}).Table([]string{"index", "value"}, [][]int{{0, 0}, {1, 1}}).Response()
+	out := encodeFor(t, fib, map[string]string{"format": "ndjson"}, "")
+	var first map[string]int
+	if err := json.Unmarshal([]byte(strings.SplitN(out.Body, "\n", 2)[0]), &first); err != nil || first["index"] != 0 || len(first) != 2 {
+		t.Errorf("Unexpected NDJSON for a table: %q", out.Body)
+	}
+}
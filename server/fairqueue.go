@@ -0,0 +1,345 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultClass es la clase de las tareas sin clase asignada
+const DefaultClass = "default"
+
+// defaultMaxClassWait es la antigüedad a partir de la cual una tarea se atiende
+// aunque haya clases más prioritarias esperando (evita la inanición)
+const defaultMaxClassWait = 2 * time.Second
+
+// SchedulingClass define cómo compite una clase de rutas por los workers de un pool
+type SchedulingClass struct {
+	Name     string
+	Weight   int           // Tareas por turno frente a clases de igual prioridad (mínimo 1)
+	Priority int           // Las clases de mayor prioridad se atienden primero
+	MaxWait  time.Duration // Antigüedad que fuerza atender la clase (0 = defaultMaxClassWait)
+}
+
+// classified lo implementan los elementos que declaran su clase de planificación
+type classified interface {
+	SchedulingClass() string
+}
+
+// fairItem es un elemento encolado con su momento de llegada
+type fairItem struct {
+	value    interface{}
+	enqueued time.Time
+}
+
+// fairClass es la cola FIFO de una clase y sus estadísticas
+type fairClass struct {
+	SchedulingClass
+	buffer  []fairItem // Ring buffer
+	head    int
+	count   int
+	deficit int // Créditos restantes en el turno actual (deficit round robin)
+
+	enqueued  int64
+	dequeued  int64
+	aged      int64 // Atendidas por superar MaxWait
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+func (c *fairClass) push(item fairItem) {
+	c.buffer[(c.head+c.count)%len(c.buffer)] = item
+	c.count++
+	c.enqueued++
+}
+
+func (c *fairClass) pop(now time.Time) interface{} {
+	item := c.buffer[c.head]
+	c.buffer[c.head] = fairItem{}
+	c.head = (c.head + 1) % len(c.buffer)
+	c.count--
+
+	wait := now.Sub(item.enqueued)
+	c.dequeued++
+	c.totalWait += wait
+	if wait > c.maxWait {
+		c.maxWait = wait
+	}
+	return item.value
+}
+
+// priorityLevel agrupa las clases de igual prioridad, que se reparten el turno por peso
+type priorityLevel struct {
+	priority int
+	classes  []*fairClass
+	cursor   int
+}
+
+// FairQueue es una cola acotada que reparte los workers entre clases de rutas:
+// las clases de mayor prioridad pasan primero, las de igual prioridad se turnan en
+// proporción a su peso (deficit round robin) y cualquier tarea que espere más que el
+// MaxWait de su clase se atiende de inmediato, así que ninguna clase queda sin servicio.
+type FairQueue struct {
+	mu       sync.Mutex
+	classes  map[string]*fairClass
+	levels   []*priorityLevel // De mayor a menor prioridad
+	count    int
+	capacity int
+	ready    chan struct{} // Un token por elemento encolado
+	done     chan struct{}
+	closed   bool
+	now      func() time.Time
+}
+
+// NewFairQueue crea una cola justa con las clases dadas; si no se define DefaultClass,
+// se agrega con peso 1 y prioridad 0
+func NewFairQueue(capacity int, classes ...SchedulingClass) *FairQueue {
+	if capacity <= 0 {
+		capacity = 100
+	}
+
+	q := &FairQueue{
+		classes:  make(map[string]*fairClass),
+		capacity: capacity,
+		ready:    make(chan struct{}, capacity),
+		done:     make(chan struct{}),
+		now:      time.Now,
+	}
+
+	for _, class := range classes {
+		q.addClass(class)
+	}
+	if _, ok := q.classes[DefaultClass]; !ok {
+		q.addClass(SchedulingClass{Name: DefaultClass, Weight: 1})
+	}
+
+	// Agrupar por prioridad; el primer turno de cada nivel arranca con sus créditos
+	byPriority := make(map[int]*priorityLevel)
+	for _, class := range q.classes {
+		level, ok := byPriority[class.Priority]
+		if !ok {
+			level = &priorityLevel{priority: class.Priority}
+			byPriority[class.Priority] = level
+			q.levels = append(q.levels, level)
+		}
+		level.classes = append(level.classes, class)
+	}
+	sort.Slice(q.levels, func(i, j int) bool { return q.levels[i].priority > q.levels[j].priority })
+	for _, level := range q.levels {
+		sort.Slice(level.classes, func(i, j int) bool { return level.classes[i].Name < level.classes[j].Name })
+		level.classes[0].deficit = level.classes[0].Weight
+	}
+
+	return q
+}
+
+// addClass registra una clase normalizando peso y espera máxima
+func (q *FairQueue) addClass(class SchedulingClass) {
+	if class.Weight <= 0 {
+		class.Weight = 1
+	}
+	if class.MaxWait <= 0 {
+		class.MaxWait = defaultMaxClassWait
+	}
+	q.classes[class.Name] = &fairClass{
+		SchedulingClass: class,
+		buffer:          make([]fairItem, q.capacity),
+	}
+}
+
+// Enqueue agrega un elemento en la cola de su clase; retorna false si está llena o cerrada
+func (q *FairQueue) Enqueue(item interface{}) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || q.count >= q.capacity {
+		return false
+	}
+
+	class := q.classes[DefaultClass]
+	if c, ok := item.(classified); ok {
+		if named, ok := q.classes[c.SchedulingClass()]; ok {
+			class = named
+		}
+	}
+
+	class.push(fairItem{value: item, enqueued: q.now()})
+	q.count++
+	q.ready <- struct{}{}
+	return true
+}
+
+// Dequeue espera hasta que haya un elemento y retorna el que corresponde según
+// prioridad, peso y antigüedad. Retorna false si el contexto se cancela, o si la
+// cola se cerró y ya no quedan elementos.
+func (q *FairQueue) Dequeue(ctx context.Context) (interface{}, bool) {
+	select {
+	case <-q.ready:
+		return q.pop(), true
+	case <-ctx.Done():
+		return nil, false
+	case <-q.done:
+		select {
+		case <-q.ready:
+			return q.pop(), true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// pop saca el próximo elemento; el llamador ya consumió su token
+func (q *FairQueue) pop() interface{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	class := q.nextClassLocked(now)
+	q.count--
+	return class.pop(now)
+}
+
+// nextClassLocked elige la clase a atender. Requiere q.mu y al menos un elemento.
+func (q *FairQueue) nextClassLocked(now time.Time) *fairClass {
+	// Aging: la tarea más vieja entre las que superaron el MaxWait de su clase
+	var oldest *fairClass
+	for _, class := range q.classes {
+		if class.count == 0 || now.Sub(class.buffer[class.head].enqueued) < class.MaxWait {
+			continue
+		}
+		if oldest == nil || class.buffer[class.head].enqueued.Before(oldest.buffer[oldest.head].enqueued) {
+			oldest = class
+		}
+	}
+	if oldest != nil {
+		oldest.aged++
+		return oldest
+	}
+
+	// Nivel de mayor prioridad con elementos
+	var level *priorityLevel
+	for _, l := range q.levels {
+		for _, class := range l.classes {
+			if class.count > 0 {
+				level = l
+				break
+			}
+		}
+		if level != nil {
+			break
+		}
+	}
+
+	// Deficit round robin dentro del nivel: cada clase atiende hasta Weight tareas por turno
+	for {
+		class := level.classes[level.cursor]
+		if class.count > 0 && class.deficit > 0 {
+			class.deficit--
+			return class
+		}
+		if class.count == 0 {
+			class.deficit = 0 // Una clase vacía no acumula créditos
+		}
+		level.cursor = (level.cursor + 1) % len(level.classes)
+		next := level.classes[level.cursor]
+		next.deficit += next.Weight
+	}
+}
+
+// Size retorna la cantidad total de elementos en cola
+func (q *FairQueue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(q.count)
+}
+
+// Capacity retorna la capacidad máxima de la cola (compartida por todas las clases)
+func (q *FairQueue) Capacity() int64 {
+	return int64(q.capacity)
+}
+
+// Close cierra la cola: rechaza nuevos elementos y despierta a todos los Dequeue bloqueados
+func (q *FairQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+}
+
+// ClassStats retorna, por clase, su configuración, ocupación y espera en cola
+func (q *FairQueue) ClassStats() map[string]interface{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := make(map[string]interface{}, len(q.classes))
+	for name, class := range q.classes {
+		avgWait := 0.0
+		if class.dequeued > 0 {
+			avgWait = float64(class.totalWait.Microseconds()) / float64(class.dequeued) / 1000
+		}
+		stats[name] = map[string]interface{}{
+			"weight":      class.Weight,
+			"priority":    class.Priority,
+			"queued":      class.count,
+			"enqueued":    class.enqueued,
+			"dequeued":    class.dequeued,
+			"aged":        class.aged,
+			"avg_wait_ms": math.Round(avgWait*100) / 100,
+			"max_wait_ms": math.Round(float64(class.maxWait.Microseconds())/10) / 100,
+		}
+	}
+	return stats
+}
+
+// SetScheduling reemplaza la cola FIFO del pool por una FairQueue con las clases dadas,
+// conservando su capacidad. Debe llamarse antes de Start: falla si el pool ya arrancó, si su
+// cola no es la FIFO por defecto (p. ej. work stealing) o si tiene tareas que quedarían varadas.
+func (s *Server) SetScheduling(pool string, classes ...SchedulingClass) error {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+
+	bh, ok := s.bulkheads[pool]
+	if !ok {
+		return fmt.Errorf("pool %q no existe", pool)
+	}
+	queue, ok := bh.queue.(*TaskQueue)
+	if !ok {
+		return fmt.Errorf("pool %q no usa la cola FIFO por defecto (%T)", pool, bh.queue)
+	}
+	if size := queue.Size(); size > 0 {
+		return fmt.Errorf("pool %q tiene %d tareas en cola", pool, size)
+	}
+	bh.pool.mu.Lock()
+	started := bh.pool.started
+	bh.pool.mu.Unlock()
+	if started {
+		return fmt.Errorf("pool %q ya está atendiendo", pool)
+	}
+
+	bh.queue = NewFairQueue(int(queue.Capacity()), classes...)
+	return nil
+}
+
+// AssignClass asigna las rutas que cubre el patrón a una clase de planificación.
+// En pools sin esa clase (o con cola FIFO) la asignación no tiene efecto.
+func (s *Server) AssignClass(method, pattern, class string) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	s.classRoutes.add(method, pattern, class)
+}
+
+// classFor retorna la clase de planificación de la petición
+func (s *Server) classFor(req *HTTPRequest) string {
+	s.poolMu.RLock()
+	defer s.poolMu.RUnlock()
+	if class, ok := s.classRoutes.lookup(req.Method, req.Path); ok {
+		return class
+	}
+	return DefaultClass
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type classedTask struct {
+	class string
+	id    int
+}
+
+func (t classedTask) SchedulingClass() string { return t.class }
+
+func drain(t *testing.T, q *FairQueue, n int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		item, ok := q.Dequeue(context.Background())
+		if !ok {
+			t.Fatalf("Dequeue %d failed", i)
+		}
+		counts[item.(classedTask).class]++
+	}
+	return counts
+}
+
+func TestFairQueueWeights(t *testing.T) {
+	q := NewFairQueue(1000,
+		SchedulingClass{Name: "light", Weight: 3},
+		SchedulingClass{Name: "heavy", Weight: 1},
+	)
+	for i := 0; i < 200; i++ {
+		q.Enqueue(classedTask{"light", i})
+		q.Enqueue(classedTask{"heavy", i})
+	}
+
+	counts := drain(t, q, 100)
+	if counts["light"] != 75 || counts["heavy"] != 25 {
+		t.Errorf("Expected a 3:1 split, got %v", counts)
+	}
+}
+
+func TestFairQueuePriorityAndFIFO(t *testing.T) {
+	q := NewFairQueue(100,
+		SchedulingClass{Name: "control", Priority: 10},
+		SchedulingClass{Name: "compute", Priority: 0},
+	)
+	for i := 0; i < 5; i++ {
+		q.Enqueue(classedTask{"compute", i})
+	}
+	for i := 0; i < 3; i++ {
+		q.Enqueue(classedTask{"control", i})
+	}
+	q.Enqueue("sin clase") // Va a DefaultClass
+
+	// Primero todo control (en orden), después compute y default por turnos
+	for i := 0; i < 3; i++ {
+		item, _ := q.Dequeue(context.Background())
+		if task := item.(classedTask); task.class != "control" || task.id != i {
+			t.Fatalf("Expected control %d, got %v", i, task)
+		}
+	}
+	rest := make(map[string]int)
+	next := 0
+	for i := 0; i < 6; i++ {
+		item, _ := q.Dequeue(context.Background())
+		if task, ok := item.(classedTask); ok {
+			if task.id != next {
+				t.Errorf("Expected compute %d, got %d", next, task.id)
+			}
+			next++
+			rest[task.class]++
+		} else {
+			rest[DefaultClass]++
+		}
+	}
+	if rest["compute"] != 5 || rest[DefaultClass] != 1 {
+		t.Errorf("Unexpected remaining classes: %v", rest)
+	}
+}
+
+func TestFairQueueAgingPreventsStarvation(t *testing.T) {
+	now := time.Now()
+	q := NewFairQueue(100,
+		SchedulingClass{Name: "control", Priority: 10, MaxWait: 10 * time.Second},
+		SchedulingClass{Name: "compute", Priority: 0, MaxWait: time.Second},
+	)
+	q.now = func() time.Time { return now }
+
+	q.Enqueue(classedTask{"compute", 0})
+	for i := 0; i < 10; i++ {
+		q.Enqueue(classedTask{"control", i})
+	}
+
+	// Antes del MaxWait gana la prioridad
+	item, _ := q.Dequeue(context.Background())
+	if item.(classedTask).class != "control" {
+		t.Fatalf("Expected control first, got %v", item)
+	}
+
+	// Superado el MaxWait, compute se atiende aunque control siga teniendo tareas
+	now = now.Add(2 * time.Second)
+	item, _ = q.Dequeue(context.Background())
+	if item.(classedTask).class != "compute" {
+		t.Fatalf("Expected aged compute task, got %v", item)
+	}
+
+	stats := q.ClassStats()["compute"].(map[string]interface{})
+	if stats["aged"].(int64) != 1 || stats["max_wait_ms"].(float64) != 2000 {
+		t.Errorf("Unexpected compute stats: %v", stats)
+	}
+}
+
+func TestFairQueueCapacityAndClose(t *testing.T) {
+	q := NewFairQueue(2, SchedulingClass{Name: "a"})
+	if !q.Enqueue(classedTask{"a", 0}) || !q.Enqueue(classedTask{DefaultClass, 1}) {
+		t.Fatal("Enqueue failed below capacity")
+	}
+	if q.Enqueue(classedTask{"a", 2}) {
+		t.Error("Enqueue should fail when the shared capacity is exhausted")
+	}
+
+	q.Close()
+	if got := len(drain(t, q, 2)); got == 0 {
+		t.Error("Expected pending items to be delivered after Close")
+	}
+	if _, ok := q.Dequeue(context.Background()); ok {
+		t.Error("Dequeue should fail on a closed and empty queue")
+	}
+}
+
+func TestServerSchedulingClasses(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 1)
+	srv.jobManager.persistenceFile = ""
+	if err := srv.SetScheduling("missing"); err == nil {
+		t.Error("SetScheduling should fail for an unknown pool")
+	}
+	if err := srv.SetScheduling(DefaultPool, SchedulingClass{Name: "control", Priority: 1}); err != nil {
+		t.Fatalf("SetScheduling failed: %v", err)
+	}
+	srv.AssignClass("GET", "/ping", "control")
+
+	// Solo se reemplaza una cola FIFO vacía de un pool que no arrancó
+	if err := srv.SetScheduling(DefaultPool); err == nil {
+		t.Error("SetScheduling should not replace a FairQueue")
+	}
+	srv.AddPool("stealing", WorkerPoolConfig{MinWorkers: 2, Scheduler: SchedulerWorkStealing}, 10)
+	if err := srv.SetScheduling("stealing"); err == nil {
+		t.Error("SetScheduling should not replace a work-stealing queue")
+	}
+	srv.AddPool("busy", WorkerPoolConfig{MinWorkers: 1}, 10)
+	srv.bulkheads["busy"].queue.Enqueue("pending")
+	if err := srv.SetScheduling("busy"); err == nil {
+		t.Error("SetScheduling should not strand queued tasks")
+	}
+
+	if got := srv.classFor(&HTTPRequest{Method: "GET", Path: "/ping"}); got != "control" {
+		t.Errorf("Expected control class, got %s", got)
+	}
+	if got := srv.classFor(&HTTPRequest{Method: "POST", Path: "/ping"}); got != DefaultClass {
+		t.Errorf("Expected default class for another method, got %s", got)
+	}
+	if _, ok := srv.poolStats()[DefaultPool].(map[string]interface{})["classes"]; !ok {
+		t.Error("Expected class stats for a pool with fair scheduling")
+	}
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Estados de un health check y del reporte completo
+const (
+	HealthPass = "pass"
+	HealthWarn = "warn" // Falló un check no crítico: el servidor sigue listo
+	HealthFail = "fail"
+)
+
+// HealthCheckFunc ejecuta un check; retorna detalles opcionales y un error si falla.
+// Debe respetar ctx, que vence al cumplirse el Timeout del check.
+type HealthCheckFunc func(ctx context.Context) (map[string]interface{}, error)
+
+// HealthCheck es un check con nombre registrado en el HealthRegistry
+type HealthCheck struct {
+	Name     string
+	Check    HealthCheckFunc
+	Timeout  time.Duration // Por defecto DefaultHealthTimeout
+	CacheTTL time.Duration // Cuánto se reutiliza el último resultado; 0 = se ejecuta siempre
+	Liveness bool          // También cuenta para /healthz (por defecto solo para /readyz)
+	Critical bool          // Si falla, el reporte falla; si no, solo queda en warn
+}
+
+// DefaultHealthTimeout es el timeout de un check que no define el suyo
+const DefaultHealthTimeout = 2 * time.Second
+
+// HealthResult es el resultado de un check
+type HealthResult struct {
+	Status     string                 `json:"status"`
+	Critical   bool                   `json:"critical"`
+	DurationMs float64                `json:"duration_ms"`
+	CheckedAt  time.Time              `json:"checked_at"`
+	Cached     bool                   `json:"cached"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport es el resultado combinado de los checks de /healthz o /readyz
+type HealthReport struct {
+	Status string                  `json:"status"`
+	Checks map[string]HealthResult `json:"checks"`
+}
+
+// Healthy indica si el reporte no tiene checks críticos fallidos
+func (r HealthReport) Healthy() bool {
+	return r.Status != HealthFail
+}
+
+// healthEntry guarda un check y su último resultado
+type healthEntry struct {
+	check HealthCheck
+	mu    sync.Mutex // Serializa ejecuciones: peticiones simultáneas comparten el resultado
+	last  *HealthResult
+}
+
+// HealthRegistry mantiene los checks con nombre y ejecuta los de liveness o readiness
+type HealthRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]*healthEntry
+	now     func() time.Time
+}
+
+// NewHealthRegistry crea un registro vacío
+func NewHealthRegistry() *HealthRegistry {
+	return &HealthRegistry{
+		entries: make(map[string]*healthEntry),
+		now:     time.Now,
+	}
+}
+
+// Register agrega (o reemplaza) un check
+func (hr *HealthRegistry) Register(check HealthCheck) {
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultHealthTimeout
+	}
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.entries[check.Name] = &healthEntry{check: check}
+}
+
+// Names retorna los nombres de los checks registrados, ordenados
+func (hr *HealthRegistry) Names() []string {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	names := make([]string, 0, len(hr.entries))
+	for name := range hr.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run ejecuta en paralelo los checks de liveness (livenessOnly) o todos (readiness)
+func (hr *HealthRegistry) Run(ctx context.Context, livenessOnly bool) HealthReport {
+	hr.mu.RLock()
+	var entries []*healthEntry
+	for _, entry := range hr.entries {
+		if !livenessOnly || entry.check.Liveness {
+			entries = append(entries, entry)
+		}
+	}
+	hr.mu.RUnlock()
+
+	results := make([]HealthResult, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func(i int, entry *healthEntry) {
+			defer wg.Done()
+			results[i] = hr.runEntry(ctx, entry)
+		}(i, entry)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: HealthPass, Checks: make(map[string]HealthResult, len(entries))}
+	for i, entry := range entries {
+		result := results[i]
+		report.Checks[entry.check.Name] = result
+		switch {
+		case result.Status == HealthFail:
+			report.Status = HealthFail
+		case result.Status == HealthWarn && report.Status == HealthPass:
+			report.Status = HealthWarn
+		}
+	}
+	return report
+}
+
+// runEntry retorna el resultado cacheado si sigue vigente o ejecuta el check con su timeout
+func (hr *HealthRegistry) runEntry(ctx context.Context, entry *healthEntry) HealthResult {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	check := entry.check
+	now := hr.now()
+	if entry.last != nil && check.CacheTTL > 0 && now.Sub(entry.last.CheckedAt) < check.CacheTTL {
+		cached := *entry.last
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		details, err := check.Check(checkCtx)
+		done <- outcome{details, err}
+	}()
+
+	// Un check que no respeta ctx no bloquea el reporte más allá del timeout
+	var out outcome
+	select {
+	case out = <-done:
+	case <-checkCtx.Done():
+		out = outcome{err: fmt.Errorf("timed out after %s", check.Timeout)}
+	}
+
+	result := HealthResult{
+		Status:     HealthPass,
+		Critical:   check.Critical,
+		DurationMs: durationMs(time.Since(start)),
+		CheckedAt:  now,
+		Details:    out.details,
+	}
+	if out.err != nil {
+		result.Error = out.err.Error()
+		result.Status = HealthWarn
+		if check.Critical {
+			result.Status = HealthFail
+		}
+	}
+	entry.last = &result
+	return result
+}
+
+// SaturationThreshold es la ocupación de cola a partir de la cual un pool no está listo
+const SaturationThreshold = 0.9
+
+// RegisterHealthCheck agrega un check al registro de /healthz y /readyz
+func (s *Server) RegisterHealthCheck(check HealthCheck) {
+	s.health.Register(check)
+}
+
+// Liveness ejecuta los checks de liveness: el proceso funciona aunque no deba recibir tráfico
+func (s *Server) Liveness(ctx context.Context) HealthReport {
+	return s.health.Run(ctx, true)
+}
+
+// Readiness ejecuta todos los checks; falla durante el shutdown
+func (s *Server) Readiness(ctx context.Context) HealthReport {
+	return s.health.Run(ctx, false)
+}
+
+// registerBuiltinChecks registra los checks propios del servidor
+func (s *Server) registerBuiltinChecks() {
+	s.health.Register(HealthCheck{
+		Name:     "shutdown",
+		Critical: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			if s.isDraining() {
+				return nil, errors.New("server is shutting down")
+			}
+			return nil, nil
+		},
+	})
+
+	s.health.Register(HealthCheck{
+		Name:     "queue_saturation",
+		Critical: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			details := make(map[string]interface{})
+			var saturated []string
+			s.eachBulkhead(func(bh *Bulkhead) {
+				capacity := bh.queue.Capacity()
+				if capacity <= 0 {
+					return
+				}
+				fill := float64(bh.queue.Size()) / float64(capacity)
+				details[bh.name] = math.Round(fill*1000) / 1000
+				if fill >= SaturationThreshold {
+					saturated = append(saturated, bh.name)
+				}
+			})
+			if len(saturated) > 0 {
+				sort.Strings(saturated)
+				return details, fmt.Errorf("queues saturated: %v", saturated)
+			}
+			return details, nil
+		},
+	})
+
+	s.health.Register(HealthCheck{
+		Name:     "job_manager",
+		Critical: true,
+		Liveness: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			// El loop da una vuelta cada 100ms; 5s sin avanzar es que terminó o está bloqueado
+			silence := time.Since(s.jobManager.LastTick())
+			details := map[string]interface{}{"last_tick_ms": durationMs(silence)}
+			if silence > 5*time.Second {
+				return details, fmt.Errorf("processing loop stalled for %s", silence.Round(time.Millisecond))
+			}
+			return details, nil
+		},
+	})
+}
+
+// DiskWritableCheck verifica que se pueda crear, escribir y borrar un archivo en dir
+func DiskWritableCheck(dir string) HealthCheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		details := map[string]interface{}{"dir": dir}
+		file, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return details, err
+		}
+		name := file.Name()
+		defer os.Remove(name)
+
+		if _, err := file.WriteString("ok"); err != nil {
+			file.Close()
+			return details, err
+		}
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return details, err
+		}
+		return details, file.Close()
+	}
+}
+
+// CommandCheck verifica que un ejecutable externo esté en el PATH
+func CommandCheck(name string) HealthCheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return map[string]interface{}{"command": name}, err
+		}
+		return map[string]interface{}{"command": name, "path": path}, nil
+	}
+}
+
+// FileDirWritableCheck verifica que se pueda escribir en el directorio de un archivo (p. ej. jobs.json)
+func FileDirWritableCheck(file string) HealthCheckFunc {
+	dir := filepath.Dir(file)
+	check := DiskWritableCheck(dir)
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		details, err := check(ctx)
+		details["file"] = file
+		return details, err
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthRegistryStatuses(t *testing.T) {
+	hr := NewHealthRegistry()
+	hr.Register(HealthCheck{Name: "ok", Liveness: true, Critical: true, Check: func(ctx context.Context) (map[string]interface{}, error) {
+		return map[string]interface{}{"n": 1}, nil
+	}})
+	hr.Register(HealthCheck{Name: "optional", Check: func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, errors.New("missing")
+	}})
+
+	report := hr.Run(context.Background(), false)
+	if report.Status != HealthWarn || !report.Healthy() {
+		t.Errorf("Expected warn for a non-critical failure, got %s", report.Status)
+	}
+	if report.Checks["optional"].Error != "missing" || report.Checks["ok"].Details["n"] != 1 {
+		t.Errorf("Unexpected results: %+v", report.Checks)
+	}
+
+	hr.Register(HealthCheck{Name: "critical", Critical: true, Check: func(ctx context.Context) (map[string]interface{}, error) {
+		panic("boom")
+	}})
+	if report := hr.Run(context.Background(), false); report.Status != HealthFail || report.Healthy() {
+		t.Errorf("Expected fail for a panicking critical check, got %s", report.Status)
+	}
+
+	// Liveness solo ejecuta los checks marcados
+	if report := hr.Run(context.Background(), true); report.Status != HealthPass || len(report.Checks) != 1 {
+		t.Errorf("Expected only the liveness check, got %+v", report)
+	}
+}
+
+func TestHealthCheckTimeoutAndCache(t *testing.T) {
+	hr := NewHealthRegistry()
+	var calls int64
+	hr.Register(HealthCheck{Name: "slow", Critical: true, Timeout: 50 * time.Millisecond, Check: func(ctx context.Context) (map[string]interface{}, error) {
+		atomic.AddInt64(&calls, 1)
+		time.Sleep(time.Second) // Ignora ctx a propósito
+		return nil, nil
+	}})
+	hr.Register(HealthCheck{Name: "cached", CacheTTL: time.Minute, Check: func(ctx context.Context) (map[string]interface{}, error) {
+		atomic.AddInt64(&calls, 100)
+		return nil, nil
+	}})
+
+	start := time.Now()
+	report := hr.Run(context.Background(), false)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run should not wait for a check past its timeout, took %v", elapsed)
+	}
+	if report.Checks["slow"].Status != HealthFail {
+		t.Errorf("Expected timed out check to fail, got %+v", report.Checks["slow"])
+	}
+
+	report = hr.Run(context.Background(), false)
+	if !report.Checks["cached"].Cached || report.Checks["slow"].Cached {
+		t.Errorf("Expected only the check with CacheTTL to be cached: %+v", report.Checks)
+	}
+	if n := atomic.LoadInt64(&calls); n != 102 {
+		t.Errorf("Expected cached check to run once (calls=102), got %d", n)
+	}
+}
+
+func TestServerReadiness(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 1)
+	srv.jobManager.persistenceFile = ""
+	srv.AddPool("tiny", WorkerPoolConfig{MinWorkers: 1, MaxWorkers: 1}, 10)
+
+	if report := srv.Readiness(context.Background()); report.Status != HealthPass {
+		t.Fatalf("Expected ready server, got %+v", report)
+	}
+
+	// Una cola al 90% deja de estar lista
+	queue := srv.bulkheads["tiny"].queue
+	for i := 0; i < 9; i++ {
+		queue.Enqueue(i)
+	}
+	report := srv.Readiness(context.Background())
+	if report.Checks["queue_saturation"].Status != HealthFail {
+		t.Errorf("Expected saturated queue to fail readiness, got %+v", report.Checks["queue_saturation"])
+	}
+	// La saturación no afecta a liveness
+	if report := srv.Liveness(context.Background()); report.Status != HealthPass || len(report.Checks) != 1 {
+		t.Errorf("Expected live server with only job_manager, got %+v", report)
+	}
+}
+
+func TestShutdownDrainsBeforeClosing(t *testing.T) {
+	const grace = 400 * time.Millisecond
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.SetShutdownGrace(grace)
+	srv.HandleFunc("GET", "/readyz", func(req *HTTPRequest) *HTTPResponse {
+		if report := srv.Readiness(req.Context()); !report.Healthy() {
+			return ServiceUnavailable(report.Checks["shutdown"].Error, 0)
+		}
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+	})
+	srv.HandleFunc("GET", "/work", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	addr := srv.Addr()
+
+	if code, _ := sendRaw(t, addr, "GET /readyz HTTP/1.1\r\nHost: test\r\n\r\n"); code != 200 {
+		t.Fatalf("Expected ready server, got %d", code)
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	// Durante la gracia el balanceador ve /readyz en 503 y las peticiones se siguen atendiendo
+	deadline := time.Now().Add(grace / 2)
+	code := 200
+	for code == 200 && time.Now().Before(deadline) {
+		code, _ = sendRaw(t, addr, "GET /readyz HTTP/1.1\r\nHost: test\r\n\r\n")
+	}
+	if code != 503 {
+		t.Fatalf("Expected /readyz to return 503 while draining, got %d", code)
+	}
+	if code, _ := sendRaw(t, addr, "GET /work HTTP/1.1\r\nHost: test\r\n\r\n"); code != 200 {
+		t.Errorf("Expected requests to be served while draining, got %d", code)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < grace {
+		t.Errorf("Listener closed after %v, before the %v grace period", elapsed, grace)
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Error("Expected connections to be refused after the grace period")
+	}
+}
+
+func TestDiskAndCommandChecks(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := DiskWritableCheck(dir)(context.Background()); err != nil {
+		t.Errorf("Expected writable temp dir, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Check should clean up its probe file, found %d entries", len(entries))
+	}
+	if _, err := DiskWritableCheck(filepath.Join(dir, "missing"))(context.Background()); err == nil {
+		t.Error("Expected error for a missing directory")
+	}
+	if _, err := CommandCheck("definitely-not-a-command-xyz")(context.Background()); err == nil {
+		t.Error("Expected error for a missing command")
+	}
+	if details, err := CommandCheck("go")(context.Background()); err != nil || details["path"] == "" {
+		t.Errorf("Expected go in PATH, got %v, %v", details, err)
+	}
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Buckets log-lineales: los valores (en µs) menores que histSub tienen un bucket cada uno;
+// desde ahí cada potencia de dos se divide en histSub buckets iguales, así que el error
+// relativo de un cuantil es a lo sumo 1/(2*histSub) ≈ 3%.
+const (
+	histSubBits = 4
+	histSub     = 1 << histSubBits
+	histMaxBits = 36 // Valores hasta 2^36 µs (~19 horas); los mayores caen en el último bucket
+	histBuckets = histSub + (histMaxBits-histSubBits)*histSub
+)
+
+// histIndex retorna el bucket de un valor en µs
+func histIndex(v uint64) int {
+	if v < histSub {
+		return int(v)
+	}
+	if v >= 1<<histMaxBits {
+		return histBuckets - 1
+	}
+	shift := bits.Len64(v) - 1 - histSubBits
+	return histSub + shift*histSub + int(v>>uint(shift)) - histSub
+}
+
+// histBucketRange retorna el menor valor y el ancho del bucket
+func histBucketRange(i int) (low, width uint64) {
+	if i < histSub {
+		return uint64(i), 1
+	}
+	shift := uint((i - histSub) / histSub)
+	sub := uint64((i - histSub) % histSub)
+	return (histSub + sub) << shift, 1 << shift
+}
+
+// histValue retorna el valor representativo (punto medio) del bucket
+func histValue(i int) float64 {
+	low, width := histBucketRange(i)
+	return float64(low) + float64(width-1)/2
+}
+
+// Histogram es un histograma de latencias con resolución de microsegundos y memoria
+// constante. Record es lock-free (solo operaciones atómicas).
+type Histogram struct {
+	counts [histBuckets]uint32
+	count  uint64
+	sum    uint64 // µs
+	min    uint64 // µs; math.MaxUint64 sin observaciones
+	max    uint64 // µs
+}
+
+// NewHistogram crea un histograma vacío
+func NewHistogram() *Histogram {
+	return &Histogram{min: math.MaxUint64}
+}
+
+// Record registra una duración
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	v := uint64(d / time.Microsecond)
+
+	atomic.AddUint32(&h.counts[histIndex(v)], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, v)
+	for {
+		cur := atomic.LoadUint64(&h.min)
+		if v >= cur || atomic.CompareAndSwapUint64(&h.min, cur, v) {
+			break
+		}
+	}
+	for {
+		cur := atomic.LoadUint64(&h.max)
+		if v <= cur || atomic.CompareAndSwapUint64(&h.max, cur, v) {
+			break
+		}
+	}
+}
+
+// reset vacía el histograma; no es atómico respecto de Record concurrentes
+func (h *Histogram) reset() {
+	for i := range h.counts {
+		atomic.StoreUint32(&h.counts[i], 0)
+	}
+	atomic.StoreUint64(&h.count, 0)
+	atomic.StoreUint64(&h.sum, 0)
+	atomic.StoreUint64(&h.min, math.MaxUint64)
+	atomic.StoreUint64(&h.max, 0)
+}
+
+// Snapshot retorna una copia del histograma
+func (h *Histogram) Snapshot() *HistogramSnapshot {
+	s := newHistogramSnapshot()
+	s.add(h)
+	return s
+}
+
+// HistogramSnapshot es una copia inmutable de uno o varios histogramas combinados
+type HistogramSnapshot struct {
+	Counts []uint64
+	Count  uint64
+	Sum    uint64 // µs
+	Min    uint64 // µs
+	Max    uint64 // µs
+}
+
+func newHistogramSnapshot() *HistogramSnapshot {
+	return &HistogramSnapshot{Counts: make([]uint64, histBuckets), Min: math.MaxUint64}
+}
+
+// add suma las observaciones de un histograma al snapshot
+func (s *HistogramSnapshot) add(h *Histogram) {
+	for i := range h.counts {
+		s.Counts[i] += uint64(atomic.LoadUint32(&h.counts[i]))
+	}
+	s.Count += atomic.LoadUint64(&h.count)
+	s.Sum += atomic.LoadUint64(&h.sum)
+	if min := atomic.LoadUint64(&h.min); min < s.Min {
+		s.Min = min
+	}
+	if max := atomic.LoadUint64(&h.max); max > s.Max {
+		s.Max = max
+	}
+}
+
+// Merge combina otro snapshot con este (los histogramas son mergeables bucket a bucket)
+func (s *HistogramSnapshot) Merge(other *HistogramSnapshot) {
+	for i, c := range other.Counts {
+		s.Counts[i] += c
+	}
+	s.Count += other.Count
+	s.Sum += other.Sum
+	if other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if other.Max > s.Max {
+		s.Max = other.Max
+	}
+}
+
+// Quantile retorna el cuantil q (0..1); 0 si no hay observaciones
+func (s *HistogramSnapshot) Quantile(q float64) time.Duration {
+	total := uint64(0)
+	for _, c := range s.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	seen := uint64(0)
+	for i, c := range s.Counts {
+		seen += c
+		if seen >= rank {
+			// El valor representativo nunca sale del rango observado
+			v := math.Min(math.Max(histValue(i), float64(s.Min)), float64(s.Max))
+			return time.Duration(v * float64(time.Microsecond))
+		}
+	}
+	return time.Duration(s.Max) * time.Microsecond
+}
+
+// Mean retorna el promedio exacto
+func (s *HistogramSnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return time.Duration(float64(s.Sum) / float64(s.Count) * float64(time.Microsecond))
+}
+
+// StdDev estima el desvío estándar a partir de los buckets
+func (s *HistogramSnapshot) StdDev() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	mean := float64(s.Sum) / float64(s.Count)
+	variance := 0.0
+	total := uint64(0)
+	for i, c := range s.Counts {
+		if c == 0 {
+			continue
+		}
+		diff := histValue(i) - mean
+		variance += diff * diff * float64(c)
+		total += c
+	}
+	return time.Duration(math.Sqrt(variance/float64(total)) * float64(time.Microsecond))
+}
+
+// MinDuration retorna la menor observación (0 si no hay)
+func (s *HistogramSnapshot) MinDuration() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return time.Duration(s.Min) * time.Microsecond
+}
+
+// MaxDuration retorna la mayor observación
+func (s *HistogramSnapshot) MaxDuration() time.Duration {
+	return time.Duration(s.Max) * time.Microsecond
+}
+
+// HistogramWindowConfig define la ventana deslizante: Slots intervalos de Slot cada uno
+type HistogramWindowConfig struct {
+	Slot  time.Duration
+	Slots int
+}
+
+// DefaultHistogramWindowConfig cubre 15 minutos en intervalos de 15 segundos
+func DefaultHistogramWindowConfig() HistogramWindowConfig {
+	return HistogramWindowConfig{Slot: 15 * time.Second, Slots: 60}
+}
+
+// histSlot es el histograma de un intervalo; epoch identifica el intervalo que contiene.
+// El histograma se asigna al usarse por primera vez, así un endpoint ocioso casi no ocupa memoria.
+type histSlot struct {
+	epoch int64 // (atómico)
+	hist  atomic.Pointer[Histogram]
+}
+
+// WindowedHistogram mantiene un histograma por intervalo en un ring, para consultar
+// cuantiles sobre los últimos N minutos. Record es lock-free salvo la primera
+// observación de cada intervalo, que recicla el slot más viejo bajo un mutex.
+type WindowedHistogram struct {
+	config HistogramWindowConfig
+	slots  []histSlot
+	rotate sync.Mutex
+	now    func() time.Time
+}
+
+// NewWindowedHistogram crea un histograma con ventana deslizante
+func NewWindowedHistogram(config HistogramWindowConfig) *WindowedHistogram {
+	if config.Slot <= 0 || config.Slots <= 0 {
+		config = DefaultHistogramWindowConfig()
+	}
+	w := &WindowedHistogram{
+		config: config,
+		slots:  make([]histSlot, config.Slots),
+		now:    time.Now,
+	}
+	for i := range w.slots {
+		w.slots[i].epoch = -1
+	}
+	return w
+}
+
+// epoch retorna el número de intervalo de un instante
+func (w *WindowedHistogram) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(w.config.Slot)
+}
+
+// Record registra una duración en el intervalo actual
+func (w *WindowedHistogram) Record(d time.Duration) {
+	epoch := w.epoch(w.now())
+	slot := &w.slots[epoch%int64(len(w.slots))]
+
+	if atomic.LoadInt64(&slot.epoch) != epoch {
+		w.rotate.Lock()
+		if atomic.LoadInt64(&slot.epoch) != epoch {
+			if hist := slot.hist.Load(); hist != nil {
+				hist.reset()
+			} else {
+				slot.hist.Store(NewHistogram())
+			}
+			atomic.StoreInt64(&slot.epoch, epoch)
+		}
+		w.rotate.Unlock()
+	}
+	slot.hist.Load().Record(d)
+}
+
+// Snapshot combina los intervalos que caen dentro de la ventana (acotada a la del ring)
+func (w *WindowedHistogram) Snapshot(window time.Duration) *HistogramSnapshot {
+	current := w.epoch(w.now())
+	n := int64((window + w.config.Slot - 1) / w.config.Slot)
+	if n < 1 {
+		n = 1
+	}
+	if n > int64(len(w.slots)) {
+		n = int64(len(w.slots))
+	}
+
+	snapshot := newHistogramSnapshot()
+	for i := range w.slots {
+		slot := &w.slots[i]
+		epoch := atomic.LoadInt64(&slot.epoch)
+		if hist := slot.hist.Load(); hist != nil && epoch > current-n && epoch <= current {
+			snapshot.add(hist)
+		}
+	}
+	return snapshot
+}
+
+// MaxWindow retorna la ventana más larga que se puede consultar
+func (w *WindowedHistogram) MaxWindow() time.Duration {
+	return w.config.Slot * time.Duration(len(w.slots))
+}
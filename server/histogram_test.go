@@ -0,0 +1,159 @@
+package server
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistogramBucketLayout(t *testing.T) {
+	// Los buckets son contiguos y cada valor cae dentro del rango de su bucket
+	next := uint64(0)
+	for i := 0; i < histBuckets-1; i++ {
+		low, width := histBucketRange(i)
+		if low != next {
+			t.Fatalf("Bucket %d starts at %d, expected %d", i, low, next)
+		}
+		next = low + width
+	}
+
+	for _, v := range []uint64{0, 1, 15, 16, 17, 31, 32, 100, 999, 1000, 123456, 1 << 30} {
+		i := histIndex(v)
+		low, width := histBucketRange(i)
+		if v < low || v >= low+width {
+			t.Errorf("Value %d mapped to bucket %d [%d, %d)", v, i, low, low+width)
+		}
+		if v >= histSub && float64(width)/float64(low) > 1.0/histSub {
+			t.Errorf("Bucket %d is too wide for value %d: width %d", i, v, width)
+		}
+	}
+}
+
+func TestHistogramQuantileAccuracy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := NewHistogram()
+	samples := make([]float64, 20000)
+	for i := range samples {
+		// Log-normal centrada en ~2ms: cubre desde decenas de µs hasta cientos de ms
+		us := math.Exp(rng.NormFloat64()*1.5 + math.Log(2000))
+		samples[i] = math.Floor(us)
+		h.Record(time.Duration(samples[i]) * time.Microsecond)
+	}
+	sort.Float64s(samples)
+
+	snapshot := h.Snapshot()
+	for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+		exact := samples[int(math.Ceil(q*float64(len(samples))))-1]
+		got := float64(snapshot.Quantile(q).Microseconds())
+		if math.Abs(got-exact)/exact > 0.035 {
+			t.Errorf("p%v: expected ~%.0fµs, got %.0fµs", q*100, exact, got)
+		}
+	}
+	if snapshot.Count != uint64(len(samples)) {
+		t.Errorf("Expected count %d, got %d", len(samples), snapshot.Count)
+	}
+	if snapshot.MinDuration() != time.Duration(samples[0])*time.Microsecond ||
+		snapshot.MaxDuration() != time.Duration(samples[len(samples)-1])*time.Microsecond {
+		t.Errorf("Min/max should be exact, got %v/%v", snapshot.MinDuration(), snapshot.MaxDuration())
+	}
+}
+
+func TestHistogramSubMillisecond(t *testing.T) {
+	h := NewHistogram()
+	for i := 0; i < 100; i++ {
+		h.Record(250 * time.Microsecond)
+	}
+	if p50 := durationMs(h.Snapshot().Quantile(0.5)); p50 != 0.25 {
+		t.Errorf("Expected p50 of 0.25ms, got %v", p50)
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, b := NewHistogram(), NewHistogram()
+	for i := 1; i <= 50; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+		b.Record(time.Duration(i+50) * time.Millisecond)
+	}
+
+	merged := a.Snapshot()
+	merged.Merge(b.Snapshot())
+	if merged.Count != 100 || merged.MinDuration() != time.Millisecond || merged.MaxDuration() != 100*time.Millisecond {
+		t.Errorf("Unexpected merged snapshot: count=%d min=%v max=%v", merged.Count, merged.MinDuration(), merged.MaxDuration())
+	}
+	if p50 := merged.Quantile(0.5); p50 < 48*time.Millisecond || p50 > 52*time.Millisecond {
+		t.Errorf("Expected merged p50 ~50ms, got %v", p50)
+	}
+}
+
+func TestWindowedHistogram(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := NewWindowedHistogram(HistogramWindowConfig{Slot: 10 * time.Second, Slots: 6})
+	w.now = func() time.Time { return now }
+
+	w.Record(100 * time.Millisecond) // t=0
+	now = now.Add(30 * time.Second)
+	w.Record(time.Millisecond) // t=30s
+
+	if s := w.Snapshot(10 * time.Second); s.Count != 1 || s.MaxDuration() != time.Millisecond {
+		t.Errorf("10s window should only contain the recent sample, got count=%d max=%v", s.Count, s.MaxDuration())
+	}
+	if s := w.Snapshot(time.Minute); s.Count != 2 {
+		t.Errorf("1m window should contain both samples, got %d", s.Count)
+	}
+
+	// Pasada la ventana completa, el slot viejo se recicla y sus datos desaparecen
+	now = now.Add(40 * time.Second)
+	w.Record(2 * time.Millisecond)
+	if s := w.Snapshot(time.Minute); s.Count != 2 || s.MaxDuration() != 2*time.Millisecond {
+		t.Errorf("Expected the t=0 sample to expire, got count=%d max=%v", s.Count, s.MaxDuration())
+	}
+	if w.MaxWindow() != time.Minute {
+		t.Errorf("Expected max window 1m, got %v", w.MaxWindow())
+	}
+}
+
+func TestWindowedHistogramConcurrentRecord(t *testing.T) {
+	w := NewWindowedHistogram(DefaultHistogramWindowConfig())
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				w.Record(time.Duration(i) * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s := w.Snapshot(time.Minute); s.Count != 8000 {
+		t.Errorf("Expected 8000 samples, got %d", s.Count)
+	}
+}
+
+func BenchmarkRequestMetricsRecord(b *testing.B) {
+	rm := NewRequestMetrics("GET /bench")
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		d := 250 * time.Microsecond
+		for pb.Next() {
+			rm.RecordWaitTime(d)
+			rm.RecordExecTime(d)
+		}
+	})
+}
+
+func BenchmarkRequestMetricsGetStats(b *testing.B) {
+	rm := NewRequestMetrics("GET /bench")
+	for i := 0; i < 1000; i++ {
+		rm.RecordExecTime(time.Duration(i) * time.Microsecond)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rm.GetStats()
+	}
+}
@@ -0,0 +1,535 @@
+package server
+
+import (
+	"container/heap"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Criterios de orden del listado; con el prefijo "-" el orden es descendente
+const (
+	JobSortCreated   = "created_at"
+	JobSortCompleted = "completed_at"
+	JobSortPriority  = "priority"
+)
+
+// JobSorts son los valores aceptados para ordenar el listado
+var JobSorts = []string{
+	"-" + JobSortCreated, JobSortCreated, "-" + JobSortCompleted, JobSortCompleted, "-" + JobSortPriority, JobSortPriority,
+}
+
+// JobStatuses son todos los estados posibles de un job
+var JobStatuses = []JobStatus{JobQueued, JobRunning, JobDone, JobError, JobCanceled, JobTimeout}
+
+// ErrInvalidCursor indica un cursor mal formado o emitido para otro orden
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// String retorna el nombre de la prioridad (low, normal, high)
+func (p JobPriority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// ParseJobPriority convierte un nombre de prioridad
+func ParseJobPriority(name string) (JobPriority, bool) {
+	switch name {
+	case "low":
+		return PriorityLow, true
+	case "normal":
+		return PriorityNormal, true
+	case "high":
+		return PriorityHigh, true
+	}
+	return PriorityNormal, false
+}
+
+// jobIndex mantiene índices de los jobs para listarlos sin recorrer el mapa completo.
+// Las listas están en orden de creación y solo crecen por append, así que un listado
+// puede quedarse con el slice sin copiarlo. Tiene su propio lock porque los cambios de
+// estado llegan desde los métodos de Job, a veces con JobManager.mu tomado. statusOf
+// permite leer el estado de un job sin tomar su lock.
+type jobIndex struct {
+	mu         sync.RWMutex
+	all        []*Job
+	byTask     map[string][]*Job
+	byOwner    map[string][]*Job
+	byPriority map[JobPriority][]*Job
+	byStatus   map[JobStatus]map[*Job]struct{}
+	statusOf   map[*Job]JobStatus
+}
+
+func newJobIndex() *jobIndex {
+	idx := &jobIndex{
+		byTask:     make(map[string][]*Job),
+		byOwner:    make(map[string][]*Job),
+		byPriority: make(map[JobPriority][]*Job),
+		byStatus:   make(map[JobStatus]map[*Job]struct{}),
+		statusOf:   make(map[*Job]JobStatus),
+	}
+	for _, status := range JobStatuses {
+		idx.byStatus[status] = make(map[*Job]struct{})
+	}
+	return idx
+}
+
+// add indexa un job nuevo y lo suscribe a sus cambios de estado
+func (idx *jobIndex) add(job *Job) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.all = appendOrdered(idx.all, job)
+	idx.byTask[job.Task] = appendOrdered(idx.byTask[job.Task], job)
+	idx.byOwner[job.Owner] = appendOrdered(idx.byOwner[job.Owner], job)
+	idx.byPriority[job.Priority] = appendOrdered(idx.byPriority[job.Priority], job)
+	if idx.byStatus[job.Status] == nil {
+		idx.byStatus[job.Status] = make(map[*Job]struct{})
+	}
+	idx.byStatus[job.Status][job] = struct{}{}
+	idx.statusOf[job] = job.Status
+	job.onStatus = idx.statusChanged
+}
+
+// statusChanged mueve el job al set de su nuevo estado
+func (idx *jobIndex) statusChanged(job *Job, from, to JobStatus) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.byStatus[from], job)
+	if idx.byStatus[to] == nil {
+		idx.byStatus[to] = make(map[*Job]struct{})
+	}
+	idx.byStatus[to][job] = struct{}{}
+	idx.statusOf[job] = to
+}
+
+// counts retorna la cantidad de jobs por estado
+func (idx *jobIndex) counts() map[JobStatus]int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.countsLocked()
+}
+
+// countsLocked es counts con idx.mu tomado
+func (idx *jobIndex) countsLocked() map[JobStatus]int {
+	counts := make(map[JobStatus]int, len(idx.byStatus))
+	for status, set := range idx.byStatus {
+		if len(set) > 0 {
+			counts[status] = len(set)
+		}
+	}
+	return counts
+}
+
+// createdBefore indica si a va antes que b en orden de creación
+func createdBefore(a, b *Job) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// appendOrdered agrega el job manteniendo el orden de creación. Si llega fuera de orden
+// (p. ej. un salto del reloj) arma un slice nuevo, para no mover elementos que un listado
+// en curso puede estar leyendo.
+func appendOrdered(list []*Job, job *Job) []*Job {
+	if len(list) == 0 || createdBefore(list[len(list)-1], job) {
+		return append(list, job)
+	}
+	i := sort.Search(len(list), func(i int) bool { return createdBefore(job, list[i]) })
+	out := make([]*Job, 0, len(list)+1)
+	out = append(out, list[:i]...)
+	out = append(out, job)
+	return append(out, list[i:]...)
+}
+
+// JobQuery son los filtros, el orden y la página de un listado de jobs. Los campos
+// vacíos no filtran; los de lista aceptan cualquiera de sus valores.
+type JobQuery struct {
+	Statuses        []JobStatus
+	Tasks           []string
+	Priorities      []JobPriority
+	Owner           string
+	OwnerSet        bool // Filtra por Owner aunque sea "" (jobs sin dueño)
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	CompletedAfter  time.Time
+	CompletedBefore time.Time
+	Sort            string // Uno de JobSorts; por defecto -created_at
+	Limit           int    // Por defecto 50
+	Cursor          string // next_cursor de la página anterior
+}
+
+// JobSummary es un job en el listado (sin el resultado, que puede ser grande)
+type JobSummary struct {
+	ID          string     `json:"job_id"`
+	Task        string     `json:"task"`
+	Status      JobStatus  `json:"status"`
+	Priority    string     `json:"priority"`
+	Owner       string     `json:"owner,omitempty"`
+	Progress    int        `json:"progress"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// JobPage es una página del listado. Total y Counts cubren todos los jobs que cumplen los
+// filtros, no solo la página; Counts ignora el filtro de estado para mostrar el reparto.
+type JobPage struct {
+	Jobs       []JobSummary      `json:"jobs"`
+	Count      int               `json:"count"`
+	Total      int               `json:"total"`
+	Counts     map[JobStatus]int `json:"counts"`
+	Sort       string            `json:"sort"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// jobCursor es la posición del último job de una página
+type jobCursor struct {
+	Sort    string `json:"s"`
+	Key     int64  `json:"k"`
+	Created int64  `json:"c"`
+	ID      string `json:"id"`
+}
+
+// jobEntry es un job candidato con el estado leído del índice y su clave de orden
+type jobEntry struct {
+	job       *Job
+	status    JobStatus
+	completed *time.Time
+	key       int64
+	created   int64
+}
+
+// ListJobs lista los jobs que cumplen la consulta. Recorre el índice más chico entre los
+// filtros (tarea, dueño, prioridad o estado) y solo toma el lock de los jobs cuando hace
+// falta completed_at y para armar la página; los jobs de la página se eligen sin ordenar
+// todos los que cumplen los filtros.
+func (jm *JobManager) ListJobs(q JobQuery) (JobPage, error) {
+	sortBy := q.Sort
+	if sortBy == "" {
+		sortBy = "-" + JobSortCreated
+	}
+	if !containsString(JobSorts, sortBy) {
+		return JobPage{}, errors.New("unknown sort " + sortBy)
+	}
+	desc := strings.HasPrefix(sortBy, "-")
+	field := strings.TrimPrefix(sortBy, "-")
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var after *jobCursor
+	if q.Cursor != "" {
+		cursor, err := decodeJobCursor(q.Cursor)
+		if err != nil || cursor.Sort != sortBy {
+			return JobPage{}, ErrInvalidCursor
+		}
+		after = cursor
+	}
+
+	statuses := make(map[JobStatus]bool, len(q.Statuses))
+	for _, status := range q.Statuses {
+		statuses[status] = true
+	}
+
+	entries, counts := jm.index.scan(q, statuses)
+
+	// completed_at cambia con el job: se lee con su lock, y solo si se filtra u ordena por él
+	filterCompleted := !q.CompletedAfter.IsZero() || !q.CompletedBefore.IsZero()
+	if filterCompleted || field == JobSortCompleted {
+		for i := range entries {
+			entries[i].completed = entries[i].job.completedAt()
+		}
+	}
+
+	matched := entries[:0]
+	for _, entry := range entries {
+		if filterCompleted && !q.completedMatches(entry.completed) {
+			continue
+		}
+		if counts != nil {
+			counts[entry.status]++
+		}
+		if len(statuses) > 0 && !statuses[entry.status] {
+			continue
+		}
+		entry.key = sortKey(entry, field)
+		matched = append(matched, entry)
+	}
+	if counts == nil {
+		counts = jm.index.counts()
+	}
+
+	page := JobPage{Counts: make(map[JobStatus]int), Sort: sortBy, Jobs: []JobSummary{}, Total: len(matched)}
+	for _, status := range JobStatuses {
+		page.Counts[status] = counts[status]
+	}
+
+	less := func(a, b jobEntry) bool {
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		if a.created != b.created {
+			return a.created < b.created
+		}
+		return a.job.ID < b.job.ID
+	}
+	before := func(a, b jobEntry) bool {
+		if desc {
+			return less(b, a)
+		}
+		return less(a, b)
+	}
+
+	// Descartar lo que ya se entregó en páginas anteriores
+	if after != nil {
+		pos := jobEntry{key: after.Key, created: after.Created, job: &Job{ID: after.ID}}
+		remaining := matched[:0]
+		for _, entry := range matched {
+			if before(pos, entry) {
+				remaining = append(remaining, entry)
+			}
+		}
+		matched = remaining
+	}
+
+	// Un elemento de más indica si hay página siguiente
+	selected := firstEntries(matched, limit+1, before)
+	hasMore := len(selected) > limit
+	if hasMore {
+		selected = selected[:limit]
+	}
+	for _, entry := range selected {
+		page.Jobs = append(page.Jobs, entry.job.summary())
+	}
+	page.Count = len(page.Jobs)
+	if hasMore {
+		last := selected[len(selected)-1]
+		page.NextCursor = encodeJobCursor(jobCursor{Sort: sortBy, Key: last.key, Created: last.created, ID: last.job.ID})
+	}
+	return page, nil
+}
+
+// scan retorna los candidatos que cumplen los filtros por campos que no cambian (tarea,
+// dueño, prioridad y fecha de creación) con su estado actual. Sin esos filtros ni los de
+// completed_at, los candidatos salen de los sets de estado y también retorna el reparto
+// por estado del índice; si no, el reparto es nil y lo cuenta ListJobs.
+func (idx *jobIndex) scan(q JobQuery, statuses map[JobStatus]bool) ([]jobEntry, map[JobStatus]int) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	fixed := len(q.Tasks) > 0 || q.OwnerSet || q.Owner != "" || len(q.Priorities) > 0 ||
+		!q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero()
+	if !fixed && q.CompletedAfter.IsZero() && q.CompletedBefore.IsZero() {
+		var entries []jobEntry
+		if len(statuses) == 0 {
+			entries = make([]jobEntry, 0, len(idx.all))
+			for _, job := range idx.all {
+				entries = append(entries, jobEntry{job: job, status: idx.statusOf[job], created: job.CreatedAt.UnixNano()})
+			}
+		} else {
+			for status := range statuses {
+				for job := range idx.byStatus[status] {
+					entries = append(entries, jobEntry{job: job, status: status, created: job.CreatedAt.UnixNano()})
+				}
+			}
+		}
+		return entries, idx.countsLocked()
+	}
+
+	var entries []jobEntry
+	for _, job := range idx.candidatesLocked(q) {
+		if q.fixedMatches(job) {
+			entries = append(entries, jobEntry{job: job, status: idx.statusOf[job], created: job.CreatedAt.UnixNano()})
+		}
+	}
+	return entries, make(map[JobStatus]int, len(JobStatuses))
+}
+
+// candidatesLocked retorna el índice más chico entre los filtros de tarea, dueño y
+// prioridad, o todos los jobs. Requiere idx.mu.
+func (idx *jobIndex) candidatesLocked(q JobQuery) []*Job {
+	best := idx.all
+	consider := func(lists [][]*Job) {
+		size := 0
+		for _, list := range lists {
+			size += len(list)
+		}
+		if size < len(best) {
+			best = nil
+			for _, list := range lists {
+				best = append(best, list...)
+			}
+		}
+	}
+
+	if len(q.Tasks) > 0 {
+		lists := make([][]*Job, 0, len(q.Tasks))
+		for _, task := range q.Tasks {
+			lists = append(lists, idx.byTask[task])
+		}
+		consider(lists)
+	}
+	if q.OwnerSet || q.Owner != "" {
+		consider([][]*Job{idx.byOwner[q.Owner]})
+	}
+	if len(q.Priorities) > 0 {
+		lists := make([][]*Job, 0, len(q.Priorities))
+		for _, priority := range q.Priorities {
+			lists = append(lists, idx.byPriority[priority])
+		}
+		consider(lists)
+	}
+	return best
+}
+
+// fixedMatches evalúa los filtros por campos que no cambian después de crear el job, así
+// que no necesita su lock
+func (q JobQuery) fixedMatches(job *Job) bool {
+	if len(q.Tasks) > 0 && !containsString(q.Tasks, job.Task) {
+		return false
+	}
+	if (q.OwnerSet || q.Owner != "") && job.Owner != q.Owner {
+		return false
+	}
+	if len(q.Priorities) > 0 {
+		found := false
+		for _, priority := range q.Priorities {
+			found = found || priority == job.Priority
+		}
+		if !found {
+			return false
+		}
+	}
+	if !q.CreatedAfter.IsZero() && job.CreatedAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !job.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// completedMatches evalúa los filtros por fecha de finalización
+func (q JobQuery) completedMatches(completed *time.Time) bool {
+	if completed == nil {
+		return false
+	}
+	if !q.CompletedAfter.IsZero() && completed.Before(q.CompletedAfter) {
+		return false
+	}
+	if !q.CompletedBefore.IsZero() && !completed.Before(q.CompletedBefore) {
+		return false
+	}
+	return true
+}
+
+// firstEntries retorna los n primeros según before, ordenados, sin ordenar el resto:
+// mantiene un heap con los n mejores vistos (O(m log n))
+func firstEntries(entries []jobEntry, n int, before func(a, b jobEntry) bool) []jobEntry {
+	if len(entries) <= n {
+		sort.Slice(entries, func(i, j int) bool { return before(entries[i], entries[j]) })
+		return entries
+	}
+	h := &entryHeap{before: before}
+	for _, entry := range entries {
+		if h.Len() < n {
+			heap.Push(h, entry)
+		} else if before(entry, h.entries[0]) {
+			h.entries[0] = entry
+			heap.Fix(h, 0)
+		}
+	}
+	sort.Slice(h.entries, func(i, j int) bool { return before(h.entries[i], h.entries[j]) })
+	return h.entries
+}
+
+// entryHeap es un heap con el peor de los elementos elegidos en la raíz
+type entryHeap struct {
+	entries []jobEntry
+	before  func(a, b jobEntry) bool
+}
+
+func (h *entryHeap) Len() int           { return len(h.entries) }
+func (h *entryHeap) Less(i, j int) bool { return h.before(h.entries[j], h.entries[i]) }
+func (h *entryHeap) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *entryHeap) Push(x interface{}) { h.entries = append(h.entries, x.(jobEntry)) }
+func (h *entryHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+// completedAt retorna la fecha de finalización de forma thread-safe
+func (j *Job) completedAt() *time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.CompletedAt
+}
+
+// summary retorna una copia de los campos del listado de forma thread-safe
+func (j *Job) summary() JobSummary {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return JobSummary{
+		ID:          j.ID,
+		Task:        j.Task,
+		Status:      j.Status,
+		Priority:    j.Priority.String(),
+		Owner:       j.Owner,
+		Progress:    j.Progress,
+		Error:       j.Error,
+		CreatedAt:   j.CreatedAt,
+		StartedAt:   j.StartedAt,
+		CompletedAt: j.CompletedAt,
+	}
+}
+
+// sortKey retorna la clave de orden principal. Los jobs sin terminar ordenan por
+// completed_at como si terminaran en el futuro: primero en orden descendente.
+func sortKey(e jobEntry, field string) int64 {
+	switch field {
+	case JobSortCompleted:
+		if e.completed == nil {
+			return math.MaxInt64
+		}
+		return e.completed.UnixNano()
+	case JobSortPriority:
+		return int64(e.job.Priority)
+	}
+	return e.created
+}
+
+// encodeJobCursor serializa el cursor como base64url opaco
+func encodeJobCursor(c jobCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeJobCursor parsea un cursor de encodeJobCursor
+func decodeJobCursor(value string) (*jobCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var c jobCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Sort == "" || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
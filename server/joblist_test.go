@@ -0,0 +1,226 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newListingManager crea un JobManager sin procesamiento con jobs de varias tareas,
+// dueños y prioridades. Retorna los jobs en orden de creación.
+func newListingManager(t *testing.T, n int) (*JobManager, []*Job) {
+	t.Helper()
+	jm := NewJobManager(n+10, time.Minute, time.Minute, "")
+	jm.Shutdown() // Sin loop de procesamiento los estados solo cambian desde el test
+
+	tasks := []string{"pi", "factor", "compress"}
+	owners := []string{"alice", "bob", ""}
+	jobs := make([]*Job, n)
+	for i := 0; i < n; i++ {
+		job, err := jm.SubmitFor(owners[i%3], tasks[i%3], nil, JobPriority(i%3))
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		jobs[i] = job
+		time.Sleep(time.Microsecond) // created_at distintos
+	}
+	return jm, jobs
+}
+
+func TestListJobsFiltersAndCounts(t *testing.T) {
+	jm, jobs := newListingManager(t, 30)
+	for i, job := range jobs {
+		switch i % 5 {
+		case 0:
+			job.SetResult(map[string]interface{}{"ok": true})
+		case 1:
+			job.SetError(errors.New("boom"))
+		case 2:
+			job.Cancel()
+		}
+	}
+
+	// Los contadores del índice siguen los cambios de estado
+	counts := jm.StatusCounts()
+	if counts[JobDone] != 6 || counts[JobError] != 6 || counts[JobCanceled] != 6 || counts[JobQueued] != 12 {
+		t.Errorf("Unexpected status counts: %v", counts)
+	}
+
+	page, err := jm.ListJobs(JobQuery{Tasks: []string{"pi"}, Statuses: []JobStatus{JobDone, JobError}})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	// pi son los i%3 == 0; done/error los i%5 == 0 o 1: i = 0, 6, 15, 21
+	if page.Total != 4 || page.Count != 4 {
+		t.Errorf("Expected 4 pi jobs done or in error, got total=%d count=%d", page.Total, page.Count)
+	}
+	for _, job := range page.Jobs {
+		if job.Task != "pi" || (job.Status != JobDone && job.Status != JobError) || job.Owner != "alice" {
+			t.Errorf("Unexpected job in page: %+v", job)
+		}
+	}
+	// counts ignora el filtro de estado: reparto de los 10 jobs de pi
+	if page.Counts[JobDone]+page.Counts[JobError]+page.Counts[JobCanceled]+page.Counts[JobQueued] != 10 || page.Counts[JobRunning] != 0 {
+		t.Errorf("Unexpected facet counts: %v", page.Counts)
+	}
+
+	// Solo con filtro de estado los candidatos salen del set de estado y counts del índice
+	page, _ = jm.ListJobs(JobQuery{Statuses: []JobStatus{JobCanceled}, Limit: 4})
+	if page.Total != 6 || page.Count != 4 || page.NextCursor == "" || page.Counts[JobQueued] != 12 || page.Counts[JobDone] != 6 {
+		t.Errorf("Unexpected status-only page: total=%d count=%d counts=%v", page.Total, page.Count, page.Counts)
+	}
+	for _, job := range page.Jobs {
+		if job.Status != JobCanceled {
+			t.Errorf("Expected only canceled jobs, got %s", job.Status)
+		}
+	}
+
+	page, _ = jm.ListJobs(JobQuery{Owner: "", OwnerSet: true, Priorities: []JobPriority{PriorityHigh}})
+	if page.Total != 10 || page.Jobs[0].Priority != "high" || page.Jobs[0].Owner != "" {
+		t.Errorf("Expected the 10 ownerless high priority jobs, got %d %+v", page.Total, page.Jobs[0])
+	}
+
+	middle := jobs[15].CreatedAt
+	page, _ = jm.ListJobs(JobQuery{CreatedAfter: middle})
+	if page.Total != 15 {
+		t.Errorf("Expected 15 jobs created from the 16th on, got %d", page.Total)
+	}
+	page, _ = jm.ListJobs(JobQuery{CompletedBefore: time.Now().Add(time.Minute)})
+	if page.Total != 18 {
+		t.Errorf("Expected only the 18 finished jobs when filtering by completion, got %d", page.Total)
+	}
+}
+
+func TestListJobsCursorPagination(t *testing.T) {
+	jm, jobs := newListingManager(t, 25)
+
+	for _, sortBy := range JobSorts {
+		seen := map[string]bool{}
+		var previous *JobSummary
+		query := JobQuery{Sort: sortBy, Limit: 7}
+		pages, want := 0, 0
+		for {
+			page, err := jm.ListJobs(query)
+			if err != nil {
+				t.Fatalf("%s: ListJobs failed: %v", sortBy, err)
+			}
+			if pages == 0 {
+				want = page.Total
+			}
+			pages++
+			for i := range page.Jobs {
+				job := page.Jobs[i]
+				if seen[job.ID] {
+					t.Errorf("%s: job %s repeated across pages", sortBy, job.ID)
+				}
+				seen[job.ID] = true
+				if previous != nil && sortBy == "-created_at" && job.CreatedAt.After(previous.CreatedAt) {
+					t.Errorf("%s: jobs out of order", sortBy)
+				}
+				previous = &job
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+
+			// Un job nuevo no desplaza las páginas siguientes en orden ascendente
+			if pages == 1 && sortBy == JobSortCreated {
+				jm.Submit("pi", nil, PriorityNormal)
+			}
+		}
+		// En orden ascendente el job agregado a mitad del recorrido aparece en la última página
+		if sortBy == JobSortCreated {
+			want++
+		}
+		if pages != (want+6)/7 || len(seen) != want {
+			t.Errorf("%s: expected %d jobs in %d pages, got %d in %d", sortBy, want, (want+6)/7, len(seen), pages)
+		}
+	}
+
+	// Por defecto, los más nuevos primero
+	page, _ := jm.ListJobs(JobQuery{Limit: 1})
+	if page.Sort != "-created_at" || page.Jobs[0].ID == jobs[0].ID {
+		t.Errorf("Expected newest first by default, got %+v", page)
+	}
+
+	// El cursor está atado al orden con el que se emitió
+	if _, err := jm.ListJobs(JobQuery{Sort: JobSortPriority, Cursor: page.NextCursor}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a cursor of another sort, got %v", err)
+	}
+	if _, err := jm.ListJobs(JobQuery{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestJobIndexOutOfOrder(t *testing.T) {
+	idx := newJobIndex()
+	base := time.Now()
+	for _, offset := range []int{0, 2, 1, 3} {
+		idx.add(&Job{ID: fmt.Sprintf("job-%d", offset), Task: "pi", Status: JobQueued, CreatedAt: base.Add(time.Duration(offset) * time.Second)})
+	}
+	for i, job := range idx.all {
+		if job.ID != fmt.Sprintf("job-%d", i) {
+			t.Errorf("Expected creation order, got %s at %d", job.ID, i)
+		}
+	}
+	if len(idx.byTask["pi"]) != 4 || idx.counts()[JobQueued] != 4 {
+		t.Errorf("Unexpected index state: %d %v", len(idx.byTask["pi"]), idx.counts())
+	}
+}
+
+// newBenchmarkIndex crea un JobManager con n jobs indexados directamente (Submit reordena la
+// cola en cada llamada). Uno de cada 100 está corriendo y el resto terminó o espera.
+func newBenchmarkIndex(n int) *JobManager {
+	jm := NewJobManager(10, time.Minute, time.Minute, "")
+	jm.Shutdown()
+
+	tasks := []string{"pi", "factor", "compress"}
+	owners := []string{"alice", "bob", ""}
+	statuses := []JobStatus{JobDone, JobDone, JobError, JobCanceled, JobQueued}
+	base := time.Now().Add(-time.Duration(n) * time.Millisecond)
+	for i := 0; i < n; i++ {
+		job := &Job{
+			ID:        fmt.Sprintf("job-%06d", i),
+			Task:      tasks[i%3],
+			Owner:     owners[i%3],
+			Priority:  JobPriority(i % 3),
+			Status:    statuses[i%len(statuses)],
+			CreatedAt: base.Add(time.Duration(i) * time.Millisecond),
+		}
+		if i%100 == 0 {
+			job.Status = JobRunning
+		}
+		if job.Status == JobDone || job.Status == JobError {
+			completed := job.CreatedAt.Add(time.Second)
+			job.CompletedAt = &completed
+		}
+		jm.jobs[job.ID] = job
+		jm.index.add(job)
+	}
+	return jm
+}
+
+func benchmarkListJobs(b *testing.B, q JobQuery) {
+	jm := newBenchmarkIndex(50000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jm.ListJobs(q); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListJobsRunning(b *testing.B) {
+	benchmarkListJobs(b, JobQuery{Statuses: []JobStatus{JobRunning}, Limit: 10})
+}
+
+func BenchmarkListJobsAll(b *testing.B) {
+	benchmarkListJobs(b, JobQuery{Limit: 50})
+}
+
+func BenchmarkListJobsTaskByCompletion(b *testing.B) {
+	benchmarkListJobs(b, JobQuery{Tasks: []string{"pi"}, Sort: "-" + JobSortCompleted, Limit: 50})
+}
@@ -3,9 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -35,6 +38,7 @@ type Job struct {
 	ID          string                 `json:"job_id"`
 	Task        string                 `json:"task"`
 	Params      map[string]string      `json:"params"`
+	Owner       string                 `json:"owner,omitempty"` // ID de la identidad que lo envió
 	Status      JobStatus              `json:"status"`
 	Priority    JobPriority            `json:"priority"`
 	Progress    int                    `json:"progress"`
@@ -47,6 +51,17 @@ type Job struct {
 	Timeout     time.Duration          `json:"-"`
 	CancelFunc  context.CancelFunc     `json:"-"`
 	mu          sync.RWMutex           `json:"-"`
+
+	onStatus func(job *Job, from, to JobStatus) // Avisa al índice del JobManager (ver jobIndex)
+}
+
+// setStatus cambia el estado y avisa al índice; debe llamarse con j.mu tomado
+func (j *Job) setStatus(status JobStatus) {
+	from := j.Status
+	j.Status = status
+	if j.onStatus != nil && from != status {
+		j.onStatus(j, from, status)
+	}
 }
 
 // UpdateProgress actualiza el progreso del job
@@ -67,7 +82,7 @@ func (j *Job) UpdateETA(eta time.Duration) {
 func (j *Job) SetResult(result map[string]interface{}) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
-	j.Status = JobDone
+	j.setStatus(JobDone)
 	j.Result = result
 	j.Progress = 100
 	now := time.Now()
@@ -78,7 +93,7 @@ func (j *Job) SetResult(result map[string]interface{}) {
 func (j *Job) SetError(err error) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
-	j.Status = JobError
+	j.setStatus(JobError)
 	j.Error = err.Error()
 	now := time.Now()
 	j.CompletedAt = &now
@@ -97,7 +112,7 @@ func (j *Job) Cancel() bool {
 		j.CancelFunc()
 	}
 
-	j.Status = JobCanceled
+	j.setStatus(JobCanceled)
 	now := time.Now()
 	j.CompletedAt = &now
 	return true
@@ -117,6 +132,10 @@ func (j *Job) GetInfo() map[string]interface{} {
 		"created_at": j.CreatedAt.Format(time.RFC3339),
 	}
 
+	if j.Owner != "" {
+		info["owner"] = j.Owner
+	}
+
 	if j.StartedAt != nil {
 		info["started_at"] = j.StartedAt.Format(time.RFC3339)
 	}
@@ -139,6 +158,7 @@ func (j *Job) GetInfo() map[string]interface{} {
 // JobManager gestiona trabajos asincrónicos
 type JobManager struct {
 	jobs            map[string]*Job
+	index           *jobIndex         // Índices para ListJobs y StatusCounts
 	queues          map[string][]*Job // Cola por tipo de tarea
 	mu              sync.RWMutex
 	maxQueueSize    int
@@ -149,7 +169,11 @@ type JobManager struct {
 	persistenceFile string
 	shutdownCh      chan struct{}
 	wg              sync.WaitGroup
-	executor        TaskExecutor // Interface para ejecutar tareas
+	executor        TaskExecutor    // Interface para ejecutar tareas
+	tasks           map[string]bool // Tareas que acepta el executor (nil = cualquiera, ver TaskLister)
+	breakers        *BreakerRegistry
+	breakersOn      bool  // Circuit breakers por tarea habilitados (ver EnableBreakers)
+	lastTick        int64 // UnixNano de la última vuelta del loop de procesamiento (atómico)
 }
 
 // TaskExecutor ejecuta tareas específicas
@@ -157,10 +181,20 @@ type TaskExecutor interface {
 	Execute(ctx context.Context, task string, params map[string]string) (map[string]interface{}, error)
 }
 
+// TaskLister lo implementan los executors que conocen sus tareas: Submit rechaza las demás
+// con ErrUnknownTask y solo esas tareas tienen circuit breaker
+type TaskLister interface {
+	Tasks() []string
+}
+
+// ErrUnknownTask se retorna al enviar una tarea que el executor no ejecuta
+var ErrUnknownTask = errors.New("unknown task")
+
 // NewJobManager crea un nuevo gestor de trabajos
 func NewJobManager(maxQueueSize int, cpuTimeout, ioTimeout time.Duration, persistenceFile string) *JobManager {
 	jm := &JobManager{
 		jobs:            make(map[string]*Job),
+		index:           newJobIndex(),
 		queues:          make(map[string][]*Job),
 		maxQueueSize:    maxQueueSize,
 		maxConcurrent:   make(map[string]int),
@@ -169,6 +203,8 @@ func NewJobManager(maxQueueSize int, cpuTimeout, ioTimeout time.Duration, persis
 		ioTimeout:       ioTimeout,
 		persistenceFile: persistenceFile,
 		shutdownCh:      make(chan struct{}),
+		breakers:        NewBreakerRegistry(DefaultBreakerConfig()),
+		lastTick:        time.Now().UnixNano(),
 	}
 
 	// Configurar límites de concurrencia por tipo
@@ -187,17 +223,77 @@ func NewJobManager(maxQueueSize int, cpuTimeout, ioTimeout time.Duration, persis
 
 // SetExecutor establece el executor de tareas
 func (jm *JobManager) SetExecutor(executor TaskExecutor) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
 	jm.executor = executor
+	jm.tasks = nil
+	if lister, ok := executor.(TaskLister); ok {
+		jm.tasks = make(map[string]bool)
+		for _, task := range lister.Tasks() {
+			jm.tasks[task] = true
+		}
+	}
+	jm.registerBreakersLocked()
+}
+
+// EnableBreakers activa un circuit breaker por cada tarea del executor (isprime, compress...):
+// con el circuito abierto, Submit falla con ErrCircuitOpen y los jobs ya encolados terminan
+// en error sin ejecutarse. Requiere un executor que implemente TaskLister.
+func (jm *JobManager) EnableBreakers(config BreakerConfig) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.breakers = NewBreakerRegistry(config)
+	jm.breakersOn = true
+	jm.registerBreakersLocked()
+}
+
+// registerBreakersLocked crea los breakers de las tareas conocidas. Se crean de antemano para
+// que una tarea inventada por un cliente no agregue entradas al registro ni a las métricas.
+func (jm *JobManager) registerBreakersLocked() {
+	if !jm.breakersOn {
+		return
+	}
+	for task := range jm.tasks {
+		jm.breakers.Get(task)
+	}
+}
+
+// breakerFor retorna el breaker de la tarea, o nil si no están habilitados o la tarea no
+// es conocida
+func (jm *JobManager) breakerFor(task string) *CircuitBreaker {
+	if !jm.breakersOn {
+		return nil
+	}
+	return jm.breakers.Lookup(task)
+}
+
+// BreakerStats retorna el estado de los breakers por tarea
+func (jm *JobManager) BreakerStats() map[string]interface{} {
+	return jm.breakers.Snapshot()
 }
 
-// Submit encola un nuevo trabajo
+// Submit encola un nuevo trabajo sin dueño
 func (jm *JobManager) Submit(task string, params map[string]string, priority JobPriority) (*Job, error) {
+	return jm.SubmitFor("", task, params, priority)
+}
+
+// SubmitFor encola un nuevo trabajo registrando la identidad que lo envió
+func (jm *JobManager) SubmitFor(owner, task string, params map[string]string, priority JobPriority) (*Job, error) {
 	jm.mu.Lock()
 	defer jm.mu.Unlock()
 
+	if jm.tasks != nil && !jm.tasks[task] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTask, task)
+	}
+
 	// Determinar tipo de tarea
 	taskType := jm.getTaskType(task)
 
+	// Fallar rápido si la tarea viene fallando
+	if cb := jm.breakerFor(task); cb != nil && cb.State() == BreakerOpen {
+		return nil, fmt.Errorf("%w for task %s", ErrCircuitOpen, task)
+	}
+
 	// Verificar tamaño de cola
 	if len(jm.queues[taskType]) >= jm.maxQueueSize {
 		return nil, fmt.Errorf("queue full")
@@ -216,6 +312,7 @@ func (jm *JobManager) Submit(task string, params map[string]string, priority Job
 		ID:        jobID,
 		Task:      task,
 		Params:    params,
+		Owner:     owner,
 		Status:    JobQueued,
 		Priority:  priority,
 		Progress:  0,
@@ -225,6 +322,7 @@ func (jm *JobManager) Submit(task string, params map[string]string, priority Job
 
 	// Agregar a jobs y cola
 	jm.jobs[jobID] = job
+	jm.index.add(job)
 	jm.queues[taskType] = append(jm.queues[taskType], job)
 
 	// Ordenar cola por prioridad
@@ -282,10 +380,17 @@ func (jm *JobManager) processQueue() {
 			return
 		case <-ticker.C:
 			jm.processNextJob()
+			atomic.StoreInt64(&jm.lastTick, time.Now().UnixNano())
 		}
 	}
 }
 
+// LastTick retorna cuándo completó el loop de procesamiento su última vuelta; si deja de
+// avanzar, el loop terminó o está bloqueado
+func (jm *JobManager) LastTick() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&jm.lastTick))
+}
+
 // processNextJob intenta procesar el siguiente trabajo de mayor prioridad
 func (jm *JobManager) processNextJob() {
 	jm.mu.Lock()
@@ -325,10 +430,23 @@ func (jm *JobManager) processNextJob() {
 		return
 	}
 
+	// Con el breaker de la tarea abierto, el job termina en error sin ocupar un slot
+	breaker := jm.breakerFor(nextJob.Task)
+	var ticket BreakerTicket
+	if breaker != nil {
+		var ok bool
+		if ticket, ok = breaker.Allow(); !ok {
+			nextJob.SetError(fmt.Errorf("%w for task %s", ErrCircuitOpen, nextJob.Task))
+			jm.saveJobs()
+			jm.mu.Unlock()
+			return
+		}
+	}
+
 	// Marcar como running
 	now := time.Now()
 	nextJob.mu.Lock()
-	nextJob.Status = JobRunning
+	nextJob.setStatus(JobRunning)
 	nextJob.StartedAt = &now
 	nextJob.mu.Unlock()
 
@@ -337,11 +455,11 @@ func (jm *JobManager) processNextJob() {
 
 	// Ejecutar en goroutine separada
 	jm.wg.Add(1)
-	go jm.executeJob(nextJob, taskType)
+	go jm.executeJob(nextJob, taskType, breaker, ticket)
 }
 
-// executeJob ejecuta un trabajo
-func (jm *JobManager) executeJob(job *Job, taskType string) {
+// executeJob ejecuta un trabajo e informa el resultado al breaker de su tarea (si hay)
+func (jm *JobManager) executeJob(job *Job, taskType string, breaker *CircuitBreaker, ticket BreakerTicket) {
 	defer jm.wg.Done()
 	defer func() {
 		jm.mu.Lock()
@@ -369,15 +487,33 @@ func (jm *JobManager) executeJob(job *Job, taskType string) {
 	}()
 
 	// Esperar resultado o timeout
+	start := time.Now()
 	select {
 	case result := <-resultCh:
 		job.SetResult(result)
+		if breaker != nil {
+			breaker.Record(ticket, nil, time.Since(start))
+		}
 	case err := <-errorCh:
 		job.SetError(err)
+		if breaker != nil {
+			if errors.Is(err, context.Canceled) {
+				breaker.Cancel(ticket)
+			} else {
+				breaker.Record(ticket, err, time.Since(start))
+			}
+		}
 	case <-ctx.Done():
+		if breaker != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				breaker.Record(ticket, ctx.Err(), time.Since(start))
+			} else {
+				breaker.Cancel(ticket) // Cancelado por el usuario: no dice nada de la tarea
+			}
+		}
 		if ctx.Err() == context.DeadlineExceeded {
 			job.mu.Lock()
-			job.Status = JobTimeout
+			job.setStatus(JobTimeout)
 			job.Error = "timeout exceeded"
 			now := time.Now()
 			job.CompletedAt = &now
@@ -452,6 +588,7 @@ func (jm *JobManager) saveJobs() {
 		ID          string                 `json:"job_id"`
 		Task        string                 `json:"task"`
 		Params      map[string]string      `json:"params"`
+		Owner       string                 `json:"owner,omitempty"`
 		Status      JobStatus              `json:"status"`
 		Priority    JobPriority            `json:"priority"`
 		Progress    int                    `json:"progress"`
@@ -469,6 +606,7 @@ func (jm *JobManager) saveJobs() {
 			ID:          job.ID,
 			Task:        job.Task,
 			Params:      job.Params,
+			Owner:       job.Owner,
 			Status:      job.Status,
 			Priority:    job.Priority,
 			Progress:    job.Progress,
@@ -504,6 +642,7 @@ func (jm *JobManager) loadJobs() {
 		ID          string                 `json:"job_id"`
 		Task        string                 `json:"task"`
 		Params      map[string]string      `json:"params"`
+		Owner       string                 `json:"owner,omitempty"`
 		Status      JobStatus              `json:"status"`
 		Priority    JobPriority            `json:"priority"`
 		Progress    int                    `json:"progress"`
@@ -519,6 +658,14 @@ func (jm *JobManager) loadJobs() {
 		return
 	}
 
+	// En orden de creación, así el índice se arma solo con appends
+	sort.Slice(persist, func(i, j int) bool {
+		if !persist[i].CreatedAt.Equal(persist[j].CreatedAt) {
+			return persist[i].CreatedAt.Before(persist[j].CreatedAt)
+		}
+		return persist[i].ID < persist[j].ID
+	})
+
 	// Restaurar jobs (solo los no completados)
 	for _, jp := range persist {
 		if jp.Status == JobRunning {
@@ -531,6 +678,7 @@ func (jm *JobManager) loadJobs() {
 			ID:          jp.ID,
 			Task:        jp.Task,
 			Params:      jp.Params,
+			Owner:       jp.Owner,
 			Status:      jp.Status,
 			Priority:    jp.Priority,
 			Progress:    jp.Progress,
@@ -542,6 +690,7 @@ func (jm *JobManager) loadJobs() {
 		}
 
 		jm.jobs[job.ID] = job
+		jm.index.add(job)
 
 		// Re-encolar si estaba queued
 		if job.Status == JobQueued {
@@ -571,6 +720,11 @@ func (jm *JobManager) GetQueueStats() map[string]interface{} {
 	return stats
 }
 
+// StatusCounts retorna la cantidad de jobs en cada estado (solo los estados con jobs)
+func (jm *JobManager) StatusCounts() map[JobStatus]int {
+	return jm.index.counts()
+}
+
 // Shutdown detiene el job manager
 func (jm *JobManager) Shutdown() {
 	close(jm.shutdownCh)
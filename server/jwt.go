@@ -0,0 +1,433 @@
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTConfig configura la validación de bearer tokens
+type JWTConfig struct {
+	JWKSFile        string                  // Archivo JWKS local con las claves de verificación
+	Issuer          string                  // Valor esperado de "iss" (vacío = no se valida)
+	Audience        string                  // Valor que debe contener "aud" (vacío = no se valida)
+	ClockSkew       time.Duration           // Tolerancia para exp/nbf/iat
+	RoleClaim       string                  // Claim con los roles (por defecto "roles")
+	AdminRole       string                  // Rol que da acceso total (por defecto "admin")
+	RolePermissions map[string][]AccessRule // Rutas permitidas por rol
+	ReloadInterval  time.Duration           // Cada cuánto revisar cambios del JWKS (por defecto 30s)
+}
+
+// jwk representa una clave del archivo JWKS
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// HMAC
+	K string `json:"k"`
+}
+
+// verificationKey es una clave JWKS ya decodificada
+type verificationKey struct {
+	kid    string
+	alg    string
+	rsa    *rsa.PublicKey
+	ec     *ecdsa.PublicKey
+	secret []byte
+}
+
+// JWTAuthenticator valida tokens HS256/RS256/ES256 usando solo la librería estándar
+type JWTAuthenticator struct {
+	config    JWTConfig
+	mu        sync.RWMutex
+	keys      []*verificationKey
+	modTime   time.Time
+	lastCheck time.Time
+	now       func() time.Time
+}
+
+// NewJWTAuthenticator crea un autenticador JWT y carga el JWKS inicial
+func NewJWTAuthenticator(config JWTConfig) (*JWTAuthenticator, error) {
+	if config.RoleClaim == "" {
+		config.RoleClaim = "roles"
+	}
+	if config.AdminRole == "" {
+		config.AdminRole = "admin"
+	}
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = 30 * time.Second
+	}
+
+	a := &JWTAuthenticator{config: config, now: time.Now}
+	if err := a.loadKeys(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Scheme retorna el esquema usado en WWW-Authenticate
+func (a *JWTAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+// Authenticate valida el bearer token del header Authorization
+func (a *JWTAuthenticator) Authenticate(req *HTTPRequest) (*Identity, error) {
+	authHeader := req.GetHeader("Authorization")
+	if len(authHeader) < 7 || !strings.EqualFold(authHeader[:7], "Bearer ") {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.Verify(strings.TrimSpace(authHeader[7:]))
+	if err != nil {
+		return nil, err
+	}
+
+	return a.identityFromClaims(claims)
+}
+
+// Verify valida firma y claims registrados de un token y retorna sus claims
+func (a *JWTAuthenticator) Verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, errors.New("malformed token header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := a.verifySignature(header.Alg, header.Kid, signed, signature); err != nil {
+		return nil, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifySignature busca la clave adecuada y verifica la firma
+func (a *JWTAuthenticator) verifySignature(alg, kid string, signed, signature []byte) error {
+	if alg != "HS256" && alg != "RS256" && alg != "ES256" {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	a.reloadIfChanged(false)
+	candidates := a.candidateKeys(alg, kid)
+	if len(candidates) == 0 && kid != "" {
+		// kid desconocido: puede ser una clave recién rotada
+		a.reloadIfChanged(true)
+		candidates = a.candidateKeys(alg, kid)
+	}
+	if len(candidates) == 0 {
+		return errors.New("no verification key for token")
+	}
+
+	digest := sha256.Sum256(signed)
+	for _, key := range candidates {
+		switch alg {
+		case "HS256":
+			mac := hmac.New(sha256.New, key.secret)
+			mac.Write(signed)
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return nil
+			}
+		case "RS256":
+			if rsa.VerifyPKCS1v15(key.rsa, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		case "ES256":
+			if len(signature) != 64 {
+				continue
+			}
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			if ecdsa.Verify(key.ec, digest[:], r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("invalid token signature")
+}
+
+// candidateKeys retorna las claves compatibles con el algoritmo (y kid si viene)
+func (a *JWTAuthenticator) candidateKeys(alg, kid string) []*verificationKey {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var candidates []*verificationKey
+	for _, key := range a.keys {
+		if key.alg != alg {
+			continue
+		}
+		if kid != "" && key.kid != kid {
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+	return candidates
+}
+
+// validateClaims revisa exp, nbf, iat, iss y aud
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := a.now()
+	skew := a.config.ClockSkew
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return errors.New("token without exp")
+	}
+	if now.After(time.Unix(exp, 0).Add(skew)) {
+		return errors.New("token expired")
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(skew).Before(time.Unix(nbf, 0)) {
+		return errors.New("token not valid yet")
+	}
+	if iat, ok := numericClaim(claims, "iat"); ok && now.Add(skew).Before(time.Unix(iat, 0)) {
+		return errors.New("token issued in the future")
+	}
+
+	if a.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.config.Issuer {
+			return errors.New("invalid token issuer")
+		}
+	}
+
+	if a.config.Audience != "" && !audienceContains(claims["aud"], a.config.Audience) {
+		return errors.New("invalid token audience")
+	}
+
+	return nil
+}
+
+// identityFromClaims construye la identidad y sus permisos a partir de los roles
+func (a *JWTAuthenticator) identityFromClaims(claims map[string]interface{}) (*Identity, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("token without sub")
+	}
+
+	roles := stringListClaim(claims[a.config.RoleClaim])
+	identity := &Identity{
+		ID:     subject,
+		Roles:  roles,
+		Source: "jwt",
+	}
+
+	for _, role := range roles {
+		if role == a.config.AdminRole {
+			identity.Admin = true
+		}
+		identity.Rules = append(identity.Rules, a.config.RolePermissions[role]...)
+	}
+
+	return identity, nil
+}
+
+// reloadIfChanged recarga el JWKS si el archivo cambió; force ignora el intervalo
+func (a *JWTAuthenticator) reloadIfChanged(force bool) {
+	a.mu.RLock()
+	elapsed := a.now().Sub(a.lastCheck)
+	due := (force && elapsed > time.Second) || elapsed >= a.config.ReloadInterval
+	modTime := a.modTime
+	a.mu.RUnlock()
+
+	if !due {
+		return
+	}
+
+	a.mu.Lock()
+	a.lastCheck = a.now()
+	a.mu.Unlock()
+
+	info, err := os.Stat(a.config.JWKSFile)
+	if err != nil || info.ModTime().Equal(modTime) {
+		return
+	}
+	a.loadKeys()
+}
+
+// loadKeys lee y decodifica todas las claves del JWKS
+func (a *JWTAuthenticator) loadKeys() error {
+	info, err := os.Stat(a.config.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("error leyendo JWKS: %w", err)
+	}
+	data, err := os.ReadFile(a.config.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("error leyendo JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("error parseando JWKS: %w", err)
+	}
+
+	keys := make([]*verificationKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := decodeJWK(k)
+		if err != nil {
+			return fmt.Errorf("clave %q inválida: %w", k.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.modTime = info.ModTime()
+	a.lastCheck = a.now()
+	a.mu.Unlock()
+	return nil
+}
+
+// decodeJWK convierte una entrada JWKS en una clave de verificación
+func decodeJWK(k jwk) (*verificationKey, error) {
+	key := &verificationKey{kid: k.Kid, alg: k.Alg}
+
+	switch k.Kty {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid k")
+		}
+		key.secret = secret
+		if key.alg == "" {
+			key.alg = "HS256"
+		}
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid n/e")
+		}
+		key.rsa = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.alg == "" {
+			key.alg = "RS256"
+		}
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid x/y")
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("point not on curve")
+		}
+		key.ec = pub
+		if key.alg == "" {
+			key.alg = "ES256"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+
+	// El algoritmo declarado debe corresponder al tipo de clave
+	expected := map[string]string{"oct": "HS256", "RSA": "RS256", "EC": "ES256"}[k.Kty]
+	if key.alg != expected {
+		return nil, fmt.Errorf("alg %q does not match kty %q", key.alg, k.Kty)
+	}
+
+	return key, nil
+}
+
+// numericClaim lee un claim NumericDate
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(value), true
+}
+
+// audienceContains indica si el claim aud contiene la audiencia. Un aud string es una sola
+// audiencia y se compara completo; solo una lista JSON tiene varias.
+func audienceContains(aud interface{}, expected string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == expected
+	case []interface{}:
+		for _, item := range v {
+			if value, ok := item.(string); ok && value == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringListClaim lee un claim que puede ser lista de strings o string separado por espacios
+func stringListClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+// signTestJWT firma un token con la clave dada (secreto HMAC, *rsa.PrivateKey o *ecdsa.PrivateKey)
+func signTestJWT(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, _ := ecdsa.Sign(rand.Reader, k, digest[:])
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func writeJWKS(t *testing.T, path string, keys ...map[string]string) {
+	t.Helper()
+	data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secret := []byte("super-secret-hmac-key")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path,
+		map[string]string{"kty": "oct", "kid": "hs", "alg": "HS256", "k": b64.EncodeToString(secret)},
+		map[string]string{"kty": "RSA", "kid": "rs", "n": b64.EncodeToString(rsaKey.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes())},
+		map[string]string{"kty": "EC", "kid": "es", "crv": "P-256", "x": b64.EncodeToString(ecKey.X.Bytes()), "y": b64.EncodeToString(ecKey.Y.Bytes())},
+	)
+
+	auth, err := NewJWTAuthenticator(JWTConfig{
+		JWKSFile:  path,
+		Issuer:    "https://issuer.example",
+		Audience:  "go-http-service",
+		ClockSkew: 30 * time.Second,
+		RolePermissions: map[string][]AccessRule{
+			"viewer": {{Methods: []string{"GET"}, Pattern: "*"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator failed: %v", err)
+	}
+
+	now := time.Now().Unix()
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub": "alice", "iss": "https://issuer.example", "aud": []string{"other", "go-http-service"},
+			"exp": now + 300, "nbf": now - 10, "roles": []string{"viewer"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr bool
+	}{
+		{"HS256", func() string { return signTestJWT(t, "HS256", "hs", secret, valid()) }, false},
+		{"RS256", func() string { return signTestJWT(t, "RS256", "rs", rsaKey, valid()) }, false},
+		{"ES256", func() string { return signTestJWT(t, "ES256", "es", ecKey, valid()) }, false},
+		{"Wrong secret", func() string { return signTestJWT(t, "HS256", "hs", []byte("other"), valid()) }, true},
+		{"Algorithm confusion", func() string { return signTestJWT(t, "HS256", "rs", secret, valid()) }, true},
+		{"Expired", func() string {
+			c := valid()
+			c["exp"] = now - 120
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, true},
+		{"Expired within skew", func() string {
+			c := valid()
+			c["exp"] = now - 10
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, false},
+		{"Not yet valid", func() string {
+			c := valid()
+			c["nbf"] = now + 120
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, true},
+		{"Wrong issuer", func() string {
+			c := valid()
+			c["iss"] = "https://evil.example"
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, true},
+		{"Wrong audience", func() string {
+			c := valid()
+			c["aud"] = "someone-else"
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, true},
+		{"Audience string with spaces", func() string {
+			c := valid()
+			c["aud"] = "other go-http-service"
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, true},
+		{"Audience string", func() string {
+			c := valid()
+			c["aud"] = "go-http-service"
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, false},
+		{"Missing exp", func() string {
+			c := valid()
+			delete(c, "exp")
+			return signTestJWT(t, "HS256", "hs", secret, c)
+		}, true},
+		{"alg none", func() string {
+			header := b64.EncodeToString([]byte(`{"alg":"none"}`))
+			payload, _ := json.Marshal(valid())
+			return header + "." + b64.EncodeToString(payload) + "."
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &HTTPRequest{Method: "GET", Path: "/pi", Headers: map[string]string{"Authorization": "Bearer " + tt.token()}}
+			identity, err := auth.Authenticate(req)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected authentication error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if identity.ID != "alice" || !identity.HasRole("viewer") || !identity.Allows("GET", "/pi") {
+				t.Errorf("Unexpected identity: %+v", identity)
+			}
+			if identity.Allows("DELETE", "/file") {
+				t.Error("viewer should not be allowed to DELETE")
+			}
+		})
+	}
+
+	// Sin header Authorization el autenticador no aplica
+	if _, err := auth.Authenticate(&HTTPRequest{Headers: map[string]string{}}); err != ErrNoCredentials {
+		t.Errorf("Expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	oldSecret, newSecret := []byte("old-secret"), []byte("new-secret")
+	writeJWKS(t, path, map[string]string{"kty": "oct", "kid": "k1", "k": b64.EncodeToString(oldSecret)})
+
+	auth, err := NewJWTAuthenticator(JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator failed: %v", err)
+	}
+
+	claims := map[string]interface{}{"sub": "bob", "exp": time.Now().Unix() + 60}
+	if _, err := auth.Verify(signTestJWT(t, "HS256", "k1", oldSecret, claims)); err != nil {
+		t.Fatalf("Old key should verify: %v", err)
+	}
+
+	// Rotar: agregar k2 y simular que pasó el intervalo mínimo entre recargas
+	writeJWKS(t, path, map[string]string{"kty": "oct", "kid": "k2", "k": b64.EncodeToString(newSecret)})
+	future := time.Now().Add(2 * time.Second)
+	os.Chtimes(path, future, future)
+	auth.now = func() time.Time { return future }
+
+	if _, err := auth.Verify(signTestJWT(t, "HS256", "k2", newSecret, claims)); err != nil {
+		t.Errorf("Rotated key should verify: %v", err)
+	}
+	if _, err := auth.Verify(signTestJWT(t, "HS256", "k1", oldSecret, claims)); err == nil {
+		t.Error("Removed key should no longer verify")
+	}
+}
+
+func TestAuthMiddlewareRequireRoles(t *testing.T) {
+	auth := NewAuthManager(staticAuthenticator{
+		"viewer-token":   {ID: "v", Roles: []string{"viewer"}, Rules: []AccessRule{{Pattern: "*"}}},
+		"operator-token": {ID: "o", Roles: []string{"operator"}, Rules: []AccessRule{{Pattern: "*"}}},
+		"admin-token":    {ID: "a", Admin: true},
+	})
+	auth.AllowPublic(nil, "/metrics")
+	auth.RequireRoles([]string{"GET"}, "/metrics", "operator")
+
+	handler := auth.Middleware()(func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200}
+	})
+
+	tests := []struct {
+		token          string
+		expectedStatus int
+	}{
+		{"", 401},
+		{"viewer-token", 403},
+		{"operator-token", 200},
+		{"admin-token", 200},
+	}
+
+	for _, tt := range tests {
+		req := &HTTPRequest{Method: "GET", Path: "/metrics", Headers: map[string]string{}}
+		if tt.token != "" {
+			req.Headers["Authorization"] = tt.token
+		}
+		if resp := handler(req); resp.StatusCode != tt.expectedStatus {
+			t.Errorf("token %q: expected %d, got %d", tt.token, tt.expectedStatus, resp.StatusCode)
+		}
+	}
+}
+
+// staticAuthenticator autentica con tokens fijos para tests
+type staticAuthenticator map[string]*Identity
+
+func (s staticAuthenticator) Scheme() string { return "Static" }
+
+func (s staticAuthenticator) Authenticate(req *HTTPRequest) (*Identity, error) {
+	token := req.GetHeader("Authorization")
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	if identity, ok := s[token]; ok {
+		return identity, nil
+	}
+	return nil, ErrNoCredentials
+}
+
+func TestAuthMiddlewareRequireAdmin(t *testing.T) {
+	auth := NewAuthManager(staticAuthenticator{
+		"operator-token": {ID: "o", Roles: []string{"operator"}, Rules: []AccessRule{{Pattern: "*"}}},
+		"admin-token":    {ID: "a", Admin: true},
+	})
+	auth.AllowPublic(nil, "*")
+	auth.RequireAdmin([]string{"GET"}, "/debug/pprof/*")
+
+	handler := auth.Middleware()(func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200}
+	})
+
+	tests := []struct {
+		token          string
+		path           string
+		expectedStatus int
+	}{
+		{"", "/debug/pprof/heap", 401},
+		{"operator-token", "/debug/pprof/heap", 403},
+		{"operator-token", "/debug/pprof", 403},
+		{"admin-token", "/debug/pprof/heap", 200},
+		{"", "/ping", 200},
+	}
+
+	for _, tt := range tests {
+		req := &HTTPRequest{Method: "GET", Path: tt.path, Headers: map[string]string{}}
+		if tt.token != "" {
+			req.Headers["Authorization"] = tt.token
+		}
+		if resp := handler(req); resp.StatusCode != tt.expectedStatus {
+			t.Errorf("token %q on %s: expected %d, got %d", tt.token, tt.path, tt.expectedStatus, resp.StatusCode)
+		}
+	}
+}
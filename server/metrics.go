@@ -2,121 +2,231 @@ package server
 
 import (
 	"math"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// RequestMetrics almacena métricas de requests por endpoint
+// DefaultStatsWindow es la ventana sobre la que se calculan las estadísticas por endpoint
+const DefaultStatsWindow = 5 * time.Minute
+
+// RequestMetrics almacena métricas de requests por endpoint. Los tiempos se registran en
+// histogramas con ventana deslizante (resolución de µs, memoria constante) sin tomar locks.
 type RequestMetrics struct {
-	mu             sync.RWMutex
-	waitTimes      []float64 // Tiempo en cola (ms)
-	execTimes      []float64 // Tiempo de ejecución (ms)
-	totalRequests  int64
-	activeRequests int64
+	waitTimes      *WindowedHistogram // Tiempo en cola
+	execTimes      *WindowedHistogram // Tiempo de ejecución
+	totalRequests  int64              // (atómico)
+	activeRequests int64              // (atómico)
 	endpoint       string
-	lastUpdateTime time.Time
+	lastUpdateTime int64 // UnixNano (atómico)
+
+	statusClasses [6]int64 // Respuestas por clase: índice 1..5 = 1xx..5xx, 0 = códigos fuera de rango (atómicos)
+	statusCodes   map[int]*int64
+	codesMu       sync.RWMutex
+	bytesIn       int64            // Bytes de las peticiones (atómico)
+	bytesOut      int64            // Bytes de las respuestas (atómico)
+	traffic       *WindowedCounter // Respuestas y errores por intervalo, para tasas por ventana
 }
 
 // NewRequestMetrics crea nuevas métricas para un endpoint
 func NewRequestMetrics(endpoint string) *RequestMetrics {
 	return &RequestMetrics{
-		waitTimes:      make([]float64, 0, 1000),
-		execTimes:      make([]float64, 0, 1000),
+		waitTimes:      NewWindowedHistogram(DefaultHistogramWindowConfig()),
+		execTimes:      NewWindowedHistogram(DefaultHistogramWindowConfig()),
 		endpoint:       endpoint,
-		lastUpdateTime: time.Now(),
+		lastUpdateTime: time.Now().UnixNano(),
+		statusCodes:    make(map[int]*int64),
+		traffic:        NewWindowedCounter(DefaultHistogramWindowConfig(), trafficCounters),
 	}
 }
 
 // RecordWaitTime registra tiempo de espera en cola
 func (rm *RequestMetrics) RecordWaitTime(duration time.Duration) {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-
-	ms := float64(duration.Milliseconds())
-	rm.waitTimes = append(rm.waitTimes, ms)
-
-	// Mantener solo últimas 1000 mediciones
-	if len(rm.waitTimes) > 1000 {
-		rm.waitTimes = rm.waitTimes[len(rm.waitTimes)-1000:]
-	}
+	rm.waitTimes.Record(duration)
 }
 
 // RecordExecTime registra tiempo de ejecución
 func (rm *RequestMetrics) RecordExecTime(duration time.Duration) {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-
-	ms := float64(duration.Milliseconds())
-	rm.execTimes = append(rm.execTimes, ms)
+	rm.execTimes.Record(duration)
+	atomic.AddInt64(&rm.totalRequests, 1)
+	atomic.StoreInt64(&rm.lastUpdateTime, time.Now().UnixNano())
+}
 
-	if len(rm.execTimes) > 1000 {
-		rm.execTimes = rm.execTimes[len(rm.execTimes)-1000:]
+// RecordResponse registra el status y los bytes de una respuesta enviada
+func (rm *RequestMetrics) RecordResponse(status int, bytesIn, bytesOut int64) {
+	class := status / 100
+	if class < 1 || class > 5 {
+		class = 0
 	}
-
-	rm.totalRequests++
-	rm.lastUpdateTime = time.Now()
+	atomic.AddInt64(&rm.statusClasses[class], 1)
+	rm.traffic.Add(trafficRequests, 1)
+	switch class {
+	case 4:
+		rm.traffic.Add(trafficClientErrors, 1)
+	case 5:
+		rm.traffic.Add(trafficErrors, 1)
+	}
+	atomic.AddInt64(&rm.bytesIn, bytesIn)
+	atomic.AddInt64(&rm.bytesOut, bytesOut)
+
+	rm.codesMu.RLock()
+	counter, ok := rm.statusCodes[status]
+	rm.codesMu.RUnlock()
+	if !ok {
+		rm.codesMu.Lock()
+		if counter, ok = rm.statusCodes[status]; !ok {
+			counter = new(int64)
+			rm.statusCodes[status] = counter
+		}
+		rm.codesMu.Unlock()
+	}
+	atomic.AddInt64(counter, 1)
 }
 
 // IncrementActive incrementa requests activos
 func (rm *RequestMetrics) IncrementActive() {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-	rm.activeRequests++
+	atomic.AddInt64(&rm.activeRequests, 1)
 }
 
 // DecrementActive decrementa requests activos
 func (rm *RequestMetrics) DecrementActive() {
-	rm.mu.Lock()
-	defer rm.mu.Unlock()
-	rm.activeRequests--
+	atomic.AddInt64(&rm.activeRequests, -1)
 }
 
-// GetStats retorna estadísticas calculadas
+// GetStats retorna estadísticas de los últimos DefaultStatsWindow
 func (rm *RequestMetrics) GetStats() map[string]interface{} {
-	rm.mu.RLock()
-	defer rm.mu.RUnlock()
+	return rm.GetStatsWindow(DefaultStatsWindow)
+}
 
-	return map[string]interface{}{
+// GetStatsWindow retorna estadísticas calculadas sobre la ventana dada
+func (rm *RequestMetrics) GetStatsWindow(window time.Duration) map[string]interface{} {
+	stats := map[string]interface{}{
 		"endpoint":        rm.endpoint,
-		"total_requests":  rm.totalRequests,
-		"active_requests": rm.activeRequests,
-		"wait_time": map[string]float64{
-			"avg_ms":  calculateAverage(rm.waitTimes),
-			"std_dev": calculateStdDev(rm.waitTimes),
-			"min_ms":  calculateMin(rm.waitTimes),
-			"max_ms":  calculateMax(rm.waitTimes),
-			"p50_ms":  calculatePercentile(rm.waitTimes, 0.50),
-			"p95_ms":  calculatePercentile(rm.waitTimes, 0.95),
-			"p99_ms":  calculatePercentile(rm.waitTimes, 0.99),
-		},
-		"exec_time": map[string]float64{
-			"avg_ms":  calculateAverage(rm.execTimes),
-			"std_dev": calculateStdDev(rm.execTimes),
-			"min_ms":  calculateMin(rm.execTimes),
-			"max_ms":  calculateMax(rm.execTimes),
-			"p50_ms":  calculatePercentile(rm.execTimes, 0.50),
-			"p95_ms":  calculatePercentile(rm.execTimes, 0.95),
-			"p99_ms":  calculatePercentile(rm.execTimes, 0.99),
-		},
-		"last_update": rm.lastUpdateTime.Format(time.RFC3339),
+		"total_requests":  atomic.LoadInt64(&rm.totalRequests),
+		"active_requests": atomic.LoadInt64(&rm.activeRequests),
+		"window":          formatWindow(window),
+		"wait_time":       latencyStats(rm.waitTimes.Snapshot(window)),
+		"exec_time":       latencyStats(rm.execTimes.Snapshot(window)),
+		"last_update":     time.Unix(0, atomic.LoadInt64(&rm.lastUpdateTime)).Format(time.RFC3339),
+		"bytes_in":        atomic.LoadInt64(&rm.bytesIn),
+		"bytes_out":       atomic.LoadInt64(&rm.bytesOut),
+	}
+	for key, value := range rm.statusStats() {
+		stats[key] = value
+	}
+	stats["rate"] = rm.trafficWindow(window)
+
+	windows := make(map[string]interface{}, len(StatsWindows))
+	for _, w := range StatsWindows {
+		windows[formatWindow(w)] = rm.trafficWindow(w)
 	}
+	stats["windows"] = windows
+	return stats
+}
+
+// trafficWindow retorna rps, tasa de error y cuantiles de los últimos window
+func (rm *RequestMetrics) trafficWindow(window time.Duration) map[string]interface{} {
+	return trafficStats(rm.traffic.Sums(window), rm.traffic.Covered(window), rm.execTimes.Snapshot(window))
+}
+
+// statusStats retorna las respuestas por clase y por código, y las proporciones de error
+func (rm *RequestMetrics) statusStats() map[string]interface{} {
+	classes := make(map[string]int64, 5)
+	responses := int64(0)
+	for class := 1; class <= 5; class++ {
+		count := atomic.LoadInt64(&rm.statusClasses[class])
+		classes[strconv.Itoa(class)+"xx"] = count
+		responses += count
+	}
+	if other := atomic.LoadInt64(&rm.statusClasses[0]); other > 0 {
+		classes["other"] = other
+		responses += other
+	}
+
+	rm.codesMu.RLock()
+	codes := make(map[string]int64, len(rm.statusCodes))
+	for code, counter := range rm.statusCodes {
+		codes[strconv.Itoa(code)] = atomic.LoadInt64(counter)
+	}
+	rm.codesMu.RUnlock()
+
+	return map[string]interface{}{
+		"responses":          responses,
+		"status_classes":     classes,
+		"status_codes":       codes,
+		"error_ratio":        ratio(classes["5xx"], responses),
+		"client_error_ratio": ratio(classes["4xx"], responses),
+	}
+}
+
+// ratio retorna part/total redondeado a 4 decimales (0 si total es 0)
+func ratio(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 10000
+}
+
+// latencyStats resume un histograma en milisegundos con resolución de µs
+func latencyStats(h *HistogramSnapshot) map[string]interface{} {
+	return map[string]interface{}{
+		"count":   h.Count,
+		"avg_ms":  durationMs(h.Mean()),
+		"std_dev": durationMs(h.StdDev()),
+		"min_ms":  durationMs(h.MinDuration()),
+		"max_ms":  durationMs(h.MaxDuration()),
+		"p50_ms":  durationMs(h.Quantile(0.50)),
+		"p90_ms":  durationMs(h.Quantile(0.90)),
+		"p95_ms":  durationMs(h.Quantile(0.95)),
+		"p99_ms":  durationMs(h.Quantile(0.99)),
+		"p999_ms": durationMs(h.Quantile(0.999)),
+	}
+}
+
+// durationMs convierte a milisegundos con tres decimales (µs)
+func durationMs(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())) / 1000
 }
 
 // MetricsManager gestiona métricas de todos los endpoints
 type MetricsManager struct {
-	mu      sync.RWMutex
-	metrics map[string]*RequestMetrics
+	mu            sync.RWMutex
+	metrics       map[string]*RequestMetrics
+	parseFailures *ReasonCounter // Peticiones que no llegaron a parsearse, por motivo
+	created       time.Time
 }
 
 // NewMetricsManager crea un nuevo gestor de métricas
 func NewMetricsManager() *MetricsManager {
 	return &MetricsManager{
 		metrics: make(map[string]*RequestMetrics),
+		parseFailures: NewReasonCounter(KillMalformed, KillHeaderTooBig, KillBodyTooLarge,
+			KillHeaderTimeout, KillBodyTooSlow),
+		created: time.Now(),
 	}
 }
 
+// RecordParseFailure registra una petición que no se pudo parsear; no tiene endpoint
+func (mm *MetricsManager) RecordParseFailure(reason string) {
+	mm.parseFailures.Increment(reason)
+}
+
+// ParseFailures retorna las peticiones no parseadas por motivo
+func (mm *MetricsManager) ParseFailures() map[string]int64 {
+	return mm.parseFailures.Snapshot()
+}
+
 // GetOrCreate obtiene o crea métricas para un endpoint
 func (mm *MetricsManager) GetOrCreate(endpoint string) *RequestMetrics {
+	mm.mu.RLock()
+	metrics, exists := mm.metrics[endpoint]
+	mm.mu.RUnlock()
+	if exists {
+		return metrics
+	}
+
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
 
@@ -124,99 +234,80 @@ func (mm *MetricsManager) GetOrCreate(endpoint string) *RequestMetrics {
 		return metrics
 	}
 
-	metrics := NewRequestMetrics(endpoint)
+	metrics = NewRequestMetrics(endpoint)
 	mm.metrics[endpoint] = metrics
 	return metrics
 }
 
+// each recorre las métricas de cada endpoint en orden de clave
+func (mm *MetricsManager) each(fn func(key string, metrics *RequestMetrics)) {
+	mm.mu.RLock()
+	all := make(map[string]*RequestMetrics, len(mm.metrics))
+	for key, metrics := range mm.metrics {
+		all[key] = metrics
+	}
+	mm.mu.RUnlock()
+
+	for _, key := range sortedKeys(all) {
+		fn(key, all[key])
+	}
+}
+
 // GetAllStats retorna estadísticas de todos los endpoints
 func (mm *MetricsManager) GetAllStats() map[string]interface{} {
+	return mm.GetAllStatsWindow(DefaultStatsWindow)
+}
+
+// GetAllStatsWindow retorna estadísticas de todos los endpoints sobre la ventana dada
+func (mm *MetricsManager) GetAllStatsWindow(window time.Duration) map[string]interface{} {
 	mm.mu.RLock()
 	defer mm.mu.RUnlock()
 
 	allStats := make(map[string]interface{})
 
 	for endpoint, metrics := range mm.metrics {
-		allStats[endpoint] = metrics.GetStats()
+		allStats[endpoint] = metrics.GetStatsWindow(window)
 	}
 
 	return allStats
 }
 
-// Funciones auxiliares para cálculos estadísticos
+// GlobalStatsWindow combina todos los endpoints: tasas y cuantiles sobre la ventana dada
+// y un resumen de cada una de StatsWindows
+func (mm *MetricsManager) GlobalStatsWindow(window time.Duration) map[string]interface{} {
+	stats := mm.globalTraffic(window)
+	stats["window"] = formatWindow(window)
 
-func calculateAverage(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	sum := 0.0
-	for _, v := range values {
-		sum += v
+	windows := make(map[string]interface{}, len(StatsWindows))
+	for _, w := range StatsWindows {
+		windows[formatWindow(w)] = mm.globalTraffic(w)
 	}
-	return math.Round(sum/float64(len(values))*100) / 100
+	stats["windows"] = windows
+	return stats
 }
 
-func calculateStdDev(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	avg := calculateAverage(values)
-	variance := 0.0
-	for _, v := range values {
-		diff := v - avg
-		variance += diff * diff
-	}
-	variance /= float64(len(values))
-	return math.Round(math.Sqrt(variance)*100) / 100
-}
-
-func calculateMin(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	min := values[0]
-	for _, v := range values {
-		if v < min {
-			min = v
-		}
-	}
-	return math.Round(min*100) / 100
-}
-
-func calculateMax(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	max := values[0]
-	for _, v := range values {
-		if v > max {
-			max = v
+// globalTraffic suma los contadores y mergea los histogramas de todos los endpoints
+func (mm *MetricsManager) globalTraffic(window time.Duration) map[string]interface{} {
+	counts := make([]int64, trafficCounters)
+	exec := newHistogramSnapshot()
+	mm.each(func(_ string, metrics *RequestMetrics) {
+		for i, count := range metrics.traffic.Sums(window) {
+			counts[i] += count
 		}
-	}
-	return math.Round(max*100) / 100
+		exec.Merge(metrics.execTimes.Snapshot(window))
+	})
+	covered := coveredWindow(DefaultHistogramWindowConfig(), mm.created, time.Now(), window)
+	return trafficStats(counts, covered, exec)
 }
 
-func calculatePercentile(values []float64, percentile float64) float64 {
-	if len(values) == 0 {
-		return 0
+// formatWindow formatea una ventana como en ?window= ("5m", "90s", "1h")
+func formatWindow(window time.Duration) string {
+	s := window.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
 	}
-
-	// Copiar y ordenar
-	sorted := make([]float64, len(values))
-	copy(sorted, values)
-
-	// Bubble sort simple (para arrays pequeños está bien)
-	for i := 0; i < len(sorted); i++ {
-		for j := i + 1; j < len(sorted); j++ {
-			if sorted[i] > sorted[j] {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
-			}
-		}
-	}
-
-	index := int(float64(len(sorted)) * percentile)
-	if index >= len(sorted) {
-		index = len(sorted) - 1
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
 	}
-	return math.Round(sorted[index]*100) / 100
+	return s
 }
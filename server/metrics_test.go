@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRequestMetricsStatusClasses(t *testing.T) {
+	rm := NewRequestMetrics("GET /x")
+	for _, status := range []int{200, 200, 201, 404, 500, 503, 700} {
+		rm.RecordResponse(status, 10, 100)
+	}
+
+	stats := rm.GetStats()
+	if stats["responses"].(int64) != 7 {
+		t.Errorf("Expected 7 responses, got %v", stats["responses"])
+	}
+	classes := stats["status_classes"].(map[string]int64)
+	if classes["2xx"] != 3 || classes["4xx"] != 1 || classes["5xx"] != 2 || classes["other"] != 1 {
+		t.Errorf("Unexpected status classes: %v", classes)
+	}
+	codes := stats["status_codes"].(map[string]int64)
+	if codes["200"] != 2 || codes["503"] != 1 {
+		t.Errorf("Unexpected status codes: %v", codes)
+	}
+	if ratio := stats["error_ratio"].(float64); ratio != 0.2857 {
+		t.Errorf("Expected error ratio 0.2857, got %v", ratio)
+	}
+	if stats["bytes_in"].(int64) != 70 || stats["bytes_out"].(int64) != 700 {
+		t.Errorf("Unexpected bytes: in=%v out=%v", stats["bytes_in"], stats["bytes_out"])
+	}
+}
+
+func TestServerMetricsByRoutePattern(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/fail", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 500, StatusText: "Internal Server Error", Body: "boom"}
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	sendRaw(t, srv.Addr(), "GET /fail HTTP/1.1\r\nHost: test\r\n\r\n")
+	for i := 0; i < 20; i++ {
+		sendRaw(t, srv.Addr(), fmt.Sprintf("GET /random/%d HTTP/1.1\r\nHost: test\r\n\r\n", i))
+	}
+	if code, _ := sendRaw(t, srv.Addr(), "GARBAGE\r\n\r\n"); code != 400 {
+		t.Fatalf("Expected 400 for malformed request, got %d", code)
+	}
+
+	// Las rutas desconocidas se agrupan en una sola entrada
+	all := srv.metricsManager.GetAllStats()
+	if len(all) != 2 {
+		t.Errorf("Expected 2 endpoints (/fail and unmatched), got %d: %v", len(all), all)
+	}
+	unmatched, ok := all["GET "+unmatchedEndpoint].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Missing unmatched endpoint in %v", all)
+	}
+	if codes := unmatched["status_codes"].(map[string]int64); codes["404"] != 20 {
+		t.Errorf("Expected 20 404s on unmatched, got %v", codes)
+	}
+
+	fail := all["GET /fail"].(map[string]interface{})
+	if fail["error_ratio"].(float64) != 1 {
+		t.Errorf("Expected error ratio 1 on /fail, got %v", fail["error_ratio"])
+	}
+	if fail["bytes_in"].(int64) <= 0 || fail["bytes_out"].(int64) <= 0 {
+		t.Errorf("Expected bytes to be recorded, got in=%v out=%v", fail["bytes_in"], fail["bytes_out"])
+	}
+
+	if failures := srv.metricsManager.ParseFailures(); failures[KillMalformed] != 1 {
+		t.Errorf("Expected 1 malformed parse failure, got %v", failures)
+	}
+}
@@ -0,0 +1,439 @@
+package server
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParamType es el tipo de un parámetro documentado
+type ParamType string
+
+const (
+	ParamString  ParamType = "string"
+	ParamInteger ParamType = "integer"
+	ParamNumber  ParamType = "number"
+	ParamBoolean ParamType = "boolean"
+)
+
+// Param describe un parámetro de una ruta (query por defecto)
+type Param struct {
+	Name        string
+	In          string // "query" (por defecto) o "header"
+	Type        ParamType
+	Description string
+	Required    bool
+	Min         *float64
+	Max         *float64
+	Enum        []string
+	Pattern     string // Expresión regular que debe cumplir el valor (texto)
+	AllowEmpty  bool   // Un valor vacío cuenta como presente (por defecto equivale a ausente)
+	Default     string
+	Example     string
+
+	re *regexp.Regexp
+}
+
+// StringParam declara un parámetro de texto
+func StringParam(name, description string) Param {
+	return Param{Name: name, Type: ParamString, Description: description}
+}
+
+// IntParam declara un parámetro entero (acotar con Between o AtLeast)
+func IntParam(name, description string) Param {
+	return Param{Name: name, Type: ParamInteger, Description: description}
+}
+
+// FloatParam declara un parámetro numérico
+func FloatParam(name, description string) Param {
+	return Param{Name: name, Type: ParamNumber, Description: description}
+}
+
+// BoolParam declara un parámetro booleano (true/false, 1/0)
+func BoolParam(name, description string) Param {
+	return Param{Name: name, Type: ParamBoolean, Description: description}
+}
+
+// EnumParam declara un parámetro de texto que solo admite los valores dados
+func EnumParam(name, description string, values ...string) Param {
+	return Param{Name: name, Type: ParamString, Description: description, Enum: values}
+}
+
+// Require marca el parámetro como obligatorio
+func (p Param) Require() Param {
+	p.Required = true
+	return p
+}
+
+// Between acota un parámetro numérico a [min, max]
+func (p Param) Between(min, max float64) Param {
+	p.Min, p.Max = &min, &max
+	return p
+}
+
+// AtLeast fija el mínimo de un parámetro numérico
+func (p Param) AtLeast(min float64) Param {
+	p.Min = &min
+	return p
+}
+
+// Matching exige que el valor cumpla la expresión regular; entra en pánico si no compila
+func (p Param) Matching(expr string) Param {
+	p.Pattern = expr
+	p.re = regexp.MustCompile(expr)
+	return p
+}
+
+// AllowingEmpty acepta un valor vacío (p. ej. text= en /reverse) como presente
+func (p Param) AllowingEmpty() Param {
+	p.AllowEmpty = true
+	return p
+}
+
+// WithDefault fija el valor que se usa si el parámetro falta
+func (p Param) WithDefault(value string) Param {
+	p.Default = value
+	return p
+}
+
+// WithExample documenta un valor de ejemplo
+func (p Param) WithExample(value string) Param {
+	p.Example = value
+	return p
+}
+
+// Response describe una respuesta posible de una ruta
+type Response struct {
+	Status      int
+	Description string
+	ContentType string      // Por defecto application/json (application/problem+json en errores sin ejemplo)
+	Example     interface{} // El schema se infiere del ejemplo
+}
+
+// RouteDoc es la metadata opcional de una ruta para la especificación OpenAPI
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []Param
+	Responses   []Response
+	Deprecated  bool
+}
+
+// APIInfo es el bloque info de la especificación
+type APIInfo struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// SetAPIInfo define título, versión y descripción de la especificación OpenAPI
+func (s *Server) SetAPIInfo(info APIInfo) {
+	s.docsMu.Lock()
+	defer s.docsMu.Unlock()
+	s.apiInfo = info
+}
+
+// RouteDocFor retorna la metadata registrada para una ruta
+func (s *Server) RouteDocFor(method, path string) (RouteDoc, bool) {
+	s.docsMu.RLock()
+	defer s.docsMu.RUnlock()
+	doc, ok := s.routeDocs[method+" "+path]
+	return doc, ok
+}
+
+// setRouteDoc guarda la metadata de una ruta
+func (s *Server) setRouteDoc(method, path string, doc RouteDoc) {
+	s.docsMu.Lock()
+	defer s.docsMu.Unlock()
+	s.routeDocs[method+" "+path] = doc
+}
+
+// OpenAPISpec genera el documento OpenAPI 3 de todas las rutas registradas. Las rutas sin
+// metadata aparecen igual, con un resumen genérico.
+func (s *Server) OpenAPISpec() map[string]interface{} {
+	s.docsMu.RLock()
+	info := s.apiInfo
+	s.docsMu.RUnlock()
+	if info.Title == "" {
+		info.Title = "GoDocker HTTP Service"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+
+	paths := make(map[string]interface{})
+	for _, route := range s.router.Routes() {
+		doc, _ := s.RouteDocFor(route.Method, route.Path)
+		item, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = s.openAPIOperation(route.Method, route.Path, doc)
+	}
+
+	infoBlock := map[string]interface{}{"title": info.Title, "version": info.Version}
+	if info.Description != "" {
+		infoBlock["description"] = info.Description
+	}
+	spec := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    infoBlock,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Problem": map[string]interface{}{
+					"type":        "object",
+					"description": "Error RFC 7807; según el error puede traer campos extra (invalid_params, retry_after_s, ...)",
+					"properties": map[string]interface{}{
+						"type":       map[string]interface{}{"type": "string"},
+						"title":      map[string]interface{}{"type": "string"},
+						"status":     map[string]interface{}{"type": "integer"},
+						"detail":     map[string]interface{}{"type": "string"},
+						"instance":   map[string]interface{}{"type": "string"},
+						"request_id": map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"type", "title", "status"},
+					"additionalProperties": true,
+				},
+			},
+		},
+	}
+	if s.authManager != nil {
+		spec["components"].(map[string]interface{})["securitySchemes"] = map[string]interface{}{
+			"ApiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": APIKeyHeader},
+			"BearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		}
+	}
+	return spec
+}
+
+// openAPIOperation construye el objeto operation de una ruta
+func (s *Server) openAPIOperation(method, path string, doc RouteDoc) map[string]interface{} {
+	summary := doc.Summary
+	if summary == "" {
+		summary = method + " " + path
+	}
+	op := map[string]interface{}{
+		"summary":     summary,
+		"operationId": operationID(method, path),
+	}
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+	if len(doc.Tags) > 0 {
+		op["tags"] = doc.Tags
+	}
+	if doc.Deprecated {
+		op["deprecated"] = true
+	}
+
+	if len(doc.Params) > 0 {
+		params := make([]interface{}, 0, len(doc.Params))
+		for _, p := range doc.Params {
+			params = append(params, p.openAPI())
+		}
+		op["parameters"] = params
+	}
+
+	responses := make(map[string]interface{})
+	for _, r := range doc.Responses {
+		responses[strconv.Itoa(r.Status)] = r.openAPI()
+	}
+	if len(responses) == 0 {
+		responses["200"] = map[string]interface{}{"description": "OK"}
+	}
+	// Respuestas que agrega el servidor, no el handler
+	if len(doc.Params) > 0 {
+		addErrorResponse(responses, 400, "Parámetros inválidos")
+	}
+	if s.authManager != nil && !s.authManager.isPublic(method, path) {
+		addErrorResponse(responses, 401, "Faltan credenciales o son inválidas")
+		addErrorResponse(responses, 403, "La identidad no tiene acceso a la ruta")
+		op["security"] = []interface{}{
+			map[string]interface{}{"ApiKeyAuth": []string{}},
+			map[string]interface{}{"BearerAuth": []string{}},
+		}
+	}
+	if !s.bypassesQueue(&HTTPRequest{Method: method, Path: path}) {
+		addErrorResponse(responses, 503, "Servidor saturado, en shutdown o circuit breaker abierto (ver Retry-After)")
+	}
+	op["responses"] = responses
+	return op
+}
+
+// addErrorResponse agrega una respuesta de error estándar si la ruta no documentó una propia
+func addErrorResponse(responses map[string]interface{}, status int, description string) {
+	key := strconv.Itoa(status)
+	if _, ok := responses[key]; ok {
+		return
+	}
+	responses[key] = map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			ProblemContentType: map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/Problem"},
+			},
+		},
+	}
+}
+
+// openAPI retorna el objeto parameter
+func (p Param) openAPI() map[string]interface{} {
+	in := p.In
+	if in == "" {
+		in = "query"
+	}
+	typ := p.Type
+	if typ == "" {
+		typ = ParamString
+	}
+
+	schema := map[string]interface{}{"type": string(typ)}
+	if p.Min != nil {
+		schema["minimum"] = *p.Min
+	}
+	if p.Max != nil {
+		schema["maximum"] = *p.Max
+	}
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum
+	}
+	if p.Pattern != "" {
+		schema["pattern"] = p.Pattern
+	}
+	if p.Default != "" {
+		schema["default"] = typedValue(typ, p.Default)
+	}
+
+	param := map[string]interface{}{
+		"name":     p.Name,
+		"in":       in,
+		"required": p.Required,
+		"schema":   schema,
+	}
+	if p.Description != "" {
+		param["description"] = p.Description
+	}
+	if p.AllowEmpty {
+		param["allowEmptyValue"] = true
+	}
+	if p.Example != "" {
+		param["example"] = typedValue(typ, p.Example)
+	}
+	return param
+}
+
+// typedValue convierte un valor de texto al tipo del parámetro para el documento
+func typedValue(typ ParamType, value string) interface{} {
+	switch typ {
+	case ParamInteger:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case ParamNumber:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case ParamBoolean:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// openAPI retorna el objeto response
+func (r Response) openAPI() map[string]interface{} {
+	description := r.Description
+	if description == "" {
+		description = strconv.Itoa(r.Status)
+	}
+	resp := map[string]interface{}{"description": description}
+
+	contentType := r.ContentType
+	if contentType == "" && r.Status >= 400 && r.Example == nil {
+		// Los errores sin contenido propio son problemas RFC 7807
+		resp["content"] = map[string]interface{}{ProblemContentType: map[string]interface{}{
+			"schema": map[string]interface{}{"$ref": "#/components/schemas/Problem"},
+		}}
+		return resp
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	media := map[string]interface{}{}
+	if r.Example != nil {
+		media["schema"] = schemaOf(reflect.ValueOf(r.Example))
+		media["example"] = r.Example
+	} else if contentType != "application/json" {
+		media["schema"] = map[string]interface{}{"type": "string"}
+	}
+	resp["content"] = map[string]interface{}{contentType: media}
+	return resp
+}
+
+// schemaOf infiere un JSON Schema a partir de un valor de ejemplo
+func schemaOf(v reflect.Value) map[string]interface{} {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return map[string]interface{}{}
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		items := map[string]interface{}{}
+		if v.Len() > 0 {
+			items = schemaOf(v.Index(0))
+		} else if elem := v.Type().Elem(); elem.Kind() != reflect.Interface {
+			items = schemaOf(reflect.Zero(elem))
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	case reflect.Map:
+		props := make(map[string]interface{}, v.Len())
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			props[key.String()] = schemaOf(v.MapIndex(key))
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+	return map[string]interface{}{}
+}
+
+// operationID arma un identificador único: "GET /jobs/status" -> "getJobsStatus"
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if r == '/' || r == '_' || r == '-' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	if path == "/" {
+		b.WriteString("Root")
+	}
+	return b.String()
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func okHandler(req *HTTPRequest) *HTTPResponse {
+	return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 1)
+	srv.SetAPIInfo(APIInfo{Title: "Test", Version: "2.0"})
+	srv.HandleFunc("GET", "/pi", okHandler, RouteDoc{
+		Summary: "Dígitos de pi",
+		Tags:    []string{"cpu"},
+		Params: []Param{
+			IntParam("digits", "Cantidad").Between(1, 1000).Require().WithExample("50"),
+			EnumParam("mode", "Modo", "fast", "slow").WithDefault("fast"),
+		},
+		Responses: []Response{{Status: 200, Example: map[string]interface{}{"digits": 2, "pi": "3.14"}}},
+	})
+	srv.HandleFunc("GET", "/ping", okHandler)
+	srv.BypassQueue("GET", "/ping")
+
+	// El documento debe ser JSON válido
+	raw, err := json.Marshal(srv.OpenAPISpec())
+	if err != nil {
+		t.Fatalf("Failed to marshal spec: %v", err)
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Summary     string                   `json:"summary"`
+			OperationID string                   `json:"operationId"`
+			Tags        []string                 `json:"tags"`
+			Security    []interface{}            `json:"security"`
+			Parameters  []map[string]interface{} `json:"parameters"`
+			Responses   map[string]struct {
+				Content map[string]struct {
+					Schema  map[string]interface{} `json:"schema"`
+					Example interface{}            `json:"example"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatalf("Invalid spec JSON: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" || spec.Info.Title != "Test" || spec.Info.Version != "2.0" {
+		t.Errorf("Unexpected header: %s %+v", spec.OpenAPI, spec.Info)
+	}
+
+	pi := spec.Paths["/pi"]["get"]
+	if pi.Summary != "Dígitos de pi" || pi.OperationID != "getPi" || !reflect.DeepEqual(pi.Tags, []string{"cpu"}) {
+		t.Errorf("Unexpected operation: %+v", pi)
+	}
+	if len(pi.Parameters) != 2 {
+		t.Fatalf("Expected 2 parameters, got %v", pi.Parameters)
+	}
+	digits := pi.Parameters[0]
+	schema := digits["schema"].(map[string]interface{})
+	if digits["in"] != "query" || digits["required"] != true || digits["example"] != float64(50) ||
+		schema["type"] != "integer" || schema["minimum"] != float64(1) || schema["maximum"] != float64(1000) {
+		t.Errorf("Unexpected digits parameter: %v", digits)
+	}
+	mode := pi.Parameters[1]["schema"].(map[string]interface{})
+	if mode["default"] != "fast" || len(mode["enum"].([]interface{})) != 2 {
+		t.Errorf("Unexpected mode schema: %v", mode)
+	}
+
+	// 400 por tener parámetros y 503 por pasar por la cola; sin auth no hay 401
+	for _, code := range []string{"200", "400", "503"} {
+		if _, ok := pi.Responses[code]; !ok {
+			t.Errorf("Expected response %s in /pi, got %v", code, pi.Responses)
+		}
+	}
+	if _, ok := pi.Responses["401"]; ok || pi.Security != nil {
+		t.Error("Did not expect auth responses without an AuthManager")
+	}
+	schema = pi.Responses["200"].Content["application/json"].Schema
+	props := schema["properties"].(map[string]interface{})
+	if schema["type"] != "object" || props["digits"].(map[string]interface{})["type"] != "integer" ||
+		props["pi"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Unexpected inferred schema: %v", schema)
+	}
+
+	// Sin metadata: resumen genérico, sin 400 y sin 503 porque no pasa por la cola
+	ping := spec.Paths["/ping"]["get"]
+	if ping.Summary != "GET /ping" {
+		t.Errorf("Expected generic summary, got %q", ping.Summary)
+	}
+	for _, code := range []string{"400", "503"} {
+		if _, ok := ping.Responses[code]; ok {
+			t.Errorf("Did not expect %s in /ping", code)
+		}
+	}
+}
+
+func TestOpenAPISpecAuth(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 1)
+	auth := NewAuthManager()
+	auth.AllowPublic([]string{"GET"}, "/public")
+	srv.SetAuthManager(auth)
+	srv.HandleFunc("GET", "/public", okHandler)
+	srv.HandleFunc("POST", "/private", okHandler)
+
+	spec := srv.OpenAPISpec()
+	components := spec["components"].(map[string]interface{})
+	if _, ok := components["securitySchemes"]; !ok {
+		t.Error("Expected security schemes with auth enabled")
+	}
+
+	paths := spec["paths"].(map[string]interface{})
+	private := paths["/private"].(map[string]interface{})["post"].(map[string]interface{})
+	responses := private["responses"].(map[string]interface{})
+	if _, ok := responses["401"]; !ok || private["security"] == nil {
+		t.Errorf("Expected 401 and security on a protected route, got %v", private)
+	}
+	public := paths["/public"].(map[string]interface{})["get"].(map[string]interface{})
+	if _, ok := public["responses"].(map[string]interface{})["401"]; ok || public["security"] != nil {
+		t.Errorf("Did not expect auth on a public route, got %v", public)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	tests := map[string]string{
+		"GET /":                 "getRoot",
+		"GET /jobs/status":      "getJobsStatus",
+		"DELETE /jobs/cancel":   "deleteJobsCancel",
+		"GET /debug/pprof/heap": "getDebugPprofHeap",
+		"GET /openapi.json":     "getOpenapiJson",
+		"GET /max_iter":         "getMaxIter",
+	}
+	for route, want := range tests {
+		method, path, _ := strings.Cut(route, " ")
+		if got := operationID(method, path); got != want {
+			t.Errorf("operationID(%s) = %s, want %s", route, got, want)
+		}
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := schemaOf(reflect.ValueOf(map[string]interface{}{
+		"list":   []int{1},
+		"empty":  []string{},
+		"nested": map[string]interface{}{"ok": true},
+		"ratio":  0.5,
+	}))
+	props := schema["properties"].(map[string]interface{})
+	if props["list"].(map[string]interface{})["items"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("Unexpected list schema: %v", props["list"])
+	}
+	if props["empty"].(map[string]interface{})["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Unexpected empty list schema: %v", props["empty"])
+	}
+	nested := props["nested"].(map[string]interface{})["properties"].(map[string]interface{})
+	if nested["ok"].(map[string]interface{})["type"] != "boolean" {
+		t.Errorf("Unexpected nested schema: %v", nested)
+	}
+	if props["ratio"].(map[string]interface{})["type"] != "number" {
+		t.Errorf("Unexpected number schema: %v", props["ratio"])
+	}
+}
+
+func TestParamOpenAPIPatternAndEmpty(t *testing.T) {
+	param := StringParam("id", "ID").Matching(`^job-[0-9]+$`).AllowingEmpty().openAPI()
+	schema := param["schema"].(map[string]interface{})
+	if schema["pattern"] != `^job-[0-9]+$` || param["allowEmptyValue"] != true {
+		t.Errorf("Unexpected parameter: %v", param)
+	}
+	if schema := FloatParam("ratio", "").openAPI()["schema"].(map[string]interface{}); schema["type"] != "number" {
+		t.Errorf("Expected number schema, got %v", schema)
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParamError describe por qué un parámetro es inválido
+type ParamError struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ParamValues son los parámetros de una petición ya validados y convertidos a su tipo
+type ParamValues struct {
+	values map[string]interface{}
+}
+
+// Has indica si el parámetro llegó en la petición o tiene un valor por defecto
+func (v ParamValues) Has(name string) bool {
+	_, ok := v.values[name]
+	return ok
+}
+
+// String retorna un parámetro de texto ("" si no está)
+func (v ParamValues) String(name string) string {
+	s, _ := v.values[name].(string)
+	return s
+}
+
+// Int retorna un parámetro entero (0 si no está)
+func (v ParamValues) Int(name string) int {
+	n, _ := v.values[name].(int)
+	return n
+}
+
+// Float retorna un parámetro numérico (0 si no está)
+func (v ParamValues) Float(name string) float64 {
+	f, _ := v.values[name].(float64)
+	return f
+}
+
+// Bool retorna un parámetro booleano (false si no está)
+func (v ParamValues) Bool(name string) bool {
+	b, _ := v.values[name].(bool)
+	return b
+}
+
+// BindParams valida params contra las especificaciones y convierte cada valor a su tipo.
+// No se detiene en el primer error: retorna todos, en el orden de specs. Los parámetros
+// que no figuran en specs se ignoran (los jobs, por ejemplo, pasan task y prio).
+func BindParams(params map[string]string, specs []Param) (ParamValues, []ParamError) {
+	values := ParamValues{values: make(map[string]interface{}, len(specs))}
+	var errs []ParamError
+
+	for _, spec := range specs {
+		raw, ok := params[spec.Name]
+		if raw == "" && !spec.AllowEmpty {
+			ok = false
+		}
+		if !ok {
+			switch {
+			case spec.Required:
+				errs = append(errs, ParamError{spec.Name, "is required"})
+				continue
+			case spec.Default == "":
+				continue
+			}
+			raw = spec.Default
+		}
+
+		value, reason := spec.convert(raw)
+		if reason != "" {
+			errs = append(errs, ParamError{spec.Name, reason})
+			continue
+		}
+		values.values[spec.Name] = value
+	}
+	return values, errs
+}
+
+// Bind valida los parámetros de la petición; si alguno es inválido retorna la respuesta 400
+// que los lista a todos
+func Bind(req *HTTPRequest, specs ...Param) (ParamValues, *HTTPResponse) {
+	values, errs := BindParams(req.Params, specs)
+	if len(errs) > 0 {
+		return values, InvalidParamsResponse(errs)
+	}
+	return values, nil
+}
+
+// InvalidParamsResponse construye el 400 uniforme para parámetros inválidos
+func InvalidParamsResponse(errs []ParamError) *HTTPResponse {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Name + " " + e.Reason
+	}
+	return NewProblem(400, "invalid query parameters: "+strings.Join(messages, "; ")).
+		WithType(ProblemInvalidParams).
+		With("invalid_params", errs).
+		Response()
+}
+
+// convert parsea raw según el tipo del parámetro y verifica sus restricciones; retorna el
+// motivo si no las cumple
+func (p Param) convert(raw string) (interface{}, string) {
+	switch p.Type {
+	case ParamInteger:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, "must be an integer"
+		}
+		if reason := p.checkRange(float64(n)); reason != "" {
+			return nil, reason
+		}
+		return n, ""
+
+	case ParamNumber:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, "must be a number"
+		}
+		if reason := p.checkRange(f); reason != "" {
+			return nil, reason
+		}
+		return f, ""
+
+	case ParamBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, "must be true or false"
+		}
+		return b, ""
+	}
+
+	if len(p.Enum) > 0 && !containsString(p.Enum, raw) {
+		return nil, "must be one of: " + strings.Join(p.Enum, ", ")
+	}
+	if p.Pattern != "" {
+		re := p.re
+		if re == nil {
+			// Param armado sin Matching: se compila en cada uso
+			var err error
+			if re, err = regexp.Compile(p.Pattern); err != nil {
+				return nil, "has an invalid pattern"
+			}
+		}
+		if !re.MatchString(raw) {
+			return nil, "must match " + p.Pattern
+		}
+	}
+	return raw, ""
+}
+
+// checkRange verifica Min y Max
+func (p Param) checkRange(v float64) string {
+	switch {
+	case p.Min != nil && p.Max != nil && (v < *p.Min || v > *p.Max):
+		return fmt.Sprintf("must be between %s and %s", formatBound(*p.Min), formatBound(*p.Max))
+	case p.Min != nil && v < *p.Min:
+		return "must be at least " + formatBound(*p.Min)
+	case p.Max != nil && v > *p.Max:
+		return "must be at most " + formatBound(*p.Max)
+	}
+	return ""
+}
+
+// formatBound imprime un límite sin decimales innecesarios
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// containsString indica si values contiene s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBindParamsTypes(t *testing.T) {
+	specs := []Param{
+		IntParam("n", "").Between(1, 10).Require(),
+		FloatParam("ratio", "").AtLeast(0),
+		BoolParam("verbose", ""),
+		EnumParam("algo", "", "merge", "quick").WithDefault("quick"),
+		StringParam("text", "").Require().AllowingEmpty(),
+		StringParam("optional", ""),
+	}
+
+	values, errs := BindParams(map[string]string{"n": "7", "ratio": "0.5", "verbose": "true", "text": "", "extra": "x"}, specs)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if values.Int("n") != 7 || values.Float("ratio") != 0.5 || !values.Bool("verbose") {
+		t.Errorf("Unexpected typed values: %+v", values)
+	}
+	// Default para el enum ausente; text vacío cuenta como presente
+	if values.String("algo") != "quick" || !values.Has("text") || values.String("text") != "" {
+		t.Errorf("Unexpected string values: %+v", values)
+	}
+	if values.Has("optional") || values.Has("extra") {
+		t.Error("Absent optional and unknown params should not be bound")
+	}
+
+	// Un valor vacío equivale a ausente: toma el default
+	values, errs = BindParams(map[string]string{"n": "1", "text": "a", "algo": ""}, specs)
+	if len(errs) != 0 || values.String("algo") != "quick" {
+		t.Errorf("Expected default for empty enum, got %v %v", values.String("algo"), errs)
+	}
+}
+
+func TestBindParamsErrors(t *testing.T) {
+	specs := []Param{
+		IntParam("n", "").Between(1, 1000).Require(),
+		IntParam("size", "").AtLeast(1),
+		IntParam("limit", "").Between(0, 5),
+		FloatParam("ratio", ""),
+		BoolParam("verbose", ""),
+		EnumParam("codec", "", "gzip", "xz").Require(),
+		StringParam("id", "").Matching(`^job-[0-9]+$`),
+		StringParam("name", "").Require(),
+	}
+	params := map[string]string{
+		"n": "abc", "size": "0", "limit": "9", "ratio": "x", "verbose": "maybe", "codec": "bzip2", "id": "42", "name": "",
+	}
+
+	_, errs := BindParams(params, specs)
+	want := []ParamError{
+		{"n", "must be an integer"},
+		{"size", "must be at least 1"},
+		{"limit", "must be between 0 and 5"},
+		{"ratio", "must be a number"},
+		{"verbose", "must be true or false"},
+		{"codec", "must be one of: gzip, xz"},
+		{"id", "must match ^job-[0-9]+$"},
+		{"name", "is required"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Unexpected errors:\n got %v\nwant %v", errs, want)
+	}
+}
+
+func TestBindResponse(t *testing.T) {
+	req := &HTTPRequest{Method: "GET", Path: "/matrixmul", Params: map[string]string{"size": "5000"}}
+	_, resp := Bind(req,
+		IntParam("size", "").Between(1, 200).Require(),
+		IntParam("seed", "").Require(),
+	)
+	if resp == nil || resp.StatusCode != 400 || resp.Headers["Content-Type"] != ProblemContentType {
+		t.Fatalf("Expected a 400 problem response, got %+v", resp)
+	}
+
+	var body struct {
+		Type          string       `json:"type"`
+		Detail        string       `json:"detail"`
+		InvalidParams []ParamError `json:"invalid_params"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if body.Type != ProblemInvalidParams || body.Detail != "invalid query parameters: size must be between 1 and 200; seed is required" || len(body.InvalidParams) != 2 {
+		t.Errorf("Unexpected body: %s", resp.Body)
+	}
+
+	req.Params = map[string]string{"size": "3", "seed": "1"}
+	if values, resp := Bind(req, IntParam("size", "").Require(), IntParam("seed", "").Require()); resp != nil || values.Int("size") != 3 {
+		t.Errorf("Expected valid params to bind, got %+v", resp)
+	}
+}
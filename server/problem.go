@@ -0,0 +1,361 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProblemContentType es el media type de los errores (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// RequestIDHeader es el header con el que se recibe y se devuelve el ID de la petición
+const RequestIDHeader = "X-Request-Id"
+
+// Tipos de problema propios; el resto usa "about:blank" (el status alcanza para entenderlo)
+const (
+	ProblemInvalidParams    = "urn:gohttp:problem:invalid-params"
+	ProblemOverloaded       = "urn:gohttp:problem:overloaded"
+	ProblemCircuitOpen      = "urn:gohttp:problem:circuit-open"
+	ProblemRequestCancelled = "urn:gohttp:problem:request-cancelled"
+)
+
+// Problem es una respuesta de error en formato RFC 7807. Los campos extra (p. ej.
+// invalid_params o retry_after_s) se serializan al mismo nivel que los estándar.
+type Problem struct {
+	Type      string
+	Title     string
+	Status    int
+	Detail    string
+	Instance  string // Por defecto el path de la petición
+	RequestID string // Lo completa el servidor al responder
+	Extra     map[string]interface{}
+
+	headers map[string]string
+}
+
+// NewProblem crea un problema con el título estándar del status
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// WithType define el URI del tipo de problema
+func (p *Problem) WithType(uri string) *Problem {
+	p.Type = uri
+	return p
+}
+
+// With agrega un campo extra; no puede pisar los campos estándar
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extra == nil {
+		p.Extra = make(map[string]interface{})
+	}
+	p.Extra[key] = value
+	return p
+}
+
+// WithHeader agrega un header a la respuesta (p. ej. Retry-After o Allow)
+func (p *Problem) WithHeader(name, value string) *Problem {
+	if p.headers == nil {
+		p.headers = make(map[string]string)
+	}
+	p.headers[name] = value
+	return p
+}
+
+// problemReserved son los campos estándar que With no puede reemplazar
+var problemReserved = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true, "instance": true, "request_id": true,
+}
+
+// MarshalJSON serializa los campos estándar y los extra en un solo objeto
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.Extra)+6)
+	for key, value := range p.Extra {
+		if !problemReserved[key] {
+			fields[key] = value
+		}
+	}
+	fields["type"] = p.Type
+	fields["title"] = p.Title
+	fields["status"] = p.Status
+	if p.Detail != "" {
+		fields["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		fields["instance"] = p.Instance
+	}
+	if p.RequestID != "" {
+		fields["request_id"] = p.RequestID
+	}
+	return json.Marshal(fields)
+}
+
+// Response construye la respuesta HTTP del problema. El servidor completa instance y
+// request_id al enviarla, y la convierte a HTML si el cliente lo prefiere.
+func (p *Problem) Response() *HTTPResponse {
+	body, _ := json.Marshal(p)
+
+	headers := map[string]string{"Content-Type": ProblemContentType}
+	for name, value := range p.headers {
+		headers[name] = value
+	}
+	return &HTTPResponse{
+		StatusCode: p.Status,
+		StatusText: StatusText(p.Status),
+		Body:       string(body),
+		Headers:    headers,
+		problem:    p,
+	}
+}
+
+// html renderiza el problema como una página para navegadores
+func (p *Problem) html() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%d %s</title></head>\n<body>\n",
+		p.Status, html.EscapeString(p.Title))
+	fmt.Fprintf(&b, "<h1>%d %s</h1>\n", p.Status, html.EscapeString(p.Title))
+	if p.Detail != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(p.Detail))
+	}
+
+	keys := make([]string, 0, len(p.Extra))
+	for key := range p.Extra {
+		if !problemReserved[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		b.WriteString("<dl>\n")
+		for _, key := range keys {
+			value, _ := json.Marshal(p.Extra[key])
+			fmt.Fprintf(&b, "<dt>%s</dt><dd><code>%s</code></dd>\n", html.EscapeString(key), html.EscapeString(string(value)))
+		}
+		b.WriteString("</dl>\n")
+	}
+
+	if p.Instance != "" {
+		fmt.Fprintf(&b, "<p><small>%s</small></p>\n", html.EscapeString(p.Instance))
+	}
+	if p.RequestID != "" {
+		fmt.Fprintf(&b, "<p><small>request id: %s</small></p>\n", html.EscapeString(p.RequestID))
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// ErrorResponse es el atajo para un problema sin campos extra
+func ErrorResponse(status int, detail string) *HTTPResponse {
+	return NewProblem(status, detail).Response()
+}
+
+// BadRequest responde 400
+func BadRequest(detail string) *HTTPResponse {
+	return ErrorResponse(400, detail)
+}
+
+// Forbidden responde 403
+func Forbidden(detail string) *HTTPResponse {
+	return ErrorResponse(403, detail)
+}
+
+// NotFound responde 404
+func NotFound(detail string) *HTTPResponse {
+	return ErrorResponse(404, detail)
+}
+
+// MethodNotAllowed responde 405 con el header Allow
+func MethodNotAllowed(method string, allowed []string) *HTTPResponse {
+	return NewProblem(405, fmt.Sprintf("method %s not allowed; use %s", method, strings.Join(allowed, ", "))).
+		With("allowed_methods", allowed).
+		WithHeader("Allow", strings.Join(allowed, ", ")).
+		Response()
+}
+
+// Conflict responde 409
+func Conflict(detail string) *HTTPResponse {
+	return ErrorResponse(409, detail)
+}
+
+// PayloadTooLarge responde 413
+func PayloadTooLarge(detail string) *HTTPResponse {
+	return ErrorResponse(413, detail)
+}
+
+// InternalError responde 500
+func InternalError(detail string) *HTTPResponse {
+	return ErrorResponse(500, detail)
+}
+
+// ServiceUnavailable responde 503 con Retry-After (en segundos, si es mayor que 0)
+func ServiceUnavailable(detail string, retryAfter int) *HTTPResponse {
+	return unavailableProblem(detail, retryAfter).Response()
+}
+
+// unavailableProblem arma el 503 con Retry-After para agregarle campos extra
+func unavailableProblem(detail string, retryAfter int) *Problem {
+	p := NewProblem(503, detail)
+	if retryAfter > 0 {
+		p.With("retry_after_s", retryAfter).WithHeader("Retry-After", strconv.Itoa(retryAfter))
+	}
+	return p
+}
+
+// finalizeResponse agrega el ID de la petición, serializa los resultados en el formato
+// pedido y, si la respuesta es un problema, completa instance y request_id y la renderiza
+// según el Accept del cliente. Retorna una copia: la respuesta original puede estar
+// compartida (coalescing) o en la caché.
+func finalizeResponse(req *HTTPRequest, resp *HTTPResponse) *HTTPResponse {
+	if resp.result != nil {
+		resp = encodeResult(req, resp)
+	}
+
+	out := *resp
+	out.Headers = make(map[string]string, len(resp.Headers)+1)
+	for name, value := range resp.Headers {
+		out.Headers[name] = value
+	}
+	if req.ID != "" {
+		out.Headers[RequestIDHeader] = req.ID
+	}
+
+	if resp.problem == nil {
+		return &out
+	}
+	problem := *resp.problem
+	if problem.Instance == "" {
+		problem.Instance = req.Path
+	}
+	problem.RequestID = req.ID
+
+	if Negotiate(req.GetHeader("Accept"), ProblemContentType, "application/json", "text/html") == "text/html" {
+		out.Body = problem.html()
+		out.Headers["Content-Type"] = "text/html; charset=utf-8"
+	} else {
+		body, _ := json.Marshal(&problem)
+		out.Body = string(body)
+		out.Headers["Content-Type"] = ProblemContentType
+	}
+	return &out
+}
+
+// Negotiate elige entre offers el media type que el header Accept prefiere, respetando
+// los valores q y los comodines (type/* y */*). Sin Accept, o si nada coincide, retorna
+// el primero de offers.
+func Negotiate(accept string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecificity := offers[0], 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseAcceptPart(part)
+		if mediaType == "" || q <= 0 {
+			continue
+		}
+		for _, offer := range offers {
+			specificity := acceptMatch(mediaType, offer)
+			if specificity < 0 {
+				continue
+			}
+			// Gana el q más alto; a igual q, el rango más específico; a igualdad, el orden de offers
+			if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+				best, bestQ, bestSpecificity = offer, q, specificity
+			}
+		}
+	}
+	return best
+}
+
+// parseAcceptPart separa un elemento de Accept en media type y q
+func parseAcceptPart(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+	q := 1.0
+	for _, param := range fields[1:] {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+	return mediaType, q
+}
+
+// acceptMatch retorna qué tan específico es el rango de Accept que cubre a offer
+// (2 exacto, 1 type/*, 0 */*) o -1 si no lo cubre
+func acceptMatch(mediaRange, offer string) int {
+	offer = strings.ToLower(offer)
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+// randomRead es la fuente de aleatoriedad de los IDs (reemplazable en tests)
+var randomRead = rand.Read
+
+// newRequestID genera un ID aleatorio de 16 caracteres hexadecimales. Si crypto/rand falla
+// usa la hora en nanosegundos, con el mismo formato.
+func newRequestID() string {
+	var b [8]byte
+	if _, err := randomRead(b[:]); err != nil {
+		return fmt.Sprintf("%016x", uint64(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// requestIDFrom usa el X-Request-Id del cliente si es razonable (para correlacionar con un
+// proxy) o genera uno nuevo
+func requestIDFrom(value string) string {
+	if value == "" || len(value) > 128 {
+		return newRequestID()
+	}
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return newRequestID()
+		}
+	}
+	return value
+}
+
+// statusTexts son las frases de los status que usa el servidor
+var statusTexts = map[int]string{
+	200: "OK", 201: "Created", 202: "Accepted", 204: "No Content",
+	301: "Moved Permanently", 302: "Found", 304: "Not Modified",
+	400: "Bad Request", 401: "Unauthorized", 403: "Forbidden", 404: "Not Found",
+	405: "Method Not Allowed", 406: "Not Acceptable", 408: "Request Timeout", 409: "Conflict",
+	413: "Payload Too Large", 415: "Unsupported Media Type", 422: "Unprocessable Entity",
+	429: "Too Many Requests", 431: "Request Header Fields Too Large",
+	500: "Internal Server Error", 501: "Not Implemented", 502: "Bad Gateway",
+	503: "Service Unavailable", 504: "Gateway Timeout",
+}
+
+// StatusText retorna la frase estándar de un status HTTP
+func StatusText(status int) string {
+	if text, ok := statusTexts[status]; ok {
+		return text
+	}
+	return "Status " + strconv.Itoa(status)
+}
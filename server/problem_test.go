@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProblemMarshal(t *testing.T) {
+	p := NewProblem(503, "server overloaded").
+		WithType(ProblemOverloaded).
+		With("retry_after_s", 5).
+		With("status", 200) // Los campos estándar no se pueden pisar
+	p.Instance = "/pi"
+	p.RequestID = "abc"
+
+	var body map[string]interface{}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	json.Unmarshal(data, &body)
+
+	want := map[string]interface{}{
+		"type": ProblemOverloaded, "title": "Service Unavailable", "status": 503.0,
+		"detail": "server overloaded", "instance": "/pi", "request_id": "abc", "retry_after_s": 5.0,
+	}
+	if len(body) != len(want) {
+		t.Errorf("Unexpected fields: %s", data)
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, body[key])
+		}
+	}
+
+	resp := ServiceUnavailable("busy", 3)
+	if resp.StatusCode != 503 || resp.StatusText != "Service Unavailable" || resp.Headers["Retry-After"] != "3" ||
+		resp.Headers["Content-Type"] != ProblemContentType {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestFinalizeResponseNegotiation(t *testing.T) {
+	resp := NotFound("job not found: <script>")
+	req := &HTTPRequest{Path: "/jobs/status", ID: "req-1", Headers: map[string]string{}}
+
+	out := finalizeResponse(req, resp)
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(out.Body), &body); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if out.Headers["Content-Type"] != ProblemContentType || out.Headers[RequestIDHeader] != "req-1" ||
+		body["instance"] != "/jobs/status" || body["request_id"] != "req-1" || body["detail"] != "job not found: <script>" {
+		t.Errorf("Unexpected JSON problem: %+v", out)
+	}
+	// La respuesta original no se modifica: puede estar compartida o cacheada
+	if _, ok := resp.Headers[RequestIDHeader]; ok || strings.Contains(resp.Body, "req-1") {
+		t.Errorf("Original response was mutated: %+v", resp)
+	}
+
+	req.Headers["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	out = finalizeResponse(req, resp)
+	if out.Headers["Content-Type"] != "text/html; charset=utf-8" || !strings.Contains(out.Body, "<h1>404 Not Found</h1>") ||
+		!strings.Contains(out.Body, "&lt;script&gt;") || !strings.Contains(out.Body, "req-1") {
+		t.Errorf("Unexpected HTML problem: %+v", out)
+	}
+
+	// Las respuestas que no son problemas solo reciben el ID
+	ok := &HTTPResponse{StatusCode: 200, Body: "hi", Headers: map[string]string{"Content-Type": "text/plain"}}
+	if out := finalizeResponse(req, ok); out.Body != "hi" || out.Headers[RequestIDHeader] != "req-1" {
+		t.Errorf("Unexpected response: %+v", out)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{ProblemContentType, "application/json", "text/html"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ProblemContentType},
+		{"*/*", ProblemContentType},
+		{"application/json", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"text/html;q=0.5, application/json", "application/json"},
+		{"text/*", "text/html"},
+		{"text/html;q=0, */*", ProblemContentType},
+		{"image/png", ProblemContentType},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.accept, offers...); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestRequestIDFrom(t *testing.T) {
+	if got := requestIDFrom("edge-7f3a:1"); got != "edge-7f3a:1" {
+		t.Errorf("Expected client ID to be kept, got %q", got)
+	}
+	for _, value := range []string{"", "bad id", strings.Repeat("a", 129), "x\"y"} {
+		if got := requestIDFrom(value); got == value || len(got) != 16 {
+			t.Errorf("Expected a generated ID for %q, got %q", value, got)
+		}
+	}
+
+	// Si crypto/rand falla el ID conserva el formato
+	randomRead = func([]byte) (int, error) { return 0, errors.New("no entropy") }
+	defer func() { randomRead = rand.Read }()
+	if got := newRequestID(); len(got) != 16 {
+		t.Errorf("Expected a 16-character fallback ID, got %q", got)
+	}
+}
+
+func TestServerProblemResponses(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/ok", okHandler)
+	srv.HandleFunc("POST", "/ok", okHandler)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	code, headers := sendRaw(t, srv.Addr(), "GET /missing HTTP/1.1\r\nHost: test\r\n\r\n")
+	if code != 404 || headers["Content-Type"] != ProblemContentType || len(headers[RequestIDHeader]) != 16 {
+		t.Errorf("Expected a 404 problem with a request id, got %d %v", code, headers)
+	}
+
+	code, headers = sendRaw(t, srv.Addr(), "DELETE /ok HTTP/1.1\r\nHost: test\r\nX-Request-Id: trace-42\r\n\r\n")
+	if code != 405 || headers["Allow"] != "GET, POST" || headers[RequestIDHeader] != "trace-42" {
+		t.Errorf("Expected a 405 with Allow and the client's request id, got %d %v", code, headers)
+	}
+
+	code, headers = sendRaw(t, srv.Addr(), "GET /missing HTTP/1.1\r\nHost: test\r\nAccept: text/html\r\n\r\n")
+	if code != 404 || headers["Content-Type"] != "text/html; charset=utf-8" {
+		t.Errorf("Expected an HTML 404 for browsers, got %d %v", code, headers)
+	}
+
+	code, headers = sendRaw(t, srv.Addr(), "GARBAGE\r\n\r\n")
+	if code != 400 || headers["Content-Type"] != ProblemContentType {
+		t.Errorf("Expected a 400 problem for a malformed request, got %d %v", code, headers)
+	}
+}
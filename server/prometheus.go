@@ -0,0 +1,460 @@
+package server
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PrometheusContentType es el Content-Type del formato de texto de Prometheus
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricsNamespace es el prefijo de las métricas propias del servidor
+const metricsNamespace = "gohttp_"
+
+// unmatchedEndpoint agrupa las rutas no registradas para no multiplicar las series con cada 404
+const unmatchedEndpoint = "unmatched"
+
+// defaultLatencyBuckets son los límites (en segundos) de los histogramas de latencia
+var defaultLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// promHistogram es un histograma de buckets fijos al estilo Prometheus; Observe es lock-free
+type promHistogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] = observaciones en (bounds[i-1], bounds[i]]; el último es +Inf (atómicos)
+	count  uint64   // (atómico)
+	sumNs  int64    // Suma de las observaciones en nanosegundos (atómico)
+}
+
+func newPromHistogram(bounds []float64) *promHistogram {
+	return &promHistogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe registra una duración
+func (h *promHistogram) Observe(d time.Duration) {
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(h.bounds, seconds)
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddInt64(&h.sumNs, int64(d))
+}
+
+// cumulative retorna los conteos acumulados por bucket (incluido +Inf), la cantidad y la suma en segundos
+func (h *promHistogram) cumulative() ([]uint64, uint64, float64) {
+	buckets := make([]uint64, len(h.counts))
+	var total uint64
+	for i := range h.counts {
+		total += atomic.LoadUint64(&h.counts[i])
+		buckets[i] = total
+	}
+	return buckets, total, float64(atomic.LoadInt64(&h.sumNs)) / 1e9
+}
+
+// requestSeries identifica una serie de peticiones por sus etiquetas
+type requestSeries struct {
+	method   string
+	endpoint string
+	status   string
+}
+
+// RequestRecorder acumula la duración y espera en cola de las peticiones por método,
+// endpoint y status para exportarlas como histogramas
+type RequestRecorder struct {
+	mu        sync.RWMutex
+	durations map[requestSeries]*promHistogram
+	waits     map[requestSeries]*promHistogram // status vacío: la espera se mide antes de ejecutar
+}
+
+// NewRequestRecorder crea un registro vacío
+func NewRequestRecorder() *RequestRecorder {
+	return &RequestRecorder{
+		durations: make(map[requestSeries]*promHistogram),
+		waits:     make(map[requestSeries]*promHistogram),
+	}
+}
+
+// Record registra una petición atendida
+func (r *RequestRecorder) Record(method, endpoint string, status int, wait, exec time.Duration) {
+	r.histogram(r.durations, requestSeries{method, endpoint, strconv.Itoa(status)}).Observe(exec)
+	r.histogram(r.waits, requestSeries{method: method, endpoint: endpoint}).Observe(wait)
+}
+
+// histogram obtiene o crea el histograma de la serie
+func (r *RequestRecorder) histogram(series map[requestSeries]*promHistogram, key requestSeries) *promHistogram {
+	r.mu.RLock()
+	h, ok := series[key]
+	r.mu.RUnlock()
+	if ok {
+		return h
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if h, ok = series[key]; !ok {
+		h = newPromHistogram(defaultLatencyBuckets)
+		series[key] = h
+	}
+	return h
+}
+
+// snapshot retorna las series ordenadas por etiquetas para una exportación estable
+func (r *RequestRecorder) snapshot(series map[requestSeries]*promHistogram) ([]requestSeries, map[requestSeries]*promHistogram) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]requestSeries, 0, len(series))
+	copied := make(map[requestSeries]*promHistogram, len(series))
+	for key, h := range series {
+		keys = append(keys, key)
+		copied[key] = h
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+	return keys, copied
+}
+
+// promWriter arma la exposición en formato de texto de Prometheus
+type promWriter struct {
+	b strings.Builder
+}
+
+// family escribe las líneas HELP y TYPE de una métrica
+func (w *promWriter) family(name, kind, help string) {
+	w.b.WriteString("# HELP " + name + " " + strings.ReplaceAll(help, "\n", " ") + "\n")
+	w.b.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// sample escribe una muestra; labels son pares nombre, valor
+func (w *promWriter) sample(name string, value float64, labels ...string) {
+	w.b.WriteString(name)
+	if len(labels) > 0 {
+		w.b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.b.WriteByte(',')
+			}
+			w.b.WriteString(labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+		}
+		w.b.WriteByte('}')
+	}
+	w.b.WriteByte(' ')
+	w.b.WriteString(formatPromValue(value))
+	w.b.WriteByte('\n')
+}
+
+// gauge escribe una métrica de una sola muestra sin etiquetas
+func (w *promWriter) gauge(name, kind, help string, value float64) {
+	w.family(name, kind, help)
+	w.sample(name, value)
+}
+
+// histogram escribe los buckets, la suma y la cantidad de un histograma
+func (w *promWriter) histogram(name string, h *promHistogram, labels ...string) {
+	buckets, count, sum := h.cumulative()
+	for i, cumulative := range buckets {
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = formatPromValue(h.bounds[i])
+		}
+		w.sample(name+"_bucket", float64(cumulative), append(labels, "le", le)...)
+	}
+	w.sample(name+"_sum", sum, labels...)
+	w.sample(name+"_count", float64(count), labels...)
+}
+
+// String retorna el texto generado
+func (w *promWriter) String() string {
+	return w.b.String()
+}
+
+// escapeLabel escapa un valor de etiqueta según el formato de texto
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+// formatPromValue formatea un número como lo espera Prometheus
+func formatPromValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys retorna las claves de un mapa ordenadas
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrometheusMetrics retorna las métricas del servidor en el formato de texto de Prometheus:
+// histogramas de latencia y espera por endpoint, conexiones, rechazos, worker pools, colas,
+// JobManager, circuit breakers, caché y runtime de Go
+func (s *Server) PrometheusMetrics() string {
+	w := &promWriter{}
+	s.writeRequestMetrics(w)
+	s.writeConnectionMetrics(w)
+	s.writePoolMetrics(w)
+	s.writeJobMetrics(w)
+	s.writeResilienceMetrics(w)
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	writeRuntimeMetrics(w, &mem)
+	s.writeProcessMetrics(w, &mem)
+	return w.String()
+}
+
+// writeRequestMetrics exporta contadores e histogramas de las peticiones atendidas
+func (s *Server) writeRequestMetrics(w *promWriter) {
+	keys, durations := s.requests.snapshot(s.requests.durations)
+
+	name := metricsNamespace + "http_requests_total"
+	w.family(name, "counter", "HTTP requests served, by method, endpoint and status code.")
+	for _, key := range keys {
+		_, count, _ := durations[key].cumulative()
+		w.sample(name, float64(count), "method", key.method, "endpoint", key.endpoint, "status", key.status)
+	}
+
+	name = metricsNamespace + "http_request_duration_seconds"
+	w.family(name, "histogram", "Handler execution time in seconds.")
+	for _, key := range keys {
+		w.histogram(name, durations[key], "method", key.method, "endpoint", key.endpoint, "status", key.status)
+	}
+
+	keys, waits := s.requests.snapshot(s.requests.waits)
+	name = metricsNamespace + "http_request_queue_wait_seconds"
+	w.family(name, "histogram", "Time requests spent in the worker queue in seconds.")
+	for _, key := range keys {
+		w.histogram(name, waits[key], "method", key.method, "endpoint", key.endpoint)
+	}
+
+	bytesIn := metricsNamespace + "http_request_bytes_total"
+	bytesOut := metricsNamespace + "http_response_bytes_total"
+	type byteSample struct {
+		method, endpoint string
+		in, out          int64
+	}
+	var samples []byteSample
+	s.metricsManager.each(func(key string, metrics *RequestMetrics) {
+		method, endpoint, _ := strings.Cut(key, " ")
+		samples = append(samples, byteSample{method, endpoint,
+			atomic.LoadInt64(&metrics.bytesIn), atomic.LoadInt64(&metrics.bytesOut)})
+	})
+	w.family(bytesIn, "counter", "Bytes received in requests (head and body), by method and endpoint.")
+	for _, sample := range samples {
+		w.sample(bytesIn, float64(sample.in), "method", sample.method, "endpoint", sample.endpoint)
+	}
+	w.family(bytesOut, "counter", "Bytes written in responses, by method and endpoint.")
+	for _, sample := range samples {
+		w.sample(bytesOut, float64(sample.out), "method", sample.method, "endpoint", sample.endpoint)
+	}
+
+	name = metricsNamespace + "http_parse_failures_total"
+	w.family(name, "counter", "Requests that could not be parsed, by reason.")
+	failures := s.metricsManager.ParseFailures()
+	for _, reason := range sortedKeys(failures) {
+		w.sample(name, float64(failures[reason]), "reason", reason)
+	}
+
+	w.gauge(metricsNamespace+"http_requests_in_flight", "gauge", "Requests currently being executed by a worker.", float64(s.busyWorkers.Get()))
+	w.gauge(metricsNamespace+"throughput_requests_per_second", "gauge", "Requests completed per second over the last 10 seconds.", s.throughput.Rate())
+}
+
+// writeConnectionMetrics exporta conexiones, cortes en la etapa de lectura y rechazos
+func (s *Server) writeConnectionMetrics(w *promWriter) {
+	w.gauge(metricsNamespace+"connections_total", "counter", "Connections accepted.", float64(s.connCounter.Get()))
+	w.gauge(metricsNamespace+"connections_active", "gauge", "Connections currently open.", float64(s.activeConns.Get()))
+	w.gauge(metricsNamespace+"connections_reading", "gauge", "Connections in the request reading stage.", float64(len(s.readerSlots)))
+
+	name := metricsNamespace + "connections_killed_total"
+	w.family(name, "counter", "Connections closed during request reading, by reason.")
+	killed := s.connKills.Snapshot()
+	for _, reason := range sortedKeys(killed) {
+		w.sample(name, float64(killed[reason]), "reason", reason)
+	}
+
+	name = metricsNamespace + "requests_rejected_total"
+	w.family(name, "counter", "Requests rejected with 503 by admission control, by reason.")
+	rejected := s.rejections.Snapshot()
+	for _, reason := range sortedKeys(rejected) {
+		w.sample(name, float64(rejected[reason]), "reason", reason)
+	}
+}
+
+// writePoolMetrics exporta el estado de cada worker pool y su cola de conexiones
+func (s *Server) writePoolMetrics(w *promWriter) {
+	var bulkheads []*Bulkhead
+	s.eachBulkhead(func(bh *Bulkhead) { bulkheads = append(bulkheads, bh) })
+
+	type poolGauge struct {
+		name, kind, help string
+		value            func(bh *Bulkhead, stats map[string]interface{}) float64
+	}
+	gauges := []poolGauge{
+		{"worker_pool_workers", "gauge", "Workers currently running in the pool.",
+			func(bh *Bulkhead, stats map[string]interface{}) float64 { return float64(stats["size"].(int)) }},
+		{"worker_pool_busy_workers", "gauge", "Workers currently executing a request.",
+			func(bh *Bulkhead, stats map[string]interface{}) float64 { return float64(stats["busy"].(int64)) }},
+		{"worker_pool_min_workers", "gauge", "Minimum pool size.",
+			func(bh *Bulkhead, stats map[string]interface{}) float64 { return float64(stats["min_size"].(int)) }},
+		{"worker_pool_max_workers", "gauge", "Maximum pool size.",
+			func(bh *Bulkhead, stats map[string]interface{}) float64 { return float64(stats["max_size"].(int)) }},
+		{"queue_size", "gauge", "Connections waiting in the pool queue.",
+			func(bh *Bulkhead, stats map[string]interface{}) float64 { return float64(bh.queue.Size()) }},
+		{"queue_capacity", "gauge", "Capacity of the pool queue.",
+			func(bh *Bulkhead, stats map[string]interface{}) float64 { return float64(bh.queue.Capacity()) }},
+	}
+
+	stats := make([]map[string]interface{}, len(bulkheads))
+	for i, bh := range bulkheads {
+		stats[i] = bh.pool.Stats()
+	}
+	for _, g := range gauges {
+		name := metricsNamespace + g.name
+		w.family(name, g.kind, g.help)
+		for i, bh := range bulkheads {
+			w.sample(name, g.value(bh, stats[i]), "pool", bh.name)
+		}
+	}
+
+	name := metricsNamespace + "worker_pool_scale_events_total"
+	w.family(name, "counter", "Autoscaling events, by pool and direction.")
+	for i, bh := range bulkheads {
+		w.sample(name, float64(stats[i]["scale_ups"].(int64)), "pool", bh.name, "direction", "up")
+		w.sample(name, float64(stats[i]["scale_downs"].(int64)), "pool", bh.name, "direction", "down")
+	}
+}
+
+// writeJobMetrics exporta las colas y estados del JobManager
+func (s *Server) writeJobMetrics(w *promWriter) {
+	queues := s.jobManager.GetQueueStats()
+	var types []string
+	for key, value := range queues {
+		if _, ok := value.(map[string]interface{}); ok {
+			types = append(types, key)
+		}
+	}
+	sort.Strings(types)
+
+	for _, metric := range []struct{ field, name, help string }{
+		{"queued", "jobs_queued", "Jobs waiting to run, by task type."},
+		{"active", "jobs_active", "Jobs currently running, by task type."},
+		{"max_concurrent", "jobs_max_concurrent", "Concurrency limit, by task type."},
+	} {
+		name := metricsNamespace + metric.name
+		w.family(name, "gauge", metric.help)
+		for _, taskType := range types {
+			w.sample(name, float64(queues[taskType].(map[string]interface{})[metric.field].(int)), "type", taskType)
+		}
+	}
+
+	name := metricsNamespace + "jobs"
+	w.family(name, "gauge", "Jobs known to the job manager, by status.")
+	counts := s.jobManager.StatusCounts()
+	for _, status := range []JobStatus{JobQueued, JobRunning, JobDone, JobError, JobCanceled, JobTimeout} {
+		w.sample(name, float64(counts[status]), "status", string(status))
+	}
+}
+
+// writeResilienceMetrics exporta circuit breakers, caché de respuestas y coalescing
+func (s *Server) writeResilienceMetrics(w *promWriter) {
+	name := metricsNamespace + "circuit_breaker_state"
+	w.family(name, "gauge", "Circuit breaker state (1 for the current state), by breaker.")
+	states := s.BreakerStates()
+	for _, breaker := range sortedKeys(states) {
+		for _, state := range []BreakerState{BreakerClosed, BreakerOpen, BreakerHalfOpen} {
+			value := 0.0
+			if states[breaker] == state {
+				value = 1
+			}
+			w.sample(name, value, "breaker", breaker, "state", string(state))
+		}
+	}
+
+	cache := s.GetCache().Stats()
+	w.gauge(metricsNamespace+"cache_hits_total", "counter", "Response cache hits.", float64(cache["hits"].(int64)))
+	w.gauge(metricsNamespace+"cache_misses_total", "counter", "Response cache misses.", float64(cache["misses"].(int64)))
+	w.gauge(metricsNamespace+"cache_evictions_total", "counter", "Entries evicted to stay within the memory budget.", float64(cache["evictions"].(int64)))
+	w.gauge(metricsNamespace+"cache_entries", "gauge", "Responses currently cached.", float64(cache["entries"].(int)))
+	w.gauge(metricsNamespace+"cache_bytes", "gauge", "Approximate memory used by cached responses.", float64(cache["bytes"].(int64)))
+
+	coalesce := s.coalescer.Stats()
+	w.gauge(metricsNamespace+"coalesce_executions_total", "counter", "Shared executions started for coalesced routes.", float64(coalesce["executions"].(int64)))
+	w.gauge(metricsNamespace+"coalesced_requests_total", "counter", "Requests that reused an identical in-flight execution.", float64(coalesce["coalesced"].(int64)))
+}
+
+// writeRuntimeMetrics exporta métricas del runtime de Go con los nombres habituales de client_golang
+func writeRuntimeMetrics(w *promWriter, mem *runtime.MemStats) {
+	w.family("go_info", "gauge", "Information about the Go environment.")
+	w.sample("go_info", 1, "version", runtime.Version())
+	w.gauge("go_goroutines", "gauge", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	w.gauge("go_memstats_alloc_bytes", "gauge", "Number of bytes allocated and still in use.", float64(mem.Alloc))
+	w.gauge("go_memstats_alloc_bytes_total", "counter", "Total number of bytes allocated, even if freed.", float64(mem.TotalAlloc))
+	w.gauge("go_memstats_sys_bytes", "gauge", "Number of bytes obtained from system.", float64(mem.Sys))
+	w.gauge("go_memstats_heap_inuse_bytes", "gauge", "Number of heap bytes that are in use.", float64(mem.HeapInuse))
+	w.gauge("go_memstats_heap_objects", "gauge", "Number of allocated objects.", float64(mem.HeapObjects))
+	w.gauge("go_memstats_mallocs_total", "counter", "Total number of mallocs.", float64(mem.Mallocs))
+	w.gauge("go_memstats_frees_total", "counter", "Total number of frees.", float64(mem.Frees))
+	w.gauge("go_memstats_last_gc_time_seconds", "gauge", "Number of seconds since 1970 of last garbage collection.", float64(mem.LastGC)/1e9)
+	w.gauge("go_gc_cycles_total", "counter", "Number of completed GC cycles.", float64(mem.NumGC))
+	w.gauge("go_gc_pause_seconds_total", "counter", "Total time spent in GC stop-the-world pauses.", float64(mem.PauseTotalNs)/1e9)
+}
+
+// writeProcessMetrics exporta la última muestra del colector de runtime con los nombres
+// estándar de process_* y go_*; los valores de /proc no disponibles se omiten
+func (s *Server) writeProcessMetrics(w *promWriter, mem *runtime.MemStats) {
+	sample := s.runtimeStats.current()
+	pauses := s.runtimeStats.gcPauseStats()
+
+	if sample.Threads >= 0 {
+		w.gauge("go_threads", "gauge", "Number of OS threads created.", float64(sample.Threads))
+	}
+	w.gauge("go_sched_gomaxprocs_threads", "gauge", "The current runtime.GOMAXPROCS setting.", float64(sample.GOMAXPROCS))
+	w.gauge("go_gc_heap_goal_bytes", "gauge", "Heap size target for the end of the GC cycle.", float64(sample.HeapGoalBytes))
+
+	// Summary como go_gc_duration_seconds de client_golang: cuantiles del histograma del
+	// runtime, suma de MemStats y cantidad de pausas del histograma
+	name := "go_gc_pause_seconds"
+	w.family(name, "summary", "Stop-the-world GC pause durations since process start.")
+	w.sample(name, pauses["p50_ms"].(float64)/1000, "quantile", "0.5")
+	w.sample(name, pauses["p99_ms"].(float64)/1000, "quantile", "0.99")
+	w.sample(name, pauses["max_ms"].(float64)/1000, "quantile", "1")
+	w.sample(name+"_sum", float64(mem.PauseTotalNs)/1e9)
+	w.sample(name+"_count", float64(pauses["count"].(uint64)))
+
+	if sample.CPUUserSeconds >= 0 {
+		w.gauge("process_cpu_seconds_total", "counter", "Total user and system CPU time spent in seconds.", sample.CPUUserSeconds+sample.CPUSystemSeconds)
+	}
+	if sample.RSSBytes >= 0 {
+		w.gauge("process_resident_memory_bytes", "gauge", "Resident memory size in bytes.", float64(sample.RSSBytes))
+	}
+	if sample.OpenFDs >= 0 {
+		w.gauge("process_open_fds", "gauge", "Number of open file descriptors.", float64(sample.OpenFDs))
+	}
+	if sample.MaxFDs >= 0 {
+		w.gauge("process_max_fds", "gauge", "Maximum number of open file descriptors.", float64(sample.MaxFDs))
+	}
+	w.gauge("process_start_time_seconds", "gauge", "Start time of the process since unix epoch in seconds.", float64(s.runtimeStats.started.Unix()))
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromHistogramCumulativeBuckets(t *testing.T) {
+	h := newPromHistogram([]float64{0.01, 0.1, 1})
+	for _, d := range []time.Duration{5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond, 2 * time.Second} {
+		h.Observe(d)
+	}
+
+	buckets, count, sum := h.cumulative()
+	expected := []uint64{2, 3, 3, 4} // <=0.01 (incluye el límite), <=0.1, <=1, +Inf
+	for i, want := range expected {
+		if buckets[i] != want {
+			t.Errorf("Bucket %d: expected %d, got %d", i, want, buckets[i])
+		}
+	}
+	if count != 4 || sum < 2.064 || sum > 2.066 {
+		t.Errorf("Expected count 4 and sum 2.065s, got %d and %v", count, sum)
+	}
+}
+
+func TestPromWriterFormat(t *testing.T) {
+	w := &promWriter{}
+	w.family("test_total", "counter", "A test\ncounter.")
+	w.sample("test_total", 3, "path", `/a"b\c`)
+	w.sample("test_total", 1.5)
+
+	want := "# HELP test_total A test counter.\n" +
+		"# TYPE test_total counter\n" +
+		`test_total{path="/a\"b\\c"} 3` + "\n" +
+		"test_total 1.5\n"
+	if w.String() != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", w.String(), want)
+	}
+}
+
+// promLine valida una línea de muestra: nombre, etiquetas opcionales y valor
+var promLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{([a-zA-Z_][a-zA-Z0-9_]*="(\\.|[^"\\])*",?)*\})? (\+Inf|-Inf|NaN|[-+0-9.eE]+)$`)
+
+func TestPrometheusMetricsExposition(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.HandleFunc("GET", "/ok", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK"}
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		sendRaw(t, srv.Addr(), "GET /ok HTTP/1.1\r\nHost: test\r\n\r\n")
+	}
+	sendRaw(t, srv.Addr(), "GET /missing/123 HTTP/1.1\r\nHost: test\r\n\r\n")
+
+	text := srv.PrometheusMetrics()
+
+	// Cada familia tiene HELP y TYPE, y cada muestra es sintácticamente válida
+	typed := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if strings.HasPrefix(line, "# TYPE ") {
+			typed[strings.Fields(line)[2]] = true
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if !promLine.MatchString(line) {
+			t.Errorf("Invalid sample line: %q", line)
+		}
+	}
+	for _, family := range []string{
+		"gohttp_http_requests_total", "gohttp_http_request_duration_seconds", "gohttp_http_request_queue_wait_seconds",
+		"gohttp_worker_pool_workers", "gohttp_queue_size", "gohttp_jobs_queued", "gohttp_requests_rejected_total",
+		"go_goroutines", "go_memstats_alloc_bytes",
+	} {
+		if !typed[family] {
+			t.Errorf("Missing TYPE for %s", family)
+		}
+	}
+
+	for _, sample := range []string{
+		`gohttp_http_requests_total{method="GET",endpoint="/ok",status="200"} 3`,
+		`gohttp_http_requests_total{method="GET",endpoint="unmatched",status="404"} 1`,
+		`gohttp_http_request_duration_seconds_bucket{method="GET",endpoint="/ok",status="200",le="+Inf"} 3`,
+		`gohttp_http_request_duration_seconds_count{method="GET",endpoint="/ok",status="200"} 3`,
+		`gohttp_http_request_queue_wait_seconds_count{method="GET",endpoint="/ok"} 3`,
+		`gohttp_worker_pool_workers{pool="default"} 2`,
+		`gohttp_requests_rejected_total{reason="queue_full"} 0`,
+	} {
+		if !strings.Contains(text, sample+"\n") {
+			t.Errorf("Missing sample %s", sample)
+		}
+	}
+}
@@ -1,15 +1,31 @@
 package server
 
 import (
+	"context"
 	"sync"
 )
 
-// TaskQueue es una cola thread-safe para tareas
+// Queue es la cola de la que consumen los workers de un pool
+type Queue interface {
+	Enqueue(item interface{}) bool
+	Dequeue(ctx context.Context) (interface{}, bool)
+	Size() int64
+	Capacity() int64
+	Close()
+}
+
+// TaskQueue es una cola FIFO acotada y thread-safe sobre un ring buffer.
+// Cada elemento encolado deposita un token en el canal ready, así que ningún
+// Dequeue bloqueado pierde una señal aunque lleguen muchos elementos a la vez;
+// los receptores bloqueados en un canal se atienden en orden de llegada.
 type TaskQueue struct {
-	items    []interface{}
-	capacity int
 	mu       sync.Mutex
-	notEmpty chan struct{}
+	buffer   []interface{}
+	head     int // Posición del próximo elemento a sacar
+	count    int // Elementos en la cola
+	capacity int
+	ready    chan struct{} // Un token por elemento encolado
+	done     chan struct{} // Se cierra en Close y despierta a todos los que esperan
 	closed   bool
 }
 
@@ -20,57 +36,72 @@ func NewTaskQueue(capacity int) *TaskQueue {
 	}
 
 	return &TaskQueue{
-		items:    make([]interface{}, 0, capacity),
+		buffer:   make([]interface{}, capacity),
 		capacity: capacity,
-		notEmpty: make(chan struct{}, 1),
+		ready:    make(chan struct{}, capacity),
+		done:     make(chan struct{}),
 		closed:   false,
 	}
 }
 
-// Enqueue agrega un elemento a la cola
+// Enqueue agrega un elemento a la cola; retorna false si está llena o cerrada
 func (q *TaskQueue) Enqueue(item interface{}) bool {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if q.closed {
+	if q.closed || q.count >= q.capacity {
 		return false
 	}
 
-	if len(q.items) >= q.capacity {
-		return false
-	}
+	q.buffer[(q.head+q.count)%q.capacity] = item
+	q.count++
 
-	q.items = append(q.items, item)
+	// Nunca bloquea: hay a lo sumo capacity tokens pendientes
+	q.ready <- struct{}{}
+	return true
+}
 
-	// Señalizar que hay elementos
+// Dequeue espera hasta que haya un elemento y lo retorna. Retorna false si el
+// contexto se cancela, o si la cola se cerró y ya no quedan elementos.
+func (q *TaskQueue) Dequeue(ctx context.Context) (interface{}, bool) {
 	select {
-	case q.notEmpty <- struct{}{}:
-	default:
+	case <-q.ready:
+		return q.pop(), true
+	case <-ctx.Done():
+		return nil, false
+	case <-q.done:
+		// Cerrada: entregar lo que haya quedado antes de reportar el cierre
+		return q.TryDequeue()
 	}
-
-	return true
 }
 
-// Dequeue remueve y retorna un elemento de la cola
-func (q *TaskQueue) Dequeue() (interface{}, bool) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	if len(q.items) == 0 {
+// TryDequeue retorna un elemento si hay uno disponible, sin bloquear
+func (q *TaskQueue) TryDequeue() (interface{}, bool) {
+	select {
+	case <-q.ready:
+		return q.pop(), true
+	default:
 		return nil, false
 	}
+}
 
-	item := q.items[0]
-	q.items = q.items[1:]
+// pop saca el elemento del frente; el llamador ya consumió su token
+func (q *TaskQueue) pop() interface{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 
-	return item, true
+	item := q.buffer[q.head]
+	q.buffer[q.head] = nil // Liberar la referencia para el GC
+	q.head = (q.head + 1) % q.capacity
+	q.count--
+	return item
 }
 
 // Size retorna el tamaño actual de la cola
 func (q *TaskQueue) Size() int64 {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return int64(len(q.items))
+	return int64(q.count)
 }
 
 // Capacity retorna la capacidad máxima de la cola
@@ -78,29 +109,27 @@ func (q *TaskQueue) Capacity() int64 {
 	return int64(q.capacity)
 }
 
-// NotEmpty retorna un canal que se señaliza cuando hay elementos
-func (q *TaskQueue) NotEmpty() <-chan struct{} {
-	return q.notEmpty
-}
-
-// Close cierra la cola
+// Close cierra la cola: rechaza nuevos elementos y despierta a todos los Dequeue bloqueados
 func (q *TaskQueue) Close() {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
 	q.closed = true
-	close(q.notEmpty)
+	close(q.done)
 }
 
 // IsEmpty retorna true si la cola está vacía
 func (q *TaskQueue) IsEmpty() bool {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return len(q.items) == 0
+	return q.count == 0
 }
 
 // IsFull retorna true si la cola está llena
 func (q *TaskQueue) IsFull() bool {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	return len(q.items) >= q.capacity
+	return q.count >= q.capacity
 }
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTaskQueueFIFOWraparound(t *testing.T) {
+	q := NewTaskQueue(3)
+	ctx := context.Background()
+
+	// Varias vueltas al ring buffer mantienen el orden
+	next := 0
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 3; i++ {
+			if !q.Enqueue(round*3 + i) {
+				t.Fatalf("Enqueue %d failed", round*3+i)
+			}
+		}
+		if q.Enqueue("overflow") {
+			t.Fatal("Enqueue should fail when the queue is full")
+		}
+		for i := 0; i < 3; i++ {
+			item, ok := q.Dequeue(ctx)
+			if !ok || item.(int) != next {
+				t.Fatalf("Expected %d, got %v (ok=%v)", next, item, ok)
+			}
+			next++
+		}
+	}
+
+	if !q.IsEmpty() || q.Size() != 0 {
+		t.Errorf("Expected empty queue, size %d", q.Size())
+	}
+	if _, ok := q.TryDequeue(); ok {
+		t.Error("TryDequeue should fail on an empty queue")
+	}
+}
+
+func TestTaskQueueBlockingDequeue(t *testing.T) {
+	q := NewTaskQueue(10)
+
+	got := make(chan interface{})
+	go func() {
+		item, _ := q.Dequeue(context.Background())
+		got <- item
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("Dequeue returned before anything was enqueued")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Enqueue("task")
+	select {
+	case item := <-got:
+		if item != "task" {
+			t.Errorf("Expected task, got %v", item)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue was not woken by Enqueue")
+	}
+
+	// Cancelar el contexto desbloquea
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, ok := q.Dequeue(ctx); ok {
+		t.Error("Dequeue should fail when the context is cancelled")
+	}
+}
+
+func TestTaskQueueNoLostWakeups(t *testing.T) {
+	const consumers, items = 8, 1000
+	q := NewTaskQueue(items)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, ok := q.Dequeue(context.Background())
+				if !ok {
+					return
+				}
+				mu.Lock()
+				seen[item.(int)] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Ráfaga: todos los elementos llegan mientras los consumidores esperan
+	for i := 0; i < items; i++ {
+		q.Enqueue(i)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for q.Size() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	q.Close()
+	wg.Wait()
+
+	if len(seen) != items {
+		t.Errorf("Expected %d items consumed, got %d", items, len(seen))
+	}
+}
+
+func TestTaskQueueCloseWakesAllWaiters(t *testing.T) {
+	q := NewTaskQueue(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.Dequeue(context.Background())
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake all waiters")
+	}
+
+	if q.Enqueue("late") {
+		t.Error("Enqueue should fail on a closed queue")
+	}
+}
+
+func TestTaskQueueCloseDrainsPendingItems(t *testing.T) {
+	q := NewTaskQueue(5)
+	q.Enqueue(1)
+	q.Enqueue(2)
+	q.Close()
+
+	for want := 1; want <= 2; want++ {
+		if item, ok := q.Dequeue(context.Background()); !ok || item.(int) != want {
+			t.Errorf("Expected %d after Close, got %v (ok=%v)", want, item, ok)
+		}
+	}
+	if _, ok := q.Dequeue(context.Background()); ok {
+		t.Error("Dequeue should fail on a closed and empty queue")
+	}
+}
+
+func TestWorkerPoolStopWithIdleWorkers(t *testing.T) {
+	q := NewTaskQueue(10)
+	pool := NewWorkerPool(8)
+	pool.Start(q, func(task interface{}) {})
+
+	done := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WorkerPool.Stop hung with idle workers")
+	}
+}
+
+// legacyTaskQueue es la implementación anterior (slice + canal de señal de 1 slot),
+// conservada solo para comparar en los benchmarks
+type legacyTaskQueue struct {
+	items    []interface{}
+	capacity int
+	mu       sync.Mutex
+	notEmpty chan struct{}
+}
+
+func newLegacyTaskQueue(capacity int) *legacyTaskQueue {
+	return &legacyTaskQueue{
+		items:    make([]interface{}, 0, capacity),
+		capacity: capacity,
+		notEmpty: make(chan struct{}, 1),
+	}
+}
+
+func (q *legacyTaskQueue) Enqueue(item interface{}) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) >= q.capacity {
+		return false
+	}
+	q.items = append(q.items, item)
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *legacyTaskQueue) Dequeue() (interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil, false
+	}
+	item := q.items[0]
+	q.items = q.items[1:]
+	return item, true
+}
+
+func BenchmarkTaskQueueSequential(b *testing.B) {
+	q := NewTaskQueue(1024)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Enqueue(i)
+		q.Dequeue(ctx)
+	}
+}
+
+func BenchmarkLegacyTaskQueueSequential(b *testing.B) {
+	q := newLegacyTaskQueue(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Enqueue(i)
+		q.Dequeue()
+	}
+}
+
+// Productor único y varios consumidores bloqueados, como el servidor
+func BenchmarkTaskQueueProducerConsumers(b *testing.B) {
+	q := NewTaskQueue(1024)
+	var wg sync.WaitGroup
+	for c := 0; c < 8; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := q.Dequeue(context.Background()); !ok {
+					return
+				}
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for !q.Enqueue(i) {
+			runtime.Gosched() // Cola llena: ceder el procesador a los consumidores
+		}
+	}
+	q.Close()
+	wg.Wait()
+}
+
+func BenchmarkLegacyTaskQueueProducerConsumers(b *testing.B) {
+	q := newLegacyTaskQueue(1024)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for c := 0; c < 8; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := q.Dequeue(); ok {
+					continue
+				}
+				select {
+				case <-stop:
+					return
+				case <-q.notEmpty:
+				case <-time.After(time.Millisecond):
+					// Sin este respaldo el consumidor puede quedarse esperando una señal perdida
+				}
+			}
+		}()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for !q.Enqueue(i) {
+			runtime.Gosched() // Cola llena: ceder el procesador a los consumidores
+		}
+	}
+	for {
+		q.mu.Lock()
+		empty := len(q.items) == 0
+		q.mu.Unlock()
+		if empty {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,383 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Motivos por los que se corta una conexión en la etapa de lectura
+const (
+	KillIdleTimeout   = "idle_timeout"     // No llegó ningún byte a tiempo
+	KillHeaderTimeout = "header_timeout"   // Los headers no se completaron a tiempo
+	KillHeaderTooBig  = "header_too_large" // Los headers superan maxHeaderBytes
+	KillBodyTooSlow   = "body_too_slow"    // El body llega por debajo de la tasa mínima
+	KillBodyTooLarge  = "body_too_large"   // Content-Length supera MaxBodyBytes
+	KillReaderLimit   = "reader_limit"     // Hay demasiadas conexiones leyéndose a la vez
+	KillMalformed     = "malformed"        // La petición no es HTTP válido
+)
+
+// ReadLimits configura la protección contra clientes lentos en la etapa de lectura
+type ReadLimits struct {
+	IdleTimeout   time.Duration // Espera máxima por el primer byte de la petición
+	HeaderTimeout time.Duration // Tiempo total para recibir request line y headers
+	BodyMinRate   int           // Bytes/segundo mínimos al recibir el body
+	BodyGrace     time.Duration // Tiempo extra antes de exigir la tasa mínima
+	MaxBodyBytes  int64         // Tamaño máximo del body
+	MaxReaders    int           // Conexiones que pueden estar leyéndose a la vez
+}
+
+// DefaultReadLimits retorna los límites usados por NewServer
+func DefaultReadLimits() ReadLimits {
+	return ReadLimits{
+		IdleTimeout:   10 * time.Second,
+		HeaderTimeout: 5 * time.Second,
+		BodyMinRate:   1024,
+		BodyGrace:     5 * time.Second,
+		MaxBodyBytes:  10 << 20,
+		MaxReaders:    1024,
+	}
+}
+
+// readError es un error de lectura con el motivo por el que se corta la conexión
+type readError struct {
+	reason string
+	err    error
+}
+
+func (e *readError) Error() string {
+	return fmt.Sprintf("%s: %v", e.reason, e.err)
+}
+
+func (e *readError) Unwrap() error {
+	return e.err
+}
+
+// errClientClosed indica que el cliente cerró la conexión sin enviar una petición
+var errClientClosed = errors.New("connection closed by client")
+
+// readConnection es la etapa de lectura: parsea la petición completa antes de que la
+// conexión ocupe un worker, y solo entonces la encola para procesamiento.
+func (s *Server) readConnection(conn net.Conn, connID int64) {
+	defer s.wg.Done()
+	defer func() { <-s.readerSlots }()
+
+	req, err := s.parseRequest(conn)
+	if err != nil {
+		s.rejectRead(conn, connID, err)
+		return
+	}
+
+	// Rutas baratas (health checks) se atienden aquí mismo, sin pasar por la cola
+	if s.bypassesQueue(req) {
+		s.serveRequest(conn, connID, req, 0, nil, BreakerTicket{})
+		return
+	}
+
+	if s.isShuttingDown() {
+		s.shedRequest(conn, connID, req, RejectShuttingDown)
+		return
+	}
+
+	// Con el breaker de la ruta abierto se falla rápido, sin ocupar un worker
+	breaker := s.breakerFor(req)
+	var ticket BreakerTicket
+	if breaker != nil {
+		var ok bool
+		if ticket, ok = breaker.Allow(); !ok {
+			s.rejectOpenCircuit(conn, connID, req, breaker)
+			return
+		}
+	}
+
+	task := ConnectionTask{
+		Conn:        conn,
+		ID:          connID,
+		EnqueueTime: time.Now(),
+		Request:     req,
+		Class:       s.classFor(req),
+		breaker:     breaker,
+		ticket:      ticket,
+	}
+
+	// Encolar en la cola del pool asignado a la ruta; si está llena se responde 503 con Retry-After
+	if !s.bulkheadFor(req).queue.Enqueue(task) {
+		if breaker != nil {
+			breaker.Cancel(ticket)
+		}
+		s.shedRequest(conn, connID, req, RejectQueueFull)
+	}
+}
+
+// rejectRead registra el motivo del corte, responde si tiene sentido y cierra la conexión
+func (s *Server) rejectRead(conn net.Conn, connID int64, err error) {
+	defer s.closeConnection(conn)
+
+	if errors.Is(err, errClientClosed) {
+		log.Printf("Connection %d: client disconnected", connID)
+		return
+	}
+
+	var rerr *readError
+	if !errors.As(err, &rerr) {
+		rerr = &readError{reason: KillMalformed, err: err}
+	}
+	s.connKills.Increment(rerr.reason)
+	if isParseFailure(rerr.reason) {
+		s.metricsManager.RecordParseFailure(rerr.reason)
+	}
+	log.Printf("Connection %d cortada (%s): %v", connID, rerr.reason, rerr.err)
+
+	switch rerr.reason {
+	case KillMalformed:
+		s.sendErrorResponse(conn, 400, "malformed request")
+	case KillHeaderTooBig:
+		s.sendErrorResponse(conn, 431, "request line or headers exceed the limit")
+	case KillBodyTooLarge:
+		s.sendErrorResponse(conn, 413, "request body exceeds the limit")
+	case KillHeaderTimeout, KillBodyTooSlow:
+		s.sendErrorResponse(conn, 408, "request was not received in time")
+	}
+}
+
+// isParseFailure indica si el motivo corresponde a una petición que llegó y no se pudo
+// parsear; una conexión keep-alive ociosa no envió nada y no cuenta como fallo
+func isParseFailure(reason string) bool {
+	switch reason {
+	case KillMalformed, KillHeaderTooBig, KillBodyTooLarge, KillHeaderTimeout, KillBodyTooSlow:
+		return true
+	}
+	return false
+}
+
+// closeConnection cierra la conexión y actualiza el contador de activas
+func (s *Server) closeConnection(conn net.Conn) {
+	conn.Close()
+	s.activeConns.Decrement()
+}
+
+// parseRequest parsea una solicitud HTTP aplicando los límites de lectura
+func (s *Server) parseRequest(conn net.Conn) (*HTTPRequest, error) {
+	limits := s.readLimits
+	start := time.Now()
+
+	// Esperar el primer byte con el timeout de inactividad
+	conn.SetReadDeadline(start.Add(limits.IdleTimeout))
+	reader := bufio.NewReader(conn)
+	if _, err := reader.Peek(1); err != nil {
+		if err == io.EOF || isConnReset(err) {
+			// Conexión cerrada (o reseteada, p. ej. un keep-alive ocioso que el cliente
+			// descarta) antes de enviar datos - no es un error grave
+			return nil, errClientClosed
+		}
+		if isTimeout(err) {
+			return nil, &readError{reason: KillIdleTimeout, err: err}
+		}
+		return nil, fmt.Errorf("error reading request line: %v", err)
+	}
+
+	// Plazo absoluto para request line + headers: un cliente que envía un byte
+	// a la vez no puede extenderlo
+	headerDeadline := time.Now().Add(limits.HeaderTimeout)
+	conn.SetReadDeadline(headerDeadline)
+	headerBudget := s.maxHeaderBytes
+
+	// Leer request line
+	requestLine, err := readLimitedLine(reader, &headerBudget)
+	if err != nil {
+		return nil, classifyHeaderError(err)
+	}
+
+	// Verificar que la línea no esté vacía
+	line := strings.TrimSpace(requestLine)
+	if line == "" {
+		return nil, fmt.Errorf("empty request line")
+	}
+
+	// Parsear method, path, version
+	parts := strings.Fields(line)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed request line: %s", line)
+	}
+
+	paramsIndex := strings.Index(parts[1], "?")
+
+	// Safely determine the path portion (avoid slicing with -1)
+	path := parts[1]
+	if paramsIndex != -1 {
+		path = parts[1][:paramsIndex]
+	}
+
+	req := &HTTPRequest{
+		Method:  parts[0],
+		Path:    path,
+		Version: parts[2],
+		Headers: make(map[string]string),
+		Params:  make(map[string]string),
+	}
+
+	if paramsIndex != -1 {
+		// Parsear query string
+		queryString := parts[1][paramsIndex+1:]
+		for _, param := range strings.Split(queryString, "&") {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) == 2 {
+				key := strings.TrimSpace(kv[0])
+				value := strings.TrimSpace(kv[1])
+				req.Params[key] = value
+			}
+		}
+	}
+
+	// Leer headers
+	for {
+		headerLine, err := readLimitedLine(reader, &headerBudget)
+		if err != nil {
+			if err == io.EOF {
+				break // Fin de headers
+			}
+			return nil, classifyHeaderError(err)
+		}
+
+		line := strings.TrimSpace(headerLine)
+		if line == "" {
+			break // Fin de headers
+		}
+
+		// Parsear header
+		headerParts := strings.SplitN(line, ":", 2)
+		if len(headerParts) == 2 {
+			key := strings.TrimSpace(headerParts[0])
+			value := strings.TrimSpace(headerParts[1])
+			req.Headers[key] = value
+		}
+	}
+	req.ID = requestIDFrom(req.GetHeader(RequestIDHeader))
+
+	// Leer body si existe Content-Length
+	if contentLengthStr := req.GetHeader("Content-Length"); contentLengthStr != "" {
+		contentLength, err := strconv.ParseInt(contentLengthStr, 10, 64)
+		if err != nil || contentLength < 0 {
+			return nil, fmt.Errorf("invalid Content-Length: %s", contentLengthStr)
+		}
+		if contentLength > limits.MaxBodyBytes {
+			return nil, &readError{reason: KillBodyTooLarge, err: fmt.Errorf("body of %d bytes", contentLength)}
+		}
+		if contentLength > 0 {
+			body := make([]byte, contentLength)
+			rateReader := &minRateReader{
+				conn:    conn,
+				reader:  reader,
+				minRate: limits.BodyMinRate,
+				grace:   limits.BodyGrace,
+				start:   time.Now(),
+				cap:     start.Add(s.readTimeout),
+			}
+			if _, err := io.ReadFull(rateReader, body); err != nil {
+				if isTimeout(err) {
+					return nil, &readError{reason: KillBodyTooSlow, err: err}
+				}
+				return nil, fmt.Errorf("error reading body: %v", err)
+			}
+			req.Body = string(body)
+		}
+	}
+
+	req.size = int64(s.maxHeaderBytes-headerBudget) + int64(len(req.Body))
+	return req, nil
+}
+
+// readLimitedLine lee una línea completa descontando sus bytes del presupuesto de headers.
+// A diferencia de ReadLine, no descarta el error cuando la línea quedó incompleta por timeout.
+func readLimitedLine(reader *bufio.Reader, budget *int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		*budget -= len(chunk)
+		if *budget < 0 {
+			return "", &readError{reason: KillHeaderTooBig, err: errors.New("headers too large")}
+		}
+		line = append(line, chunk...)
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(line) > 0:
+			// Última línea sin CRLF: se acepta como la hacía ReadLine
+		case err != nil:
+			return "", err
+		}
+		return strings.TrimRight(string(line), "\r\n"), nil
+	}
+}
+
+// classifyHeaderError asigna el motivo de corte a un error al leer headers
+func classifyHeaderError(err error) error {
+	var rerr *readError
+	if errors.As(err, &rerr) {
+		return err
+	}
+	if isTimeout(err) {
+		return &readError{reason: KillHeaderTimeout, err: err}
+	}
+	return fmt.Errorf("error reading headers: %v", err)
+}
+
+// isTimeout indica si el error es un timeout de red
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isConnReset indica si el error es un reset o un corte abrupto de la conexión por el cliente
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE)
+}
+
+// minRateReader exige una tasa mínima de transferencia: tras el período de gracia,
+// el plazo para haber recibido N bytes es N/minRate segundos desde el inicio del body.
+// Se lee en bloques acotados para que un cliente que se atrasa se corte enseguida.
+type minRateReader struct {
+	conn    net.Conn
+	reader  io.Reader
+	minRate int
+	grace   time.Duration
+	start   time.Time
+	cap     time.Time // Plazo absoluto para toda la petición
+	read    int64
+}
+
+func (m *minRateReader) Read(p []byte) (int, error) {
+	if m.minRate > 0 {
+		// Bloques de ~1 segundo a la tasa mínima
+		chunk := m.minRate
+		if chunk < 512 {
+			chunk = 512
+		} else if chunk > 32*1024 {
+			chunk = 32 * 1024
+		}
+		if len(p) > chunk {
+			p = p[:chunk]
+		}
+
+		expected := time.Duration(float64(m.read+int64(len(p))) / float64(m.minRate) * float64(time.Second))
+		deadline := m.start.Add(m.grace + expected)
+		if deadline.After(m.cap) {
+			deadline = m.cap
+		}
+		m.conn.SetReadDeadline(deadline)
+	} else {
+		m.conn.SetReadDeadline(m.cap)
+	}
+
+	n, err := m.reader.Read(p)
+	m.read += int64(n)
+	return n, err
+}
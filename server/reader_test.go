@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T, limits ReadLimits) *Server {
+	t.Helper()
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+	srv.SetReadLimits(limits)
+	srv.HandleFunc("GET", "/ping", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: "pong"}
+	})
+	srv.HandleFunc("POST", "/echo", func(req *HTTPRequest) *HTTPResponse {
+		return &HTTPResponse{StatusCode: 200, StatusText: "OK", Body: req.Body}
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	})
+	return srv
+}
+
+func waitForKill(t *testing.T, srv *Server, reason string) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if srv.connKills.Get(reason) > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected a connection killed for %s, got %v", reason, srv.connKills.Snapshot())
+}
+
+func TestReaderStageLimits(t *testing.T) {
+	limits := ReadLimits{
+		IdleTimeout:   200 * time.Millisecond,
+		HeaderTimeout: 300 * time.Millisecond,
+		BodyMinRate:   512,
+		BodyGrace:     200 * time.Millisecond,
+		MaxBodyBytes:  1 << 20,
+		MaxReaders:    16,
+	}
+	srv := startTestServer(t, limits)
+
+	t.Run("Normal request", func(t *testing.T) {
+		conn, err := net.Dial("tcp", srv.Addr())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+		io.WriteString(conn, "POST /echo HTTP/1.1\r\nContent-Length: 5\r\n\r\nhello")
+		data, _ := io.ReadAll(conn)
+		if !strings.HasPrefix(string(data), "HTTP/1.1 200") || !strings.HasSuffix(string(data), "hello") {
+			t.Errorf("Unexpected response: %q", data)
+		}
+	})
+
+	t.Run("Idle connection", func(t *testing.T) {
+		conn, _ := net.Dial("tcp", srv.Addr())
+		defer conn.Close()
+		waitForKill(t, srv, KillIdleTimeout)
+		if failures := srv.metricsManager.ParseFailures(); failures[KillIdleTimeout] != 0 {
+			t.Errorf("Idle connections should not count as parse failures, got %v", failures)
+		}
+	})
+
+	t.Run("Trickled headers", func(t *testing.T) {
+		conn, _ := net.Dial("tcp", srv.Addr())
+		defer conn.Close()
+		// Un byte cada 50ms: nunca hay inactividad, pero los headers no terminan a tiempo
+		go func() {
+			for _, b := range []byte("GET /ping HTTP/1.1\r\nX-Slow: aaaaaaaaaaaaaaaaaaaaaaa\r\n") {
+				if _, err := conn.Write([]byte{b}); err != nil {
+					return
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+		}()
+		status, _ := bufio.NewReader(conn).ReadString('\n')
+		if !strings.Contains(status, "408") {
+			t.Errorf("Expected 408, got %q", status)
+		}
+		waitForKill(t, srv, KillHeaderTimeout)
+	})
+
+	t.Run("Slow body", func(t *testing.T) {
+		conn, _ := net.Dial("tcp", srv.Addr())
+		defer conn.Close()
+		io.WriteString(conn, "POST /echo HTTP/1.1\r\nContent-Length: 4096\r\n\r\nab")
+		waitForKill(t, srv, KillBodyTooSlow)
+	})
+
+	t.Run("Body too large", func(t *testing.T) {
+		conn, _ := net.Dial("tcp", srv.Addr())
+		defer conn.Close()
+		io.WriteString(conn, "POST /echo HTTP/1.1\r\nContent-Length: 99999999\r\n\r\n")
+		status, _ := bufio.NewReader(conn).ReadString('\n')
+		if !strings.Contains(status, "413") {
+			t.Errorf("Expected 413, got %q", status)
+		}
+	})
+
+	t.Run("Headers too large", func(t *testing.T) {
+		srv.maxHeaderBytes = 256
+		defer func() { srv.maxHeaderBytes = 1 << 20 }()
+		conn, _ := net.Dial("tcp", srv.Addr())
+		defer conn.Close()
+		io.WriteString(conn, "GET /ping HTTP/1.1\r\nX-Big: "+strings.Repeat("a", 1024)+"\r\n\r\n")
+		status, _ := bufio.NewReader(conn).ReadString('\n')
+		if !strings.Contains(status, "431") {
+			t.Errorf("Expected 431, got %q", status)
+		}
+	})
+
+	t.Run("Reset before sending", func(t *testing.T) {
+		malformed, accepted := srv.connKills.Get(KillMalformed), srv.connCounter.Get()
+		conn, _ := net.Dial("tcp", srv.Addr())
+		deadline := time.Now().Add(3 * time.Second)
+		for srv.connCounter.Get() == accepted && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		// Linger 0: el cierre envía un reset, como un cliente que descarta una conexión ociosa
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+
+		for srv.activeConns.Get() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := srv.connKills.Get(KillMalformed); got != malformed {
+			t.Errorf("A reset connection should count as client closed, malformed went %d -> %d", malformed, got)
+		}
+	})
+}
@@ -2,12 +2,16 @@ package server
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
 // HandlerFunc es una función que maneja una petición HTTP
 type HandlerFunc func(*HTTPRequest) *HTTPResponse
 
+// Middleware envuelve un handler para agregar comportamiento antes o después de él
+type Middleware func(next HandlerFunc) HandlerFunc
+
 // Route representa una ruta registrada
 type Route struct {
 	Method  string
@@ -17,8 +21,10 @@ type Route struct {
 
 // Router maneja el enrutamiento de peticiones
 type Router struct {
-	routes map[string]map[string]HandlerFunc // method -> path -> handler
-	mu     sync.RWMutex
+	routes      map[string]map[string]HandlerFunc // method -> path -> handler
+	middlewares []Middleware
+	inner       Middleware // Envuelve solo a los handlers registrados, por dentro de los middlewares
+	mu          sync.RWMutex
 }
 
 // NewRouter crea un nuevo router
@@ -40,27 +46,99 @@ func (r *Router) Register(method, path string, handler HandlerFunc) {
 	r.routes[method][path] = handler
 }
 
+// Routes retorna las rutas registradas ordenadas por path y método
+func (r *Router) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var routes []Route
+	for method, paths := range r.routes {
+		for path, handler := range paths {
+			routes = append(routes, Route{Method: method, Path: path, Handler: handler})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Use agrega un middleware a la cadena. Se ejecutan en el orden en que se registran,
+// y envuelven también la respuesta 404 de rutas inexistentes.
+func (r *Router) Use(mw Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, mw)
+}
+
+// setInner define el middleware que envuelve a cada handler registrado antes que los
+// middlewares globales (p. ej. la caché, que debe quedar detrás de la autenticación)
+func (r *Router) setInner(mw Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inner = mw
+}
+
 // Handle procesa una petición y retorna una respuesta
 func (r *Router) Handle(req *HTTPRequest) *HTTPResponse {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	handler, found := r.lookup(req)
+	inner := r.inner
+	middlewares := r.middlewares
+	r.mu.RUnlock()
+
+	if found && inner != nil {
+		handler = inner(handler)
+	}
+
+	// Aplicar middlewares de afuera hacia adentro
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler(req)
+}
 
+// lookup busca el handler para la petición; debe llamarse con el lock tomado.
+// Si el path existe con otros métodos responde 405 con Allow; si no existe, 404.
+func (r *Router) lookup(req *HTTPRequest) (HandlerFunc, bool) {
 	// Buscar handler para el método y path
 	if methodRoutes, ok := r.routes[req.Method]; ok {
 		if handler, ok := methodRoutes[req.Path]; ok {
-			return handler(req)
+			return handler, true
 		}
 	}
 
-	// No encontrado
-	return &HTTPResponse{
-		StatusCode: 404,
-		StatusText: "Not Found",
-		Body:       fmt.Sprintf("<html><body><h1>404 Not Found</h1><p>%s %s</p></body></html>", req.Method, req.Path),
-		Headers: map[string]string{
-			"Content-Type": "text/html",
-		},
+	var allowed []string
+	for method, methodRoutes := range r.routes {
+		if _, ok := methodRoutes[req.Path]; ok {
+			allowed = append(allowed, method)
+		}
+	}
+	if len(allowed) > 0 {
+		sort.Strings(allowed)
+		return func(req *HTTPRequest) *HTTPResponse {
+			return MethodNotAllowed(req.Method, allowed)
+		}, false
 	}
+
+	return notFoundHandler, false
+}
+
+// notFoundHandler responde 404 para rutas no registradas
+func notFoundHandler(req *HTTPRequest) *HTTPResponse {
+	return NotFound(fmt.Sprintf("no route for %s %s", req.Method, req.Path))
+}
+
+// hasRoute indica si hay un handler registrado para el método y path
+func (r *Router) hasRoute(method, path string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.routes[method][path]
+	return ok
 }
 
 // GetRoutes retorna todas las rutas registradas
@@ -0,0 +1,369 @@
+package server
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RuntimeCollectorConfig define cada cuánto se muestrea el runtime y cuántas muestras se guardan
+type RuntimeCollectorConfig struct {
+	Interval time.Duration
+	History  int
+}
+
+// DefaultRuntimeCollectorConfig guarda 15 minutos de historia con una muestra cada 5 segundos
+func DefaultRuntimeCollectorConfig() RuntimeCollectorConfig {
+	return RuntimeCollectorConfig{Interval: 5 * time.Second, History: 180}
+}
+
+// RuntimeSample es una muestra del runtime de Go y del proceso. Los valores que salen de
+// /proc valen -1 donde no está disponible (fuera de Linux).
+type RuntimeSample struct {
+	Time             time.Time `json:"time"`
+	Goroutines       uint64    `json:"goroutines"`
+	GOMAXPROCS       uint64    `json:"gomaxprocs"`
+	Threads          int64     `json:"os_threads"`
+	HeapBytes        uint64    `json:"heap_bytes"`      // Objetos vivos y aún no barridos
+	HeapObjects      uint64    `json:"heap_objects"`    // Objetos en el heap
+	HeapGoalBytes    uint64    `json:"heap_goal_bytes"` // Tamaño de heap al que se dispara el próximo GC
+	RuntimeBytes     uint64    `json:"runtime_bytes"`   // Memoria total mapeada por el runtime
+	RSSBytes         int64     `json:"rss_bytes"`
+	GCCycles         uint64    `json:"gc_cycles"`
+	GCPauses         uint64    `json:"gc_pauses"`       // Pausas stop-the-world en el intervalo
+	GCPauseMaxMs     float64   `json:"gc_pause_max_ms"` // Mayor pausa del intervalo (límite del bucket)
+	CPUUserSeconds   float64   `json:"cpu_user_seconds"`
+	CPUSystemSeconds float64   `json:"cpu_system_seconds"`
+	CPUPercent       float64   `json:"cpu_percent"` // Uso en el intervalo; 100 = un core completo
+	OpenFDs          int64     `json:"open_fds"`
+	MaxFDs           int64     `json:"max_fds"`
+}
+
+// Métricas de runtime/metrics que se leen en cada muestra
+const (
+	rtGoroutines  = "/sched/goroutines:goroutines"
+	rtGOMAXPROCS  = "/sched/gomaxprocs:threads"
+	rtHeapBytes   = "/memory/classes/heap/objects:bytes"
+	rtHeapObjects = "/gc/heap/objects:objects"
+	rtHeapGoal    = "/gc/heap/goal:bytes"
+	rtTotalBytes  = "/memory/classes/total:bytes"
+	rtGCCycles    = "/gc/cycles/total:gc-cycles"
+	rtGCPauses    = "/sched/pauses/total/gc:seconds"
+)
+
+// clockTicks es USER_HZ, la unidad de los tiempos de CPU en /proc/self/stat (100 en Linux)
+const clockTicks = 100
+
+// RuntimeCollector muestrea periódicamente el runtime y el proceso y guarda la historia en un ring
+type RuntimeCollector struct {
+	config    RuntimeCollectorConfig
+	mu        sync.RWMutex
+	samples   []metrics.Sample
+	history   []RuntimeSample
+	next      int  // Próxima posición del ring
+	full      bool // El ring ya dio la vuelta
+	pauses    *metrics.Float64Histogram
+	lastCount []uint64 // Conteos acumulados de pausas en la muestra anterior
+	started   time.Time
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewRuntimeCollector crea un colector; no muestrea hasta Start o Collect
+func NewRuntimeCollector(config RuntimeCollectorConfig) *RuntimeCollector {
+	defaults := DefaultRuntimeCollectorConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.History <= 0 {
+		config.History = defaults.History
+	}
+
+	names := []string{rtGoroutines, rtGOMAXPROCS, rtHeapBytes, rtHeapObjects, rtHeapGoal, rtTotalBytes, rtGCCycles, rtGCPauses}
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	return &RuntimeCollector{
+		config:  config,
+		samples: samples,
+		history: make([]RuntimeSample, config.History),
+		started: time.Now(),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Start toma una muestra y sigue muestreando cada Interval hasta Stop
+func (rc *RuntimeCollector) Start() {
+	rc.Collect()
+
+	rc.wg.Add(1)
+	go func() {
+		defer rc.wg.Done()
+		ticker := time.NewTicker(rc.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-rc.stopCh:
+				return
+			case <-ticker.C:
+				rc.Collect()
+			}
+		}
+	}()
+}
+
+// Stop detiene el muestreo periódico
+func (rc *RuntimeCollector) Stop() {
+	rc.stopOnce.Do(func() { close(rc.stopCh) })
+	rc.wg.Wait()
+}
+
+// Collect toma una muestra, la agrega a la historia y la retorna
+func (rc *RuntimeCollector) Collect() RuntimeSample {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	metrics.Read(rc.samples)
+	sample := RuntimeSample{Time: time.Now()}
+	for _, s := range rc.samples {
+		switch s.Name {
+		case rtGoroutines:
+			sample.Goroutines = sampleUint(s)
+		case rtGOMAXPROCS:
+			sample.GOMAXPROCS = sampleUint(s)
+		case rtHeapBytes:
+			sample.HeapBytes = sampleUint(s)
+		case rtHeapObjects:
+			sample.HeapObjects = sampleUint(s)
+		case rtHeapGoal:
+			sample.HeapGoalBytes = sampleUint(s)
+		case rtTotalBytes:
+			sample.RuntimeBytes = sampleUint(s)
+		case rtGCCycles:
+			sample.GCCycles = sampleUint(s)
+		case rtGCPauses:
+			if s.Value.Kind() == metrics.KindFloat64Histogram {
+				rc.recordPauses(&sample, s.Value.Float64Histogram())
+			}
+		}
+	}
+
+	proc := readProcStats()
+	sample.Threads = proc.threads
+	sample.RSSBytes = proc.rss
+	sample.CPUUserSeconds = proc.user
+	sample.CPUSystemSeconds = proc.system
+	sample.OpenFDs = proc.openFDs
+	sample.MaxFDs = proc.maxFDs
+
+	if prev, ok := rc.latestLocked(); ok && proc.user >= 0 {
+		if elapsed := sample.Time.Sub(prev.Time).Seconds(); elapsed > 0 {
+			cpu := (sample.CPUUserSeconds + sample.CPUSystemSeconds) - (prev.CPUUserSeconds + prev.CPUSystemSeconds)
+			sample.CPUPercent = math.Round(cpu/elapsed*1000) / 10
+		}
+	}
+
+	rc.history[rc.next] = sample
+	rc.next = (rc.next + 1) % len(rc.history)
+	if rc.next == 0 {
+		rc.full = true
+	}
+	return sample
+}
+
+// recordPauses calcula las pausas del intervalo como diferencia con los conteos anteriores.
+// El histograma del runtime es acumulado, así que se copia (el runtime reutiliza el slice).
+func (rc *RuntimeCollector) recordPauses(sample *RuntimeSample, h *metrics.Float64Histogram) {
+	counts := append([]uint64(nil), h.Counts...)
+	for i := len(counts) - 1; i >= 0; i-- {
+		delta := counts[i]
+		if i < len(rc.lastCount) {
+			delta -= rc.lastCount[i]
+		}
+		if delta > 0 && sample.GCPauseMaxMs == 0 {
+			sample.GCPauseMaxMs = bucketUpperMs(h.Buckets, i)
+		}
+		sample.GCPauses += delta
+	}
+	rc.lastCount = counts
+	rc.pauses = &metrics.Float64Histogram{Counts: counts, Buckets: append([]float64(nil), h.Buckets...)}
+}
+
+// Latest retorna la última muestra
+func (rc *RuntimeCollector) Latest() (RuntimeSample, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.latestLocked()
+}
+
+func (rc *RuntimeCollector) latestLocked() (RuntimeSample, bool) {
+	if !rc.full && rc.next == 0 {
+		return RuntimeSample{}, false
+	}
+	return rc.history[(rc.next-1+len(rc.history))%len(rc.history)], true
+}
+
+// History retorna las muestras guardadas, de la más vieja a la más nueva
+func (rc *RuntimeCollector) History() []RuntimeSample {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	if !rc.full {
+		return append([]RuntimeSample(nil), rc.history[:rc.next]...)
+	}
+	history := make([]RuntimeSample, 0, len(rc.history))
+	history = append(history, rc.history[rc.next:]...)
+	return append(history, rc.history[:rc.next]...)
+}
+
+// current retorna la última muestra, tomando una si todavía no hay
+func (rc *RuntimeCollector) current() RuntimeSample {
+	if sample, ok := rc.Latest(); ok {
+		return sample
+	}
+	return rc.Collect()
+}
+
+// gcPauseStats resume todas las pausas de GC desde el arranque
+func (rc *RuntimeCollector) gcPauseStats() map[string]interface{} {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	stats := map[string]interface{}{"count": uint64(0), "p50_ms": 0.0, "p99_ms": 0.0, "max_ms": 0.0}
+	if rc.pauses == nil {
+		return stats
+	}
+	total := uint64(0)
+	for _, c := range rc.pauses.Counts {
+		total += c
+	}
+	stats["count"] = total
+	stats["p50_ms"] = histogramQuantileMs(rc.pauses, total, 0.50)
+	stats["p99_ms"] = histogramQuantileMs(rc.pauses, total, 0.99)
+	stats["max_ms"] = histogramQuantileMs(rc.pauses, total, 1)
+	return stats
+}
+
+// Stats retorna la última muestra, la historia y las pausas de GC
+func (rc *RuntimeCollector) Stats() map[string]interface{} {
+	latest := rc.current()
+	history := rc.History()
+
+	peakGoroutines, peakHeap := uint64(0), uint64(0)
+	for _, sample := range history {
+		peakGoroutines = max(peakGoroutines, sample.Goroutines)
+		peakHeap = max(peakHeap, sample.HeapBytes)
+	}
+
+	return map[string]interface{}{
+		"go_version":      runtime.Version(),
+		"uptime_seconds":  math.Round(time.Since(rc.started).Seconds()),
+		"interval":        rc.config.Interval.String(),
+		"latest":          latest,
+		"gc_pause":        rc.gcPauseStats(),
+		"peak_goroutines": peakGoroutines,
+		"peak_heap_bytes": peakHeap,
+		"history":         history,
+	}
+}
+
+// Summary retorna los valores clave de la última muestra para /status
+func (rc *RuntimeCollector) Summary() map[string]interface{} {
+	latest := rc.current()
+	return map[string]interface{}{
+		"goroutines":      latest.Goroutines,
+		"heap_mb":         math.Round(float64(latest.HeapBytes)/(1<<20)*10) / 10,
+		"rss_mb":          math.Round(float64(latest.RSSBytes)/(1<<20)*10) / 10,
+		"cpu_percent":     latest.CPUPercent,
+		"open_fds":        latest.OpenFDs,
+		"gc_cycles":       latest.GCCycles,
+		"gc_pause_p99_ms": rc.gcPauseStats()["p99_ms"],
+		"uptime_seconds":  math.Round(time.Since(rc.started).Seconds()),
+	}
+}
+
+// sampleUint lee un valor entero de runtime/metrics (0 si la métrica no existe en esta versión)
+func sampleUint(s metrics.Sample) uint64 {
+	if s.Value.Kind() == metrics.KindUint64 {
+		return s.Value.Uint64()
+	}
+	return 0
+}
+
+// bucketUpperMs retorna el límite superior del bucket i en ms (el inferior si es +Inf)
+func bucketUpperMs(buckets []float64, i int) float64 {
+	upper := buckets[i+1]
+	if math.IsInf(upper, 1) {
+		upper = buckets[i]
+	}
+	return math.Round(upper*1e6) / 1e3
+}
+
+// histogramQuantileMs retorna el cuantil q de un histograma de runtime/metrics en ms
+func histogramQuantileMs(h *metrics.Float64Histogram, total uint64, q float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	seen := uint64(0)
+	for i, c := range h.Counts {
+		seen += c
+		if c > 0 && seen >= rank {
+			return bucketUpperMs(h.Buckets, i)
+		}
+	}
+	return 0
+}
+
+// procStats son los datos del proceso que salen de /proc/self (-1 si no están disponibles)
+type procStats struct {
+	threads, rss, openFDs, maxFDs int64
+	user, system                  float64
+}
+
+// readProcStats lee /proc/self/stat, /proc/self/fd y /proc/self/limits
+func readProcStats() procStats {
+	stats := procStats{threads: -1, rss: -1, openFDs: -1, maxFDs: -1, user: -1, system: -1}
+
+	if data, err := os.ReadFile("/proc/self/stat"); err == nil {
+		// El nombre del proceso va entre paréntesis y puede tener espacios: se parsea desde el último ')'
+		if end := strings.LastIndexByte(string(data), ')'); end >= 0 {
+			fields := strings.Fields(string(data)[end+1:])
+			// fields[0] es el campo 3 (state) de proc(5)
+			if len(fields) > 21 {
+				user, _ := strconv.ParseFloat(fields[11], 64)
+				system, _ := strconv.ParseFloat(fields[12], 64)
+				stats.user, stats.system = user/clockTicks, system/clockTicks
+				stats.threads, _ = strconv.ParseInt(fields[17], 10, 64)
+				if pages, err := strconv.ParseInt(fields[21], 10, 64); err == nil {
+					stats.rss = pages * int64(os.Getpagesize())
+				}
+			}
+		}
+	}
+
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		stats.openFDs = int64(len(entries))
+	}
+
+	if data, err := os.ReadFile("/proc/self/limits"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "Max open files") {
+				if fields := strings.Fields(strings.TrimPrefix(line, "Max open files")); len(fields) > 0 {
+					if limit, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+						stats.maxFDs = limit
+					}
+				}
+			}
+		}
+	}
+	return stats
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuntimeCollectorSample(t *testing.T) {
+	rc := NewRuntimeCollector(RuntimeCollectorConfig{Interval: time.Hour, History: 3})
+	if _, ok := rc.Latest(); ok {
+		t.Fatal("Expected no samples before Collect")
+	}
+
+	runtime.GC()
+	sample := rc.Collect()
+	if sample.Goroutines == 0 || sample.HeapBytes == 0 || sample.GOMAXPROCS == 0 {
+		t.Errorf("Expected runtime values, got %+v", sample)
+	}
+	if sample.GCCycles == 0 || sample.GCPauses == 0 {
+		t.Errorf("Expected the forced GC to be recorded, got cycles=%d pauses=%d", sample.GCCycles, sample.GCPauses)
+	}
+	if runtime.GOOS == "linux" {
+		if sample.OpenFDs <= 0 || sample.MaxFDs <= 0 || sample.RSSBytes <= 0 || sample.Threads <= 0 || sample.CPUUserSeconds < 0 {
+			t.Errorf("Expected /proc values on linux, got %+v", sample)
+		}
+	}
+
+	// Sin GC entre muestras no hay pausas nuevas en el intervalo
+	if next := rc.Collect(); next.GCCycles == sample.GCCycles && next.GCPauses != 0 {
+		t.Errorf("Expected 0 pauses in an interval without GC, got %d", next.GCPauses)
+	}
+}
+
+func TestRuntimeCollectorHistoryRing(t *testing.T) {
+	rc := NewRuntimeCollector(RuntimeCollectorConfig{Interval: time.Hour, History: 3})
+	var taken []time.Time
+	for i := 0; i < 5; i++ {
+		taken = append(taken, rc.Collect().Time)
+	}
+
+	history := rc.History()
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 samples, got %d", len(history))
+	}
+	for i, sample := range history {
+		if !sample.Time.Equal(taken[i+2]) {
+			t.Errorf("Sample %d out of order: %v, want %v", i, sample.Time, taken[i+2])
+		}
+	}
+	if latest, _ := rc.Latest(); !latest.Time.Equal(taken[4]) {
+		t.Errorf("Latest should be the last sample")
+	}
+}
+
+func TestRuntimeCollectorStartStop(t *testing.T) {
+	rc := NewRuntimeCollector(RuntimeCollectorConfig{Interval: 10 * time.Millisecond, History: 100})
+	rc.Start()
+	time.Sleep(60 * time.Millisecond)
+	rc.Stop()
+	rc.Stop() // idempotente
+
+	n := len(rc.History())
+	if n < 2 {
+		t.Errorf("Expected periodic samples, got %d", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(rc.History()) != n {
+		t.Error("Collector kept sampling after Stop")
+	}
+}
+
+func TestRuntimeInMetricsFormats(t *testing.T) {
+	srv := NewServer("127.0.0.1:0", 2)
+	srv.jobManager.persistenceFile = ""
+
+	rt, ok := srv.GetMetrics()["runtime"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Missing runtime in metrics")
+	}
+	if latest := rt["latest"].(RuntimeSample); latest.Goroutines == 0 {
+		t.Errorf("Expected goroutines in latest sample, got %+v", latest)
+	}
+	if summary := srv.RuntimeSummary(); summary["goroutines"].(uint64) == 0 {
+		t.Errorf("Expected goroutines in summary, got %v", summary)
+	}
+
+	text := srv.PrometheusMetrics()
+	for _, family := range []string{"go_threads", "go_gc_pause_seconds", "process_start_time_seconds"} {
+		if runtime.GOOS != "linux" && family == "go_threads" {
+			continue
+		}
+		if !strings.Contains(text, "# TYPE "+family+" ") {
+			t.Errorf("Prometheus output missing %s", family)
+		}
+	}
+	if !strings.Contains(text, "# TYPE go_gc_pause_seconds summary") ||
+		!strings.Contains(text, "go_gc_pause_seconds_sum ") || !strings.Contains(text, "go_gc_pause_seconds_count ") {
+		t.Error("Expected go_gc_pause_seconds to be a summary with _sum and _count")
+	}
+	if runtime.GOOS == "linux" && !strings.Contains(text, "process_open_fds ") {
+		t.Error("Prometheus output missing process_open_fds")
+	}
+}
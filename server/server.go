@@ -1,13 +1,11 @@
 package server
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"log"
+	"math"
 	"net"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,12 +13,45 @@ import (
 
 // HTTPRequest representa una solicitud HTTP parseada
 type HTTPRequest struct {
-	Method  string
-	Path    string
-	Version string
-	Headers map[string]string
-	Body    string
-	Params  map[string]string
+	Method   string
+	Path     string
+	Version  string
+	Headers  map[string]string
+	Body     string
+	Params   map[string]string
+	Identity *Identity // Cliente autenticado (nil si no hay autenticación)
+	ID       string    // ID de la petición: el X-Request-Id del cliente o uno generado
+	ctx      context.Context
+	size     int64 // Bytes recibidos: request line, headers y body
+}
+
+// Context retorna el contexto de la petición; se cancela si el cliente se desconecta
+// (en rutas con coalescing) o si se cancela el job que la originó
+func (r *HTTPRequest) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// WithContext retorna una copia superficial de la petición con otro contexto
+func (r *HTTPRequest) WithContext(ctx context.Context) *HTTPRequest {
+	copied := *r
+	copied.ctx = ctx
+	return &copied
+}
+
+// GetHeader retorna el valor de un header sin distinguir mayúsculas/minúsculas
+func (r *HTTPRequest) GetHeader(name string) string {
+	if value, ok := r.Headers[name]; ok {
+		return value
+	}
+	for key, value := range r.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
 }
 
 // HTTPResponse representa una respuesta HTTP
@@ -29,51 +60,129 @@ type HTTPResponse struct {
 	StatusText string
 	Headers    map[string]string
 	Body       string
+	problem    *Problem // Error RFC 7807 del que salió la respuesta (ver finalizeResponse)
+	result     *Result  // Valor estructurado que se serializa según el formato pedido
 }
 
-// ConnectionTask representa una tarea de conexión a procesar
+// ConnectionTask representa una conexión con su petición ya parseada, lista para un worker
 type ConnectionTask struct {
 	Conn        net.Conn
 	ID          int64
 	EnqueueTime time.Time
+	Request     *HTTPRequest
+	Class       string          // Clase de planificación de la ruta (ver FairQueue)
+	breaker     *CircuitBreaker // Breaker que admitió la petición (nil si la ruta no tiene)
+	ticket      BreakerTicket   // Ticket de esa admisión, para informar el resultado
+}
+
+// SchedulingClass retorna la clase con la que FairQueue ordena la tarea
+func (t ConnectionTask) SchedulingClass() string {
+	return t.Class
 }
 
 // Server representa el servidor HTTP
 type Server struct {
-	addr           string
-	listener       net.Listener
-	workerPool     *WorkerPool
-	taskQueue      *TaskQueue
-	connCounter    *Counter
-	activeConns    *Counter
-	busyWorkers    *Counter
-	router         *Router
-	metricsManager *MetricsManager
-	jobManager     *JobManager
-	shutdownCh     chan struct{}
-	wg             sync.WaitGroup
-	maxHeaderBytes int
-	readTimeout    time.Duration
-	writeTimeout   time.Duration
+	addr             string
+	listener         net.Listener
+	bulkheads        map[string]*Bulkhead // Pools de workers con su cola, por nombre
+	poolRoutes       routeTable           // Asignación de rutas a pools
+	classRoutes      routeTable           // Asignación de rutas a clases de planificación
+	breakerRoutes    routeTable           // Asignación de rutas a circuit breakers
+	routeBreakers    *BreakerRegistry
+	cacheRoutes      routeTable             // Rutas con caché de respuestas
+	cachePolicies    map[string]*cacheRoute // Política y estadísticas por ruta cacheada
+	cache            *ResponseCache
+	coalesceRoutes   routeTable                // Rutas con coalescing de peticiones idénticas
+	coalescePolicies map[string]*coalesceRoute // Configuración y estadísticas por ruta
+	coalescer        *Coalescer
+	poolMu           sync.RWMutex
+	connCounter      *Counter
+	activeConns      *Counter
+	busyWorkers      *Counter
+	router           *Router
+	metricsManager   *MetricsManager
+	requests         *RequestRecorder  // Histogramas por método, endpoint y status (formato Prometheus)
+	runtimeStats     *RuntimeCollector // Muestras periódicas del runtime de Go y del proceso
+	health           *HealthRegistry   // Checks de /healthz y /readyz
+	jobManager       *JobManager
+	shutdownCh       chan struct{}
+	drainCh          chan struct{} // Se cierra al iniciar el shutdown: /readyz falla desde ahí
+	shutdownGrace    time.Duration // Tiempo entre dejar de estar listo y cerrar el listener
+	wg               sync.WaitGroup
+	maxHeaderBytes   int
+	readTimeout      time.Duration
+	writeTimeout     time.Duration
+	readLimits       ReadLimits
+	readerSlots      chan struct{}  // Semáforo de conexiones en etapa de lectura
+	connKills        *ReasonCounter // Conexiones cortadas por motivo
+	admission        AdmissionConfig
+	rejections       *ReasonCounter // Peticiones rechazadas con 503 por motivo
+	throughput       *throughputMeter
+	bypassRoutes     map[string]bool // Rutas atendidas sin pasar por la cola
+	bypassMu         sync.RWMutex
+	authManager      *AuthManager
+	routeDocs        map[string]RouteDoc // Metadata OpenAPI por "METHOD path"
+	apiInfo          APIInfo
+	docsMu           sync.RWMutex
 }
 
 // NewServer crea una nueva instancia del servidor
 func NewServer(addr string, poolSize int) *Server {
-	return &Server{
-		addr:           addr,
-		workerPool:     NewWorkerPool(poolSize),
-		taskQueue:      NewTaskQueue(1000),
+	if poolSize <= 0 {
+		poolSize = 10
+	}
+
+	s := &Server{
+		addr: addr,
+		bulkheads: map[string]*Bulkhead{
+			DefaultPool: newBulkhead(DefaultPool, WorkerPoolConfig{MinWorkers: poolSize, MaxWorkers: poolSize}, defaultQueueCapacity),
+		},
 		connCounter:    NewCounter(),
 		activeConns:    NewCounter(),
 		busyWorkers:    NewCounter(),
 		router:         NewRouter(),
 		metricsManager: NewMetricsManager(),
+		requests:       NewRequestRecorder(),
+		runtimeStats:   NewRuntimeCollector(DefaultRuntimeCollectorConfig()),
+		health:         NewHealthRegistry(),
 		jobManager:     NewJobManager(200, 60*time.Second, 120*time.Second, "jobs.json"),
 		shutdownCh:     make(chan struct{}),
+		drainCh:        make(chan struct{}),
 		maxHeaderBytes: 1 << 20,
 		readTimeout:    30 * time.Second,
 		writeTimeout:   30 * time.Second,
+		readLimits:     DefaultReadLimits(),
+		connKills: NewReasonCounter(KillIdleTimeout, KillHeaderTimeout, KillHeaderTooBig,
+			KillBodyTooSlow, KillBodyTooLarge, KillReaderLimit, KillMalformed),
+		admission:        DefaultAdmissionConfig(),
+		rejections:       NewReasonCounter(RejectQueueFull, RejectQueueTimeout, RejectShuttingDown, RejectCircuitOpen),
+		routeBreakers:    NewBreakerRegistry(DefaultBreakerConfig()),
+		throughput:       newThroughputMeter(10),
+		bypassRoutes:     make(map[string]bool),
+		cachePolicies:    make(map[string]*cacheRoute),
+		cache:            NewResponseCache(DefaultCacheConfig()),
+		coalescePolicies: make(map[string]*coalesceRoute),
+		coalescer:        NewCoalescer(),
+		routeDocs:        make(map[string]RouteDoc),
 	}
+	s.router.setInner(s.cacheMiddleware)
+	s.registerBuiltinChecks()
+	return s
+}
+
+// SetWorkerPoolConfig reemplaza el pool por defecto por uno elástico; debe llamarse antes de Start
+func (s *Server) SetWorkerPoolConfig(config WorkerPoolConfig) {
+	s.AddPool(DefaultPool, config, defaultQueueCapacity)
+}
+
+// GetWorkerPool retorna el pool de workers por defecto (p. ej. para redimensionarlo en tiempo de ejecución)
+func (s *Server) GetWorkerPool() *WorkerPool {
+	return s.GetPool(DefaultPool)
+}
+
+// SetReadLimits configura los límites de la etapa de lectura; debe llamarse antes de Start
+func (s *Server) SetReadLimits(limits ReadLimits) {
+	s.readLimits = limits
 }
 
 // Start inicia el servidor
@@ -86,8 +195,20 @@ func (s *Server) Start() error {
 
 	log.Printf("Servidor iniciado en %s", s.addr)
 
-	// Iniciar worker pool
-	s.workerPool.Start(s.taskQueue, s.processConnection)
+	maxReaders := s.readLimits.MaxReaders
+	if maxReaders <= 0 {
+		maxReaders = DefaultReadLimits().MaxReaders
+	}
+	s.readerSlots = make(chan struct{}, maxReaders)
+
+	// Iniciar un worker pool por bulkhead, cada uno sobre su propia cola
+	s.eachBulkhead(func(bh *Bulkhead) {
+		bh.pool.Start(bh.queue, func(task interface{}) {
+			s.processConnection(bh, task)
+		})
+	})
+
+	s.runtimeStats.Start()
 
 	// Aceptar conexiones
 	s.wg.Add(1)
@@ -96,6 +217,14 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// Addr retorna la dirección en la que escucha el servidor (útil con puerto ":0")
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
 // acceptConnections acepta nuevas conexiones entrantes
 func (s *Server) acceptConnections() {
 	defer s.wg.Done()
@@ -119,197 +248,159 @@ func (s *Server) acceptConnections() {
 			connID := s.connCounter.Increment()
 			s.activeConns.Increment()
 
-			task := ConnectionTask{
-				Conn:        conn,
-				ID:          connID,
-				EnqueueTime: time.Now(),
-			}
-
-			// Encolar tarea
-			if !s.taskQueue.Enqueue(task) {
-				log.Printf("Cola llena, rechazando conexión %d", connID)
-				conn.Close()
-				s.activeConns.Decrement()
+			// La petición se lee en su propia goroutine, sin ocupar un worker
+			select {
+			case s.readerSlots <- struct{}{}:
+				s.wg.Add(1)
+				go s.readConnection(conn, connID)
+			default:
+				s.connKills.Increment(KillReaderLimit)
+				log.Printf("Demasiadas conexiones en lectura, rechazando conexión %d", connID)
+				s.closeConnection(conn)
 			}
 		}
 	}
 }
 
-// parseRequest parsea una solicitud HTTP
-func (s *Server) parseRequest(conn net.Conn) (*HTTPRequest, error) {
-	// Configurar timeout para lectura
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-
-	reader := bufio.NewReader(conn)
-
-	// Leer request line con manejo de EOF
-	requestLineBytes, _, err := reader.ReadLine()
-	if err != nil {
-		if err == io.EOF {
-			// Conexión cerrada antes de enviar datos - no es un error grave
-			return nil, fmt.Errorf("connection closed by client")
-		}
-		return nil, fmt.Errorf("error reading request line: %v", err)
-	}
-
-	// Verificar que la línea no esté vacía
-	line := strings.TrimSpace(string(requestLineBytes))
-	if line == "" {
-		return nil, fmt.Errorf("empty request line")
-	}
-
-	// Parsear method, path, version
-	parts := strings.Fields(line)
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("malformed request line: %s", line)
-	}
-
-	paramsIndex := strings.Index(parts[1], "?")
-
-	// Safely determine the path portion (avoid slicing with -1)
-	path := parts[1]
-	if paramsIndex != -1 {
-		path = parts[1][:paramsIndex]
-	}
-
-	req := &HTTPRequest{
-		Method:  parts[0],
-		Path:    path,
-		Version: parts[2],
-		Headers: make(map[string]string),
-		Params:  make(map[string]string),
-	}
-
-	if paramsIndex != -1 {
-		// Parsear query string
-		queryString := parts[1][paramsIndex+1:]
-		for _, param := range strings.Split(queryString, "&") {
-			kv := strings.SplitN(param, "=", 2)
-			if len(kv) == 2 {
-				key := strings.TrimSpace(kv[0])
-				value := strings.TrimSpace(kv[1])
-				req.Params[key] = value
-			}
-		}
-	}
-
-	// Leer headers
-	for {
-		headerLineBytes, _, err := reader.ReadLine()
-		if err != nil {
-			if err == io.EOF {
-				break // Fin de headers
-			}
-			return nil, fmt.Errorf("error reading headers: %v", err)
-		}
+// processConnection procesa una conexión tomada de la cola de un bulkhead por uno de sus workers
+func (s *Server) processConnection(bh *Bulkhead, task interface{}) {
+	connTask := task.(ConnectionTask)
 
-		line := strings.TrimSpace(string(headerLineBytes))
-		if line == "" {
-			break // Fin de headers
-		}
+	// Incrementar workers ocupados
+	s.busyWorkers.Increment()
+	defer s.busyWorkers.Decrement()
 
-		// Parsear header
-		headerParts := strings.SplitN(line, ":", 2)
-		if len(headerParts) == 2 {
-			key := strings.TrimSpace(headerParts[0])
-			value := strings.TrimSpace(headerParts[1])
-			req.Headers[key] = value
-		}
-	}
+	// Calcular tiempo de espera en cola
+	waitTime := time.Since(connTask.EnqueueTime)
+	bh.pool.ObserveWait(waitTime)
 
-	// Leer body si existe Content-Length
-	body := ""
-	if contentLengthStr, ok := req.Headers["Content-Length"]; ok {
-		contentLength, err := strconv.Atoi(contentLengthStr)
-		if err == nil && contentLength > 0 {
-			bodyBytes := make([]byte, contentLength)
-			_, err := io.ReadFull(reader, bodyBytes)
-			if err != nil && err != io.EOF {
-				return nil, fmt.Errorf("error reading body: %v", err)
-			}
-			body = string(bodyBytes)
+	// Descartar peticiones que ya esperaron demasiado: el cliente probablemente desistió
+	if s.admission.MaxQueueWait > 0 && waitTime > s.admission.MaxQueueWait {
+		if connTask.breaker != nil {
+			connTask.breaker.Cancel(connTask.ticket)
 		}
+		s.shedRequest(connTask.Conn, connTask.ID, connTask.Request, RejectQueueTimeout)
+		return
 	}
 
-	req.Body = body
-	return req, nil
+	s.serveRequest(connTask.Conn, connTask.ID, connTask.Request, waitTime, connTask.breaker, connTask.ticket)
+	bh.throughput.Record()
 }
 
-// processConnection procesa una conexión individual con mejor error handling
-func (s *Server) processConnection(task interface{}) {
-	connTask := task.(ConnectionTask)
-	conn := connTask.Conn
-	connID := connTask.ID
-
-	// Incrementar workers ocupados
-	s.busyWorkers.Increment()
-	defer s.busyWorkers.Decrement()
-
+// serveRequest ejecuta el handler de una petición ya parseada, envía la respuesta y cierra la conexión.
+// Si la ruta tiene circuit breaker, le informa el resultado: 5xx, panic o lentitud cuentan como fallo.
+func (s *Server) serveRequest(conn net.Conn, connID int64, req *HTTPRequest, waitTime time.Duration, breaker *CircuitBreaker, ticket BreakerTicket) {
+	execStart := time.Now()
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Panic in connection %d: %v", connID, r)
+			if breaker != nil {
+				breaker.Record(ticket, fmt.Errorf("panic: %v", r), time.Since(execStart))
+			}
 		}
-		conn.Close()
-		s.activeConns.Decrement()
+		s.closeConnection(conn)
 	}()
 
-	// Calcular tiempo de espera en cola
-	dequeueTime := time.Now()
-	waitTime := dequeueTime.Sub(connTask.EnqueueTime)
-
-	// Configurar timeouts
-	conn.SetReadDeadline(time.Now().Add(s.readTimeout))
 	conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
 
-	// Parsear request con manejo de errores mejorado
-	req, err := s.parseRequest(conn)
-	if err != nil {
-		// Distinguir entre errores normales (EOF) y errores reales
-		if strings.Contains(err.Error(), "connection closed by client") {
-			// Log silencioso para conexiones cerradas por cliente
-			log.Printf("Connection %d: client disconnected", connID)
-		} else if strings.Contains(err.Error(), "EOF") {
-			// Log silencioso para EOF
-			log.Printf("Connection %d: premature EOF", connID)
-		} else {
-			// Log para errores reales
-			log.Printf("Error parsing request [conn:%d]: %v", connID, err)
-		}
-
-		// Intentar enviar respuesta de error si la conexión sigue activa
-		s.sendErrorResponse(conn, 400, "Bad Request")
-		return
+	// En rutas con coalescing, si el cliente se va deja de esperar la ejecución compartida
+	if s.coalesceRouteFor(req.Method, req.Path) != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req.ctx = ctx
+		defer watchDisconnect(conn, cancel)()
 	}
 
 	// Log para ver qué se está solicitando
 	log.Printf("Connection %d: %s %s", connID, req.Method, req.Path)
 
-	// Obtener métricas para este endpoint
-	endpoint := fmt.Sprintf("%s %s", req.Method, req.Path)
-	metrics := s.metricsManager.GetOrCreate(endpoint)
+	// Obtener métricas para la ruta (las no registradas comparten una sola entrada)
+	method, endpoint := s.endpointFor(req)
+	metrics := s.metricsManager.GetOrCreate(method + " " + endpoint)
 
 	// Registrar tiempo de espera en cola
 	metrics.RecordWaitTime(waitTime)
 	metrics.IncrementActive()
 
 	// Medir tiempo de ejecución del handler
-	execStart := time.Now()
-	response := s.router.Handle(req)
+	execStart = time.Now()
+	response := finalizeResponse(req, s.router.Handle(req))
 	execDuration := time.Since(execStart)
 
+	if breaker != nil {
+		var failure error
+		if response.StatusCode >= 500 {
+			failure = fmt.Errorf("status %d", response.StatusCode)
+		}
+		breaker.Record(ticket, failure, execDuration)
+	}
+
 	// Registrar métricas
 	metrics.RecordExecTime(execDuration)
 	metrics.DecrementActive()
+	s.throughput.Record()
+	s.requests.Record(method, endpoint, response.StatusCode, waitTime, execDuration)
 
 	// Enviar respuesta
-	err = s.sendResponse(conn, response)
+	written, err := s.writeResponse(conn, response)
 	if err != nil {
 		log.Printf("Error sending response [conn:%d]: %v", connID, err)
 	}
+	metrics.RecordResponse(response.StatusCode, req.size, int64(written))
+}
+
+// respond envía una respuesta que no pasó por un handler (p. ej. un rechazo 503)
+// y la registra en las métricas de la ruta
+func (s *Server) respond(conn net.Conn, req *HTTPRequest, resp *HTTPResponse) error {
+	written, err := s.writeResponse(conn, finalizeResponse(req, resp))
+	method, endpoint := s.endpointFor(req)
+	s.metricsManager.GetOrCreate(method+" "+endpoint).RecordResponse(resp.StatusCode, req.size, int64(written))
+	return err
+}
+
+// endpointFor retorna método y endpoint con los que se agrupan las métricas de la petición.
+// Las rutas no registradas van a unmatchedEndpoint y los métodos no estándar a "OTHER",
+// así un cliente no puede hacer crecer las métricas sin límite.
+func (s *Server) endpointFor(req *HTTPRequest) (string, string) {
+	if s.router.hasRoute(req.Method, req.Path) {
+		return req.Method, req.Path
+	}
+	switch req.Method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		return req.Method, unmatchedEndpoint
+	}
+	return "OTHER", unmatchedEndpoint
+}
+
+// isDraining indica si se llamó a Shutdown, aunque el servidor siga atendiendo durante
+// el período de gracia
+func (s *Server) isDraining() bool {
+	select {
+	case <-s.drainCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// isShuttingDown indica si terminó el período de gracia y el servidor dejó de aceptar peticiones
+func (s *Server) isShuttingDown() bool {
+	select {
+	case <-s.shutdownCh:
+		return true
+	default:
+		return false
+	}
 }
 
 // sendResponse con mejor error handling
 func (s *Server) sendResponse(conn net.Conn, resp *HTTPResponse) error {
+	_, err := s.writeResponse(conn, resp)
+	return err
+}
+
+// writeResponse serializa y envía la respuesta; retorna los bytes escritos
+func (s *Server) writeResponse(conn net.Conn, resp *HTTPResponse) (int, error) {
 	// Construir response HTTP
 	var response strings.Builder
 
@@ -331,35 +422,61 @@ func (s *Server) sendResponse(conn net.Conn, resp *HTTPResponse) error {
 
 	// Enviar respuesta con timeout
 	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-	_, err := conn.Write([]byte(response.String()))
-
-	return err
+	return conn.Write([]byte(response.String()))
 }
 
-// sendErrorResponse envía una respuesta de error simple
-func (s *Server) sendErrorResponse(conn net.Conn, statusCode int, statusText string) {
-	errorBody := fmt.Sprintf(`{"error":"%s"}`, statusText)
+// sendErrorResponse envía un problema para una petición que no se pudo parsear,
+// así que no hay path ni Accept: siempre va como problem+json
+func (s *Server) sendErrorResponse(conn net.Conn, statusCode int, detail string) {
+	req := &HTTPRequest{ID: newRequestID()}
+	s.sendResponse(conn, finalizeResponse(req, ErrorResponse(statusCode, detail)))
+}
 
-	response := &HTTPResponse{
-		StatusCode: statusCode,
-		StatusText: statusText,
-		Body:       errorBody,
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
+// HandleFunc registra un handler para un path específico. La metadata opcional
+// (resumen, parámetros, respuestas) se publica en la especificación OpenAPI.
+func (s *Server) HandleFunc(method, path string, handler HandlerFunc, doc ...RouteDoc) {
+	s.router.Register(method, path, handler)
+	if len(doc) > 0 {
+		s.setRouteDoc(method, path, doc[0])
 	}
+}
 
-	s.sendResponse(conn, response)
+// Use agrega un middleware que se ejecuta para todas las peticiones
+func (s *Server) Use(mw Middleware) {
+	s.router.Use(mw)
 }
 
-// HandleFunc registra un handler para un path específico
-func (s *Server) HandleFunc(method, path string, handler HandlerFunc) {
-	s.router.Register(method, path, handler)
+// SetAuthManager habilita autenticación y autorización para todas las rutas
+func (s *Server) SetAuthManager(am *AuthManager) {
+	s.authManager = am
+	s.router.Use(am.Middleware())
+}
+
+// GetAuthManager retorna el gestor de autenticación (nil si no está habilitado)
+func (s *Server) GetAuthManager() *AuthManager {
+	return s.authManager
 }
 
-// Shutdown detiene el servidor gracefully
+// SetShutdownGrace define cuánto tiempo sigue atendiendo el servidor después de que Shutdown
+// lo marca como no listo, para que el balanceador vea /readyz en 503 y deje de enviarle
+// tráfico antes de que se cierre el listener (0 = cerrar de inmediato)
+func (s *Server) SetShutdownGrace(grace time.Duration) {
+	s.shutdownGrace = grace
+}
+
+// Shutdown detiene el servidor gracefully: marca el servidor como no listo, sigue aceptando
+// conexiones durante el período de gracia (o hasta que venza ctx) y después cierra el
+// listener y espera a las peticiones en curso
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Iniciando shutdown...")
+	close(s.drainCh)
+	if s.shutdownGrace > 0 {
+		log.Printf("Drenando tráfico durante %v antes de cerrar el listener", s.shutdownGrace)
+		select {
+		case <-time.After(s.shutdownGrace):
+		case <-ctx.Done():
+		}
+	}
 	close(s.shutdownCh)
 
 	// Cerrar listener
@@ -367,11 +484,15 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		s.listener.Close()
 	}
 
-	// Detener JobManager
+	// Detener JobManager y el muestreo del runtime
 	s.jobManager.Shutdown()
+	s.runtimeStats.Stop()
 
-	// Detener worker pool
-	s.workerPool.Stop()
+	// Cerrar las colas (despierta a los workers que esperan) y detener los worker pools
+	s.eachBulkhead(func(bh *Bulkhead) {
+		bh.queue.Close()
+		bh.pool.Stop()
+	})
 
 	// Esperar a que terminen las goroutines
 	done := make(chan struct{})
@@ -391,32 +512,71 @@ func (s *Server) Shutdown(ctx context.Context) error {
 
 // GetStats retorna estadísticas del servidor
 func (s *Server) GetStats() map[string]int64 {
+	queueSize, _ := s.queueTotals()
 	return map[string]int64{
 		"total_connections":  s.connCounter.Get(),
 		"active_connections": s.activeConns.Get(),
-		"queue_size":         s.taskQueue.Size(),
+		"queue_size":         queueSize,
 	}
 }
 
+// RuntimeSummary retorna goroutines, memoria, CPU, descriptores y pausas de GC actuales
+func (s *Server) RuntimeSummary() map[string]interface{} {
+	return s.runtimeStats.Summary()
+}
+
 // GetMetrics retorna métricas detalladas del servidor
 func (s *Server) GetMetrics() map[string]interface{} {
-	stats := s.metricsManager.GetAllStats()
+	return s.GetMetricsWindow(DefaultStatsWindow)
+}
 
-	// Agregar métricas del worker pool
-	stats["worker_pool"] = map[string]interface{}{
-		"size":         s.workerPool.Size(),
-		"busy_workers": s.busyWorkers.Get(),
-		"idle_workers": int64(s.workerPool.Size()) - s.busyWorkers.Get(),
-	}
+// GetMetricsWindow retorna las métricas con las estadísticas por endpoint calculadas sobre window
+func (s *Server) GetMetricsWindow(window time.Duration) map[string]interface{} {
+	stats := s.metricsManager.GetAllStatsWindow(window)
+
+	// Tasa, errores y cuantiles de todos los endpoints juntos
+	stats["traffic"] = s.metricsManager.GlobalStatsWindow(window)
+
+	// Runtime de Go y proceso: última muestra e historia
+	stats["runtime"] = s.runtimeStats.Stats()
+
+	// Agregar métricas de los worker pools: totales y por bulkhead
+	pools := s.poolStats()
+	stats["worker_pool"] = aggregatePoolStats(pools, s.busyWorkers.Get())
+	stats["pools"] = pools
 
 	// Agregar métricas globales
+	queueSize, queueCapacity := s.queueTotals()
 	stats["global"] = map[string]interface{}{
 		"total_connections":  s.connCounter.Get(),
 		"active_connections": s.activeConns.Get(),
-		"queue_size":         s.taskQueue.Size(),
-		"queue_capacity":     s.taskQueue.Capacity(),
+		"queue_size":         queueSize,
+		"queue_capacity":     queueCapacity,
+		"reading_conns":      len(s.readerSlots),
 	}
 
+	// Conexiones cortadas en la etapa de lectura, por motivo
+	stats["connections_killed"] = s.connKills.Snapshot()
+
+	// Peticiones que no se pudieron parsear (respondidas con 400/408/413/431 o cortadas), por motivo
+	stats["parse_failures"] = s.metricsManager.ParseFailures()
+
+	// Peticiones rechazadas con 503 por el control de admisión
+	stats["rejections"] = s.rejections.Snapshot()
+	stats["throughput_rps"] = math.Round(s.throughput.Rate()*100) / 100
+
+	// Circuit breakers por ruta y por tarea del JobManager
+	stats["circuit_breakers"] = map[string]interface{}{
+		"routes": s.routeBreakers.Snapshot(),
+		"jobs":   s.jobManager.BreakerStats(),
+	}
+
+	// Caché de respuestas de rutas deterministas
+	stats["response_cache"] = s.CacheStats()
+
+	// Peticiones idénticas que compartieron una ejecución
+	stats["coalescing"] = s.CoalesceStats()
+
 	return stats
 }
 
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Scheduler indica cómo reparte las tareas un pool entre sus workers
+type Scheduler string
+
+const (
+	SchedulerShared       Scheduler = "shared"        // Una cola compartida por todos los workers
+	SchedulerWorkStealing Scheduler = "work_stealing" // Una cola local por worker con robo de trabajo
+	SchedulerFair         Scheduler = "fair"          // FairQueue por clases (ver SetScheduling)
+)
+
+// localDequeuer lo implementan las colas que entregan trabajo según el worker que lo pide
+type localDequeuer interface {
+	DequeueLocal(ctx context.Context, worker int) (interface{}, bool)
+	SetWorkers(workers int)
+}
+
+// stealingShard es la cola local de un worker
+type stealingShard struct {
+	mu     sync.Mutex
+	buffer []interface{} // Ring buffer
+	head   int
+	count  int
+	signal chan struct{} // Avisa al dueño que llegó trabajo a su cola
+}
+
+func (sh *stealingShard) push(item interface{}) bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.count >= len(sh.buffer) {
+		return false
+	}
+	sh.buffer[(sh.head+sh.count)%len(sh.buffer)] = item
+	sh.count++
+	return true
+}
+
+func (sh *stealingShard) pop() (interface{}, bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.count == 0 {
+		return nil, false
+	}
+	item := sh.buffer[sh.head]
+	sh.buffer[sh.head] = nil
+	sh.head = (sh.head + 1) % len(sh.buffer)
+	sh.count--
+	return item, true
+}
+
+// StealingQueue reparte las tareas en colas locales, una por worker. Enqueue distribuye
+// round-robin; cada worker atiende primero su cola y, si está vacía, roba la tarea más
+// antigua de otra. Los workers solo compiten por el mutex de una cola cuando roban.
+type StealingQueue struct {
+	shards   []*stealingShard
+	next     uint64 // Cursor round-robin de Enqueue (atómico)
+	workers  int64  // Workers vivos: Enqueue reparte entre sus colas (atómico)
+	count    int64  // Elementos en todas las colas (atómico)
+	idle     int64  // Workers esperando trabajo (atómico)
+	steals   int64  // Tareas tomadas de la cola de otro worker (atómico)
+	capacity int
+	wake     chan struct{} // Despierta workers ociosos para que roben
+	done     chan struct{}
+	closeMu  sync.Mutex
+	closed   int32 // (atómico)
+}
+
+// NewStealingQueue crea una cola con una cola local por worker; la capacidad se reparte entre ellas
+func NewStealingQueue(capacity, workers int) *StealingQueue {
+	if capacity <= 0 {
+		capacity = 100
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	perShard := (capacity + workers - 1) / workers
+	q := &StealingQueue{
+		shards:   make([]*stealingShard, workers),
+		workers:  int64(workers),
+		capacity: perShard * workers,
+		wake:     make(chan struct{}, workers),
+		done:     make(chan struct{}),
+	}
+	for i := range q.shards {
+		q.shards[i] = &stealingShard{
+			buffer: make([]interface{}, perShard),
+			signal: make(chan struct{}, 1),
+		}
+	}
+	return q
+}
+
+// SetWorkers fija cuántos workers vivos hay (los de id 0..workers-1). Enqueue reparte solo
+// entre sus colas; las de workers retirados se siguen vaciando por robo.
+func (q *StealingQueue) SetWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(q.shards) {
+		workers = len(q.shards)
+	}
+	atomic.StoreInt64(&q.workers, int64(workers))
+}
+
+// Enqueue agrega la tarea a la cola local del siguiente worker vivo (round-robin); si está
+// llena prueba las demás. Retorna false si todas están llenas o la cola está cerrada.
+func (q *StealingQueue) Enqueue(item interface{}) bool {
+	if atomic.LoadInt32(&q.closed) == 1 {
+		return false
+	}
+
+	// Contar antes de publicar para que Size nunca quede negativo
+	atomic.AddInt64(&q.count, 1)
+
+	start := int(atomic.AddUint64(&q.next, 1) % uint64(atomic.LoadInt64(&q.workers)))
+	for i := 0; i < len(q.shards); i++ {
+		shard := q.shards[(start+i)%len(q.shards)]
+		if !shard.push(item) {
+			continue
+		}
+
+		select {
+		case shard.signal <- struct{}{}:
+		default:
+		}
+		// Si hay workers ociosos, despertar a uno por si el dueño está ocupado
+		if atomic.LoadInt64(&q.idle) > 0 {
+			select {
+			case q.wake <- struct{}{}:
+			default:
+			}
+		}
+		return true
+	}
+
+	atomic.AddInt64(&q.count, -1)
+	return false
+}
+
+// Dequeue retorna una tarea para un consumidor sin cola propia
+func (q *StealingQueue) Dequeue(ctx context.Context) (interface{}, bool) {
+	return q.DequeueLocal(ctx, int(atomic.LoadUint64(&q.next)))
+}
+
+// DequeueLocal espera una tarea para el worker: primero de su cola y si no, robando.
+// Retorna false si el contexto se cancela, o si la cola se cerró y ya no quedan tareas.
+func (q *StealingQueue) DequeueLocal(ctx context.Context, worker int) (interface{}, bool) {
+	own := worker % len(q.shards)
+
+	for {
+		if item, ok := q.take(own); ok {
+			return item, true
+		}
+
+		// Anunciarse ocioso antes del último intento: un Enqueue concurrente
+		// o bien deja la tarea visible, o bien ve idle > 0 y despierta a alguien
+		atomic.AddInt64(&q.idle, 1)
+		if item, ok := q.take(own); ok {
+			atomic.AddInt64(&q.idle, -1)
+			return item, true
+		}
+		if atomic.LoadInt32(&q.closed) == 1 {
+			atomic.AddInt64(&q.idle, -1)
+			return nil, false
+		}
+
+		select {
+		case <-q.shards[own].signal:
+		case <-q.wake:
+		case <-q.done:
+		case <-ctx.Done():
+			atomic.AddInt64(&q.idle, -1)
+			return nil, false
+		}
+		atomic.AddInt64(&q.idle, -1)
+	}
+}
+
+// take saca una tarea de la cola propia o, si está vacía, roba de las demás
+func (q *StealingQueue) take(own int) (interface{}, bool) {
+	if item, ok := q.shards[own].pop(); ok {
+		atomic.AddInt64(&q.count, -1)
+		return item, true
+	}
+	for i := 1; i < len(q.shards); i++ {
+		if item, ok := q.shards[(own+i)%len(q.shards)].pop(); ok {
+			atomic.AddInt64(&q.count, -1)
+			atomic.AddInt64(&q.steals, 1)
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// Size retorna la cantidad total de tareas en las colas locales
+func (q *StealingQueue) Size() int64 {
+	return atomic.LoadInt64(&q.count)
+}
+
+// Capacity retorna la capacidad total (suma de las colas locales)
+func (q *StealingQueue) Capacity() int64 {
+	return int64(q.capacity)
+}
+
+// Steals retorna cuántas tareas se tomaron de la cola de otro worker
+func (q *StealingQueue) Steals() int64 {
+	return atomic.LoadInt64(&q.steals)
+}
+
+// Close cierra la cola: rechaza nuevas tareas y despierta a todos los workers en espera
+func (q *StealingQueue) Close() {
+	q.closeMu.Lock()
+	defer q.closeMu.Unlock()
+	if atomic.CompareAndSwapInt32(&q.closed, 0, 1) {
+		close(q.done)
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStealingQueueDistributesAndSteals(t *testing.T) {
+	q := NewStealingQueue(8, 4)
+	if q.Capacity() != 8 {
+		t.Errorf("Expected capacity 8, got %d", q.Capacity())
+	}
+	for i := 0; i < 8; i++ {
+		if !q.Enqueue(i) {
+			t.Fatalf("Enqueue %d failed", i)
+		}
+	}
+	if q.Enqueue(8) {
+		t.Error("Enqueue should fail when every local queue is full")
+	}
+
+	// Round-robin: cada cola local recibió dos tareas
+	for i, shard := range q.shards {
+		if shard.count != 2 {
+			t.Errorf("Shard %d has %d tasks, expected 2", i, shard.count)
+		}
+	}
+
+	// Un solo worker vacía su cola y después roba todo lo demás
+	seen := make(map[int]bool)
+	for i := 0; i < 8; i++ {
+		item, ok := q.DequeueLocal(context.Background(), 0)
+		if !ok {
+			t.Fatalf("DequeueLocal %d failed", i)
+		}
+		seen[item.(int)] = true
+	}
+	if len(seen) != 8 || q.Steals() != 6 || q.Size() != 0 {
+		t.Errorf("Expected 8 distinct tasks with 6 steals, got %d tasks, %d steals, size %d", len(seen), q.Steals(), q.Size())
+	}
+}
+
+func TestStealingQueueIdleWorkerIsWoken(t *testing.T) {
+	q := NewStealingQueue(10, 2)
+
+	// El worker 1 espera; la tarea cae en la cola del worker 0, que no está consumiendo
+	got := make(chan interface{})
+	go func() {
+		item, _ := q.DequeueLocal(context.Background(), 1)
+		got <- item
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	atomic.StoreUint64(&q.next, 1) // El próximo Enqueue va a la cola (1+1)%2 == 0
+	q.Enqueue("task")
+
+	select {
+	case item := <-got:
+		if item != "task" {
+			t.Errorf("Expected task, got %v", item)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Idle worker was not woken to steal the task")
+	}
+}
+
+func TestStealingQueueCloseWakesWorkers(t *testing.T) {
+	q := NewStealingQueue(10, 4)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			q.DequeueLocal(context.Background(), w)
+		}(w)
+	}
+	time.Sleep(20 * time.Millisecond)
+	q.Close()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake all workers")
+	}
+	if q.Enqueue("late") {
+		t.Error("Enqueue should fail on a closed queue")
+	}
+}
+
+func TestWorkStealingPool(t *testing.T) {
+	bh := newBulkhead("test", WorkerPoolConfig{MinWorkers: 4, Scheduler: SchedulerWorkStealing}, 100)
+	if _, ok := bh.queue.(*StealingQueue); !ok {
+		t.Fatalf("Expected a StealingQueue, got %T", bh.queue)
+	}
+
+	var processed int64
+	var wg sync.WaitGroup
+	wg.Add(200)
+	bh.pool.Start(bh.queue, func(task interface{}) {
+		atomic.AddInt64(&processed, 1)
+		wg.Done()
+	})
+	for i := 0; i < 200; {
+		if bh.queue.Enqueue(i) {
+			i++
+		}
+	}
+	wg.Wait()
+	bh.queue.Close()
+	bh.pool.Stop()
+
+	if processed != 200 {
+		t.Errorf("Expected 200 tasks processed, got %d", processed)
+	}
+	if bh.Stats()["scheduler"] != SchedulerWorkStealing {
+		t.Errorf("Unexpected scheduler in stats: %v", bh.Stats()["scheduler"])
+	}
+}
+
+func TestStealingQueueDistributesAmongLiveWorkers(t *testing.T) {
+	q := NewStealingQueue(16, 8)
+	q.SetWorkers(2)
+	for i := 0; i < 4; i++ {
+		q.Enqueue(i)
+	}
+
+	// Solo las colas de los workers vivos reciben tareas, así cada uno toma de la suya sin robar
+	for i, shard := range q.shards {
+		expected := 0
+		if i < 2 {
+			expected = 2
+		}
+		if shard.count != expected {
+			t.Errorf("Shard %d has %d tasks, expected %d", i, shard.count, expected)
+		}
+	}
+	for _, worker := range []int{0, 0, 1, 1} {
+		if _, ok := q.DequeueLocal(context.Background(), worker); !ok {
+			t.Fatalf("DequeueLocal for worker %d failed", worker)
+		}
+	}
+	if q.Steals() != 0 {
+		t.Errorf("Expected no steals, got %d", q.Steals())
+	}
+}
+
+func TestWorkStealingPoolReusesWorkerSlots(t *testing.T) {
+	bh := newBulkhead("test", WorkerPoolConfig{MinWorkers: 2, MaxWorkers: 8, Scheduler: SchedulerWorkStealing}, 80)
+	queue := bh.queue.(*StealingQueue)
+	bh.pool.Start(queue, func(task interface{}) {})
+	defer bh.pool.Stop()
+
+	// Tras crecer y achicar, los workers vivos siguen ocupando los ids 0..n-1
+	bh.pool.Resize(6)
+	bh.pool.Resize(2)
+	bh.pool.Resize(3)
+
+	bh.pool.mu.Lock()
+	for id := 0; id < 3; id++ {
+		if bh.pool.workers[id] == nil {
+			t.Errorf("Expected a live worker with id %d, got %v", id, bh.pool.workers)
+		}
+	}
+	bh.pool.mu.Unlock()
+	if workers := atomic.LoadInt64(&queue.workers); workers != 3 {
+		t.Errorf("Expected the queue to distribute among 3 workers, got %d", workers)
+	}
+}
+
+// benchmarkScheduler encola b.N tareas cortas en un pool de 8 workers y reporta
+// throughput y la latencia p50/p99 desde que la tarea se encola hasta que se procesa
+func benchmarkScheduler(b *testing.B, scheduler Scheduler) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	bh := newBulkhead("bench", WorkerPoolConfig{MinWorkers: 8, Scheduler: scheduler}, 1024)
+
+	latencies := make([]time.Duration, b.N)
+	var wg sync.WaitGroup
+	wg.Add(b.N)
+	bh.pool.Start(bh.queue, func(task interface{}) {
+		t := task.(benchTask)
+		latencies[t.id] = time.Since(t.enqueued)
+		wg.Done()
+	})
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; {
+		if bh.queue.Enqueue(benchTask{id: i, enqueued: time.Now()}) {
+			i++
+		} else {
+			runtime.Gosched() // Cola llena: ceder el procesador a los workers
+		}
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	b.StopTimer()
+
+	bh.queue.Close()
+	bh.pool.Stop()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	b.ReportMetric(float64(b.N)/elapsed.Seconds(), "tasks/s")
+	b.ReportMetric(float64(latencies[b.N/2].Microseconds()), "p50-µs")
+	b.ReportMetric(float64(latencies[b.N*99/100].Microseconds()), "p99-µs")
+}
+
+type benchTask struct {
+	id       int
+	enqueued time.Time
+}
+
+func BenchmarkSchedulerShared(b *testing.B) {
+	benchmarkScheduler(b, SchedulerShared)
+}
+
+func BenchmarkSchedulerWorkStealing(b *testing.B) {
+	benchmarkScheduler(b, SchedulerWorkStealing)
+}
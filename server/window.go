@@ -0,0 +1,167 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatsWindows son las ventanas que /metrics resume para cada endpoint y globalmente
+var StatsWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// ParseStatsWindow interpreta el parámetro ?window= ("1m", "5m", "90s"...). La ventana debe
+// estar entre un intervalo del ring y lo que el ring retiene.
+func ParseStatsWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultStatsWindow, nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q", value)
+	}
+	config := DefaultHistogramWindowConfig()
+	limit := config.Slot * time.Duration(config.Slots)
+	if window < config.Slot || window > limit {
+		return 0, fmt.Errorf("window must be between %s and %s", config.Slot, limit)
+	}
+	return window, nil
+}
+
+// counterSlot guarda los contadores de un intervalo; epoch identifica el intervalo
+type counterSlot struct {
+	epoch  int64 // (atómico)
+	values []int64
+}
+
+// WindowedCounter mantiene varios contadores por intervalo en un ring, con la misma
+// rotación que WindowedHistogram, para calcular tasas sobre los últimos N minutos.
+type WindowedCounter struct {
+	config  HistogramWindowConfig
+	slots   []counterSlot
+	rotate  sync.Mutex
+	created time.Time
+	now     func() time.Time
+}
+
+// NewWindowedCounter crea un ring de n contadores por intervalo
+func NewWindowedCounter(config HistogramWindowConfig, n int) *WindowedCounter {
+	if config.Slot <= 0 || config.Slots <= 0 {
+		config = DefaultHistogramWindowConfig()
+	}
+	w := &WindowedCounter{
+		config:  config,
+		slots:   make([]counterSlot, config.Slots),
+		created: time.Now(),
+		now:     time.Now,
+	}
+	for i := range w.slots {
+		w.slots[i] = counterSlot{epoch: -1, values: make([]int64, n)}
+	}
+	return w
+}
+
+// epoch retorna el número de intervalo de un instante
+func (w *WindowedCounter) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(w.config.Slot)
+}
+
+// Add suma delta al contador i del intervalo actual
+func (w *WindowedCounter) Add(i int, delta int64) {
+	epoch := w.epoch(w.now())
+	slot := &w.slots[epoch%int64(len(w.slots))]
+
+	if atomic.LoadInt64(&slot.epoch) != epoch {
+		w.rotate.Lock()
+		if atomic.LoadInt64(&slot.epoch) != epoch {
+			for j := range slot.values {
+				atomic.StoreInt64(&slot.values[j], 0)
+			}
+			atomic.StoreInt64(&slot.epoch, epoch)
+		}
+		w.rotate.Unlock()
+	}
+	atomic.AddInt64(&slot.values[i], delta)
+}
+
+// Sums retorna la suma de cada contador sobre la ventana (acotada a la del ring)
+func (w *WindowedCounter) Sums(window time.Duration) []int64 {
+	current := w.epoch(w.now())
+	n := w.slotsFor(window)
+
+	sums := make([]int64, len(w.slots[0].values))
+	for i := range w.slots {
+		slot := &w.slots[i]
+		epoch := atomic.LoadInt64(&slot.epoch)
+		if epoch <= current-n || epoch > current {
+			continue
+		}
+		for j := range sums {
+			sums[j] += atomic.LoadInt64(&slot.values[j])
+		}
+	}
+	return sums
+}
+
+// Covered retorna el tiempo que realmente cubre la ventana (ver coveredWindow)
+func (w *WindowedCounter) Covered(window time.Duration) time.Duration {
+	return coveredWindow(w.config, w.created, w.now(), window)
+}
+
+// slotsFor retorna cuántos intervalos abarca la ventana
+func (w *WindowedCounter) slotsFor(window time.Duration) int64 {
+	return windowSlots(w.config, window)
+}
+
+// windowSlots retorna cuántos intervalos del ring abarca la ventana
+func windowSlots(config HistogramWindowConfig, window time.Duration) int64 {
+	n := int64((window + config.Slot - 1) / config.Slot)
+	if n < 1 {
+		n = 1
+	}
+	if n > int64(config.Slots) {
+		n = int64(config.Slots)
+	}
+	return n
+}
+
+// coveredWindow retorna el tiempo que realmente cubre una ventana: el intervalo actual
+// está en curso y un contador creado hace poco todavía no llenó la ventana. Es el
+// denominador de las tasas, así un endpoint nuevo no reporta rps artificialmente bajos.
+func coveredWindow(config HistogramWindowConfig, created, now time.Time, window time.Duration) time.Duration {
+	n := windowSlots(config, window)
+	elapsedInSlot := time.Duration(now.UnixNano() % int64(config.Slot))
+	covered := time.Duration(n-1)*config.Slot + elapsedInSlot
+	if age := now.Sub(created); age < covered {
+		covered = age
+	}
+	if covered < time.Second {
+		covered = time.Second
+	}
+	return covered
+}
+
+// Índices de los contadores de tráfico por intervalo
+const (
+	trafficRequests = iota
+	trafficClientErrors
+	trafficErrors
+	trafficCounters
+)
+
+// trafficStats resume una ventana: volumen, tasa, errores y cuantiles de ejecución
+func trafficStats(counts []int64, covered time.Duration, exec *HistogramSnapshot) map[string]interface{} {
+	requests := counts[trafficRequests]
+	return map[string]interface{}{
+		"requests":          requests,
+		"rps":               math.Round(float64(requests)/covered.Seconds()*1000) / 1000,
+		"errors":            counts[trafficErrors],
+		"error_rate":        ratio(counts[trafficErrors], requests),
+		"client_errors":     counts[trafficClientErrors],
+		"client_error_rate": ratio(counts[trafficClientErrors], requests),
+		"p50_ms":            durationMs(exec.Quantile(0.50)),
+		"p95_ms":            durationMs(exec.Quantile(0.95)),
+		"p99_ms":            durationMs(exec.Quantile(0.99)),
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowedCounterSums(t *testing.T) {
+	config := HistogramWindowConfig{Slot: time.Second, Slots: 10}
+	now := time.Unix(1000, 0)
+	w := NewWindowedCounter(config, 2)
+	w.created = now.Add(-time.Hour)
+	w.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		w.Add(0, 1)
+		if i%2 == 0 {
+			w.Add(1, 1)
+		}
+		now = now.Add(time.Second)
+	}
+	// Último intervalo escrito: 1009; ahora es 1010, todavía sin observaciones
+	if sums := w.Sums(3 * time.Second); sums[0] != 2 || sums[1] != 1 {
+		t.Errorf("Expected [2 1] over 3s, got %v", sums)
+	}
+	if sums := w.Sums(time.Minute); sums[0] != 9 {
+		t.Errorf("Expected the ring to retain 9 intervals, got %v", sums)
+	}
+
+	// Un intervalo reutilizado empieza desde cero
+	now = now.Add(5 * time.Second)
+	w.Add(0, 7)
+	if sums := w.Sums(time.Second); sums[0] != 7 {
+		t.Errorf("Expected recycled slot to hold 7, got %v", sums)
+	}
+}
+
+func TestCoveredWindow(t *testing.T) {
+	config := HistogramWindowConfig{Slot: 15 * time.Second, Slots: 60}
+	now := time.Unix(1500, 0).Add(5 * time.Second) // 5s dentro del intervalo actual
+
+	if got := coveredWindow(config, now.Add(-time.Hour), now, time.Minute); got != 50*time.Second {
+		t.Errorf("Expected 50s covered for 1m, got %v", got)
+	}
+	// Un contador de 10s cubre solo 10s aunque se pida 5m
+	if got := coveredWindow(config, now.Add(-10*time.Second), now, 5*time.Minute); got != 10*time.Second {
+		t.Errorf("Expected 10s covered for a young counter, got %v", got)
+	}
+}
+
+func TestGlobalStatsWindow(t *testing.T) {
+	mm := NewMetricsManager()
+	for _, status := range []int{200, 200, 500} {
+		mm.GetOrCreate("GET /a").RecordResponse(status, 0, 0)
+	}
+	mm.GetOrCreate("GET /b").RecordResponse(404, 0, 0)
+	mm.GetOrCreate("GET /b").RecordExecTime(2 * time.Millisecond)
+
+	stats := mm.GlobalStatsWindow(time.Minute)
+	if stats["requests"].(int64) != 4 || stats["errors"].(int64) != 1 || stats["client_errors"].(int64) != 1 {
+		t.Errorf("Unexpected global counts: %v", stats)
+	}
+	if stats["error_rate"].(float64) != 0.25 {
+		t.Errorf("Expected error rate 0.25, got %v", stats["error_rate"])
+	}
+	if stats["rps"].(float64) <= 0 {
+		t.Errorf("Expected positive rps, got %v", stats["rps"])
+	}
+	if p99 := stats["p99_ms"].(float64); p99 < 1.9 || p99 > 2.1 {
+		t.Errorf("Expected p99 near 2ms, got %v", p99)
+	}
+	windows := stats["windows"].(map[string]interface{})
+	for _, key := range []string{"1m", "5m", "15m"} {
+		if _, ok := windows[key]; !ok {
+			t.Errorf("Missing %s summary in %v", key, windows)
+		}
+	}
+}
+
+func TestParseStatsWindow(t *testing.T) {
+	if w, err := ParseStatsWindow(""); err != nil || w != DefaultStatsWindow {
+		t.Errorf("Expected default window, got %v, %v", w, err)
+	}
+	if w, err := ParseStatsWindow("1m"); err != nil || w != time.Minute {
+		t.Errorf("Expected 1m, got %v, %v", w, err)
+	}
+	for _, bad := range []string{"abc", "-5m", "2s", "16m"} {
+		if _, err := ParseStatsWindow(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
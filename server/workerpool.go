@@ -1,54 +1,137 @@
 package server
 
 import (
+	"context"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// WorkerPool maneja un pool de workers para procesar tareas
+// Motivos de los eventos de escalado
+const (
+	ScaleReasonQueueDepth = "queue_depth" // La cola superó ScaleUpQueueDepth
+	ScaleReasonQueueWait  = "queue_wait"  // La espera en cola superó ScaleUpWait
+	ScaleReasonIdle       = "idle"        // Hubo workers ociosos durante ScaleDownIdle
+	ScaleReasonManual     = "manual"      // Resize o SetBounds
+)
+
+// maxScalingEvents es la cantidad de eventos recientes que se guardan para /metrics
+const maxScalingEvents = 20
+
+// WorkerPoolConfig configura los límites y el autoescalado del pool
+type WorkerPoolConfig struct {
+	MinWorkers        int           // Workers que siempre se mantienen
+	MaxWorkers        int           // Tope de workers
+	ScaleUpQueueDepth int           // Crecer si hay al menos estas tareas en cola (0 = no usar)
+	ScaleUpWait       time.Duration // Crecer si alguna tarea esperó en cola más que esto (0 = no usar)
+	ScaleUpStep       int           // Workers que se agregan por evento de crecimiento
+	ScaleDownIdle     time.Duration // Tiempo con workers ociosos y cola vacía antes de achicar
+	CheckInterval     time.Duration // Frecuencia de evaluación del autoescalado
+	Scheduler         Scheduler     // Cola compartida (por defecto) o work stealing
+}
+
+// DefaultWorkerPoolConfig retorna una configuración elástica entre min y max workers
+func DefaultWorkerPoolConfig(min, max int) WorkerPoolConfig {
+	return WorkerPoolConfig{
+		MinWorkers:        min,
+		MaxWorkers:        max,
+		ScaleUpQueueDepth: 10,
+		ScaleUpWait:       100 * time.Millisecond,
+		ScaleUpStep:       4,
+		ScaleDownIdle:     30 * time.Second,
+		CheckInterval:     500 * time.Millisecond,
+	}
+}
+
+// ScalingEvent registra un cambio de tamaño del pool
+type ScalingEvent struct {
+	Time   time.Time `json:"time"`
+	From   int       `json:"from"`
+	To     int       `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// WorkerPool maneja un pool de workers para procesar tareas. Si MaxWorkers > MinWorkers
+// el pool crece y se achica según la carga de la cola.
 type WorkerPool struct {
-	size       int
-	workers    []*Worker
+	config    WorkerPoolConfig
+	mu        sync.Mutex
+	workers   map[int]*Worker // Por id; los vivos ocupan siempre los ids 0..len-1
+	target    int
+	queue     Queue
+	processor TaskProcessor
+	started   bool
+	scaling   bool // El loop de autoescalado está corriendo
+
+	busy      int64 // Workers procesando una tarea (atómico)
+	live      int64 // Goroutines de workers corriendo, incluidos los retirados que terminan su tarea (atómico)
+	maxWait   int64 // Mayor espera en cola observada desde la última evaluación, en ns (atómico)
+	idleSince time.Time
+
+	scaleUps   int64
+	scaleDowns int64
+	events     []ScalingEvent
+
 	wg         sync.WaitGroup
 	stopCh     chan struct{}
+	ctx        context.Context // Se cancela en Stop y despierta a los workers bloqueados en Dequeue
+	cancel     context.CancelFunc
 	stoppedMux sync.Mutex
 	stopped    bool
 }
 
 // Worker representa un worker individual
 type Worker struct {
-	id   int
-	pool *WorkerPool
+	id     int
+	pool   *WorkerPool
+	ctx    context.Context
+	cancel context.CancelFunc // Retira este worker al achicar el pool
 }
 
 // TaskProcessor es una función que procesa una tarea
 type TaskProcessor func(task interface{})
 
-// NewWorkerPool crea un nuevo pool de workers
+// NewWorkerPool crea un nuevo pool de tamaño fijo
 func NewWorkerPool(size int) *WorkerPool {
 	if size <= 0 {
 		size = 10
 	}
+	return NewElasticWorkerPool(WorkerPoolConfig{MinWorkers: size, MaxWorkers: size})
+}
 
-	pool := &WorkerPool{
-		size:    size,
-		workers: make([]*Worker, size),
-		stopCh:  make(chan struct{}),
-		stopped: false,
+// NewElasticWorkerPool crea un pool que escala entre MinWorkers y MaxWorkers
+func NewElasticWorkerPool(config WorkerPoolConfig) *WorkerPool {
+	if config.MinWorkers <= 0 {
+		config.MinWorkers = 1
 	}
-
-	for i := 0; i < size; i++ {
-		pool.workers[i] = &Worker{
-			id:   i,
-			pool: pool,
-		}
+	if config.MaxWorkers < config.MinWorkers {
+		config.MaxWorkers = config.MinWorkers
+	}
+	if config.ScaleUpStep <= 0 {
+		config.ScaleUpStep = 1
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 500 * time.Millisecond
+	}
+	if config.Scheduler != SchedulerWorkStealing {
+		config.Scheduler = SchedulerShared
 	}
 
-	return pool
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WorkerPool{
+		config:  config,
+		workers: make(map[int]*Worker),
+		target:  config.MinWorkers,
+		stopCh:  make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: false,
+	}
 }
 
-// Start inicia todos los workers del pool
-func (wp *WorkerPool) Start(queue *TaskQueue, processor TaskProcessor) {
+// Start inicia los workers del pool y, si corresponde, el autoescalado
+func (wp *WorkerPool) Start(queue Queue, processor TaskProcessor) {
 	wp.stoppedMux.Lock()
 	if wp.stopped {
 		wp.stoppedMux.Unlock()
@@ -56,12 +139,46 @@ func (wp *WorkerPool) Start(queue *TaskQueue, processor TaskProcessor) {
 	}
 	wp.stoppedMux.Unlock()
 
-	for _, worker := range wp.workers {
-		wp.wg.Add(1)
-		go worker.start(queue, processor, &wp.wg, wp.stopCh)
+	wp.mu.Lock()
+	wp.queue = queue
+	wp.processor = processor
+	wp.started = true
+	for len(wp.workers) < wp.target {
+		wp.spawnLocked()
 	}
+	wp.syncQueueLocked()
+	size := len(wp.workers)
+	elastic := wp.startAutoscaleLocked()
+	wp.mu.Unlock()
 
-	log.Printf("Worker pool iniciado con %d workers", wp.size)
+	if elastic {
+		log.Printf("Worker pool iniciado con %d workers (elástico hasta %d)", size, wp.config.MaxWorkers)
+		return
+	}
+
+	log.Printf("Worker pool iniciado con %d workers", size)
+}
+
+// startAutoscaleLocked arranca el loop de autoescalado si el pool ya inició, es elástico y
+// el loop no está corriendo. Retorna si el pool es elástico. Requiere wp.mu.
+func (wp *WorkerPool) startAutoscaleLocked() bool {
+	if wp.config.MaxWorkers <= wp.config.MinWorkers {
+		return false
+	}
+	if !wp.started || wp.scaling {
+		return true
+	}
+
+	// El lock evita sumar al WaitGroup después de que Stop empezó a esperarlo
+	wp.stoppedMux.Lock()
+	defer wp.stoppedMux.Unlock()
+	if wp.stopped {
+		return true
+	}
+	wp.scaling = true
+	wp.wg.Add(1)
+	go wp.autoscaleLoop()
+	return true
 }
 
 // Stop detiene todos los workers del pool
@@ -75,37 +192,264 @@ func (wp *WorkerPool) Stop() {
 	wp.stoppedMux.Unlock()
 
 	close(wp.stopCh)
+	wp.cancel()
 	wp.wg.Wait()
 	log.Println("Worker pool detenido")
 }
 
-// start inicia el loop de procesamiento del worker
-func (w *Worker) start(queue *TaskQueue, processor TaskProcessor, wg *sync.WaitGroup, stopCh chan struct{}) {
-	defer wg.Done()
+// Resize fija el tamaño del pool en tiempo de ejecución, acotado a [MinWorkers, MaxWorkers].
+// Con autoescalado activo, el pool sigue ajustándose a partir del nuevo tamaño.
+func (wp *WorkerPool) Resize(size int) int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if size < wp.config.MinWorkers {
+		size = wp.config.MinWorkers
+	}
+	if size > wp.config.MaxWorkers {
+		size = wp.config.MaxWorkers
+	}
+	wp.resizeLocked(size, ScaleReasonManual)
+	return wp.target
+}
+
+// SetBounds cambia los límites del pool; el tamaño actual se ajusta si queda fuera de ellos.
+// Si un pool fijo pasa a ser elástico arranca el autoescalado, con los umbrales de
+// DefaultWorkerPoolConfig si no tenía ninguno configurado.
+func (wp *WorkerPool) SetBounds(min, max int) {
+	if min <= 0 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.config.MinWorkers = min
+	wp.config.MaxWorkers = max
+	switch {
+	case wp.target < min:
+		wp.resizeLocked(min, ScaleReasonManual)
+	case wp.target > max:
+		wp.resizeLocked(max, ScaleReasonManual)
+	}
+
+	config := &wp.config
+	if max > min && config.ScaleUpQueueDepth == 0 && config.ScaleUpWait == 0 && config.ScaleDownIdle == 0 {
+		defaults := DefaultWorkerPoolConfig(min, max)
+		config.ScaleUpQueueDepth = defaults.ScaleUpQueueDepth
+		config.ScaleUpWait = defaults.ScaleUpWait
+		config.ScaleUpStep = defaults.ScaleUpStep
+		config.ScaleDownIdle = defaults.ScaleDownIdle
+	}
+	wp.startAutoscaleLocked()
+}
+
+// ObserveWait informa el tiempo que una tarea esperó en cola; alimenta el autoescalado
+func (wp *WorkerPool) ObserveWait(wait time.Duration) {
+	for {
+		current := atomic.LoadInt64(&wp.maxWait)
+		if int64(wait) <= current || atomic.CompareAndSwapInt64(&wp.maxWait, current, int64(wait)) {
+			return
+		}
+	}
+}
+
+// resizeLocked cambia el tamaño objetivo y arranca o retira workers. Requiere wp.mu.
+func (wp *WorkerPool) resizeLocked(size int, reason string) {
+	if size == wp.target {
+		return
+	}
+
+	from := wp.target
+	wp.target = size
+	if size > from {
+		wp.scaleUps++
+	} else {
+		wp.scaleDowns++
+	}
+
+	wp.events = append(wp.events, ScalingEvent{Time: time.Now(), From: from, To: size, Reason: reason})
+	if len(wp.events) > maxScalingEvents {
+		wp.events = wp.events[len(wp.events)-maxScalingEvents:]
+	}
+	log.Printf("Worker pool: %d -> %d workers (%s)", from, size, reason)
+
+	if !wp.started {
+		return
+	}
+
+	for len(wp.workers) < size {
+		wp.spawnLocked()
+	}
+
+	// Se retiran los de id más alto; terminan la tarea en curso antes de salir
+	for len(wp.workers) > size {
+		id := len(wp.workers) - 1
+		wp.workers[id].cancel()
+		delete(wp.workers, id)
+	}
+	wp.syncQueueLocked()
+}
+
+// syncQueueLocked informa a una cola con colas locales cuántos workers vivos hay, para que
+// reparta solo entre las suyas. Requiere wp.mu.
+func (wp *WorkerPool) syncQueueLocked() {
+	if local, ok := wp.queue.(localDequeuer); ok {
+		local.SetWorkers(len(wp.workers))
+	}
+}
+
+// spawnLocked arranca un worker nuevo con el primer id libre, así cada worker vivo es dueño
+// de una cola local distinta con work stealing. Requiere wp.mu.
+func (wp *WorkerPool) spawnLocked() {
+	ctx, cancel := context.WithCancel(wp.ctx)
+	worker := &Worker{
+		id:     len(wp.workers),
+		pool:   wp,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	wp.workers[worker.id] = worker
+
+	atomic.AddInt64(&wp.live, 1)
+	wp.wg.Add(1)
+	go worker.start(wp.queue, wp.processor, &wp.wg)
+}
+
+// autoscaleLoop evalúa periódicamente si el pool debe crecer o achicarse
+func (wp *WorkerPool) autoscaleLoop() {
+	defer wp.wg.Done()
+
+	ticker := time.NewTicker(wp.config.CheckInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
-		case <-stopCh:
+		case <-wp.stopCh:
+			return
+		case now := <-ticker.C:
+			wp.autoscale(now)
+		}
+	}
+}
+
+// autoscale crece ante cola profunda o esperas largas y se achica tras un período ocioso
+func (wp *WorkerPool) autoscale(now time.Time) {
+	depth := wp.queue.Size()
+	wait := time.Duration(atomic.SwapInt64(&wp.maxWait, 0))
+	busy := int(atomic.LoadInt64(&wp.busy))
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	config := wp.config
+	size := wp.target
+
+	switch {
+	case config.ScaleUpQueueDepth > 0 && depth >= int64(config.ScaleUpQueueDepth):
+		wp.idleSince = time.Time{}
+		wp.growLocked(ScaleReasonQueueDepth)
+	case config.ScaleUpWait > 0 && wait >= config.ScaleUpWait:
+		wp.idleSince = time.Time{}
+		wp.growLocked(ScaleReasonQueueWait)
+	case depth == 0 && busy < size:
+		if wp.idleSince.IsZero() {
+			wp.idleSince = now
+			return
+		}
+		if config.ScaleDownIdle <= 0 || now.Sub(wp.idleSince) < config.ScaleDownIdle || size <= config.MinWorkers {
+			return
+		}
+
+		// Retirar la mitad de los workers ociosos (al menos uno) sin bajar del mínimo
+		remove := (size - busy) / 2
+		if remove < 1 {
+			remove = 1
+		}
+		newSize := size - remove
+		if newSize < config.MinWorkers {
+			newSize = config.MinWorkers
+		}
+		wp.resizeLocked(newSize, ScaleReasonIdle)
+		wp.idleSince = now
+	default:
+		wp.idleSince = time.Time{}
+	}
+}
+
+// growLocked agrega ScaleUpStep workers sin pasar del máximo. Requiere wp.mu.
+func (wp *WorkerPool) growLocked(reason string) {
+	newSize := wp.target + wp.config.ScaleUpStep
+	if newSize > wp.config.MaxWorkers {
+		newSize = wp.config.MaxWorkers
+	}
+	wp.resizeLocked(newSize, reason)
+}
+
+// start inicia el loop de procesamiento del worker. Termina cuando se cancela su
+// contexto (Stop o achique del pool) o cuando la cola se cierra vacía.
+func (w *Worker) start(queue Queue, processor TaskProcessor, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer atomic.AddInt64(&w.pool.live, -1)
+	defer w.cancel()
+
+	// Con work stealing cada worker consume de su propia cola local
+	dequeue := queue.Dequeue
+	if local, ok := queue.(localDequeuer); ok {
+		dequeue = func(ctx context.Context) (interface{}, bool) {
+			return local.DequeueLocal(ctx, w.id)
+		}
+	}
+
+	for w.ctx.Err() == nil {
+		task, ok := dequeue(w.ctx)
+		if !ok {
 			return
-		default:
-			task, ok := queue.Dequeue()
-			if !ok {
-				// Cola vacía, esperar por señal
-				select {
-				case <-stopCh:
-					return
-				case <-queue.NotEmpty():
-					continue
-				}
-			}
-
-			// Procesar tarea
-			processor(task)
 		}
+
+		// Procesar tarea
+		atomic.AddInt64(&w.pool.busy, 1)
+		processor(task)
+		atomic.AddInt64(&w.pool.busy, -1)
 	}
 }
 
-// Size retorna el tamaño del pool
+// Size retorna la cantidad de workers corriendo; puede superar al objetivo mientras
+// los retirados terminan su tarea
 func (wp *WorkerPool) Size() int {
-	return wp.size
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if !wp.started {
+		return wp.target
+	}
+	return int(atomic.LoadInt64(&wp.live))
+}
+
+// Stats retorna el estado del pool y su historial de escalado
+func (wp *WorkerPool) Stats() map[string]interface{} {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	size := wp.target
+	if wp.started {
+		size = int(atomic.LoadInt64(&wp.live))
+	}
+	events := make([]ScalingEvent, len(wp.events))
+	copy(events, wp.events)
+
+	return map[string]interface{}{
+		"size":           size,
+		"target_size":    wp.target,
+		"busy":           atomic.LoadInt64(&wp.busy),
+		"min_size":       wp.config.MinWorkers,
+		"max_size":       wp.config.MaxWorkers,
+		"elastic":        wp.config.MaxWorkers > wp.config.MinWorkers,
+		"scheduler":      wp.config.Scheduler,
+		"scale_ups":      wp.scaleUps,
+		"scale_downs":    wp.scaleDowns,
+		"scaling_events": events,
+	}
 }
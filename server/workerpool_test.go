@@ -0,0 +1,136 @@
+package server
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitForPoolSize(t *testing.T, pool *WorkerPool, size int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if pool.Size() == size {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected pool size %d, got %d", size, pool.Size())
+}
+
+func TestWorkerPoolAutoscaling(t *testing.T) {
+	queue := NewTaskQueue(100)
+	release := make(chan struct{})
+
+	pool := NewElasticWorkerPool(WorkerPoolConfig{
+		MinWorkers:        1,
+		MaxWorkers:        4,
+		ScaleUpQueueDepth: 2,
+		ScaleUpStep:       2,
+		ScaleDownIdle:     100 * time.Millisecond,
+		CheckInterval:     20 * time.Millisecond,
+	})
+	pool.Start(queue, func(task interface{}) { <-release })
+	defer pool.Stop()
+
+	waitForPoolSize(t, pool, 1)
+
+	// Tareas bloqueadas: la cola se acumula y el pool debe crecer hasta el máximo
+	for i := 0; i < 10; i++ {
+		queue.Enqueue(i)
+	}
+	waitForPoolSize(t, pool, 4)
+
+	// Al liberar las tareas el pool queda ocioso y vuelve al mínimo
+	close(release)
+	waitForPoolSize(t, pool, 1)
+
+	stats := pool.Stats()
+	if stats["scale_ups"].(int64) < 1 || stats["scale_downs"].(int64) < 1 {
+		t.Errorf("Expected scale up and scale down events, got %v", stats)
+	}
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	queue := NewTaskQueue(10)
+	processed := make(chan interface{}, 10)
+
+	pool := NewElasticWorkerPool(WorkerPoolConfig{MinWorkers: 2, MaxWorkers: 6})
+	pool.Start(queue, func(task interface{}) { processed <- task })
+	defer pool.Stop()
+
+	if got := pool.Resize(5); got != 5 {
+		t.Errorf("Expected target 5, got %d", got)
+	}
+	waitForPoolSize(t, pool, 5)
+
+	// Fuera de los límites se acota
+	if got := pool.Resize(100); got != 6 {
+		t.Errorf("Expected Resize to clamp to 6, got %d", got)
+	}
+	if got := pool.Resize(0); got != 2 {
+		t.Errorf("Expected Resize to clamp to 2, got %d", got)
+	}
+	waitForPoolSize(t, pool, 2)
+
+	// Los workers restantes siguen procesando
+	queue.Enqueue("task")
+	select {
+	case <-processed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Task was not processed after shrinking")
+	}
+
+	pool.SetBounds(3, 3)
+	waitForPoolSize(t, pool, 3)
+	if events := pool.Stats()["scaling_events"].([]ScalingEvent); len(events) != 4 {
+		t.Errorf("Expected 4 scaling events, got %d", len(events))
+	}
+}
+
+func TestWorkerPoolSetBoundsStartsAutoscaling(t *testing.T) {
+	queue := NewTaskQueue(100)
+	release := make(chan struct{})
+
+	pool := NewWorkerPool(1)
+	pool.Start(queue, func(task interface{}) { <-release })
+	defer pool.Stop()
+	defer close(release)
+
+	// Un pool fijo que pasa a ser elástico debe crecer con la cola acumulada
+	pool.SetBounds(1, 4)
+	for i := 0; i < 20; i++ {
+		queue.Enqueue(i)
+	}
+	waitForPoolSize(t, pool, 4)
+
+	if stats := pool.Stats(); stats["scale_ups"].(int64) < 1 {
+		t.Errorf("Expected a scale up event, got %v", stats)
+	}
+}
+
+func TestWorkerPoolStatsCountRetiringWorkers(t *testing.T) {
+	queue := NewTaskQueue(100)
+	release := make(chan struct{})
+	pool := NewElasticWorkerPool(WorkerPoolConfig{MinWorkers: 1, MaxWorkers: 4})
+	pool.Start(queue, func(task interface{}) { <-release })
+	defer pool.Stop()
+
+	pool.Resize(4)
+	for i := 0; i < 4; i++ {
+		queue.Enqueue(i)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt64(&pool.busy) < 4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Los workers retirados siguen corriendo hasta terminar su tarea
+	pool.Resize(1)
+	if stats := pool.Stats(); stats["size"] != 4 || stats["target_size"] != 1 {
+		t.Errorf("Expected size 4 and target_size 1 while retiring, got %v and %v", stats["size"], stats["target_size"])
+	}
+
+	close(release)
+	waitForPoolSize(t, pool, 1)
+}